@@ -8,6 +8,8 @@ import (
 	"io"
 	"net"
 	"os"
+	"os/signal"
+	"strings"
 	"syscall"
 	"umemory/internal"
 	"umemory/internal/network"
@@ -15,6 +17,32 @@ import (
 	"go.uber.org/zap"
 )
 
+const commandsHelp = `
+Commands:
+  set key value [EX seconds|PX milliseconds] || get key || delete key
+  mget key [key ...] || mset key value [key value ...] || msetnx key value [key value ...] || mdel key [key ...]
+  history key || getat key version|timestamp || revert key version
+  index create name ON prefix FIELD path || index drop name || find index value || findrange index min max
+  expire key seconds || ttl key || pttl key || persist key
+  incr key || decr key || incrby key increment || decrby key decrement || incrbyfloat key increment
+  lpush key value [value ...] || rpush key value [value ...] || lpop key || rpop key
+  llen key || lrange key start stop || lindex key index || lrem key count value || ltrim key start stop
+  hset key field value [field value ...] || hget key field || hdel key field [field ...] || hgetall key
+  hkeys key || hvals key || hlen key || hexists key field || hincrby key field increment
+  sadd key member [member ...] || srem key member [member ...] || smembers key || sismember key member
+  scard key || spop key [count] || srandmember key [count]
+  sinter|sunion|sdiff key [key ...] || sinterstore|sunionstore|sdiffstore destination key [key ...]
+  zadd key score member [score member ...] || zrem key member [member ...] || zscore|zrank|zrevrank key member
+  zincrby key increment member || zrange|zrevrange key start stop [WITHSCORES]
+  zrangebyscore key min max [WITHSCORES] || zcount key min max
+  scan cursor [MATCH pattern] [COUNT count] || keys pattern || dbsize
+  range|revrange start end [LIMIT count] || prefix prefix [LIMIT count]
+  subscribe channel [channel ...] || psubscribe pattern [pattern ...] || publish channel message
+  unsubscribe [channel ...] || punsubscribe [pattern ...]
+  select index || move key db || swapdb index1 index2 || flushdb || flushall
+  multi || exec || discard || watch key [key ...] || unwatch || cas key expected value
+  save || bgsave || info [engine|memory|compression|replication]`
+
 func main() {
 	cfg, err := internal.GetConfig()
 	if err != nil {
@@ -56,16 +84,14 @@ func main() {
 
 	fmt.Println("\nSave/Get/Delete value by key")
 	fmt.Println(`key/value available symbols: [a-zA-Zа-яА-Я0-9!?,.;:\"\'\ *#-=_@+№%$^/\|[]]`)
+	fmt.Println(commandsHelp)
 
+	lines := readLines(bufferReader, logger)
 	for {
-		fmt.Println("\nCommands: set key value || get key || delete key")
-		fmt.Print("Your command: ")
-
-		request, err := bufferReader.ReadBytes('\n')
-		if err != nil && err != io.EOF {
-			logger.Error("Arguments read error", zap.Error(err))
-			fmt.Println("Arguments read error")
+		fmt.Print("\nYour command: ")
 
+		request, ok := <-lines
+		if !ok {
 			return
 		}
 
@@ -83,5 +109,139 @@ func main() {
 
 		fmt.Print("Response: ")
 		fmt.Println(string(response))
+
+		if subscribed(string(response)) && !receiveMessages(tcpClient, lines, logger) {
+			return
+		}
 	}
 }
+
+// readLines reads the commands from stdin in the background, so the cli
+// can wait for them together with pushed messages. The channel is closed
+// at the end of the input.
+func readLines(reader *bufio.Reader, logger *zap.Logger) <-chan []byte {
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				lines <- line
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				logger.Error("Arguments read error", zap.Error(err))
+				fmt.Println("Arguments read error")
+
+				return
+			}
+		}
+	}()
+
+	return lines
+}
+
+// subscribed reports whether the response confirms a subscription, then
+// the server pushes messages and accepts only subscription commands.
+func subscribed(response string) bool {
+	return strings.HasPrefix(response, "1) subscribe\n") || strings.HasPrefix(response, "1) psubscribe\n")
+}
+
+// unsubscribed reports whether the last reply of the response confirms
+// that no subscriptions are left, so the server left the push mode.
+func unsubscribed(response string) bool {
+	lines := strings.Split(strings.TrimRight(response, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(lines[i], "1) ") {
+			continue
+		}
+		reply := lines[i:]
+
+		return len(reply) == 3 &&
+			(reply[0] == "1) unsubscribe" || reply[0] == "1) punsubscribe") &&
+			reply[2] == "3) 0"
+	}
+
+	return false
+}
+
+// receiveMessages prints messages published to the subscribed channels as
+// they arrive, while sending the subscription commands typed meanwhile. It
+// returns true once everything is unsubscribed and false when the cli
+// should exit: on quit, Ctrl-C, the end of the input or a closed
+// connection.
+func receiveMessages(tcpClient *network.TCPClient, lines <-chan []byte, logger *zap.Logger) bool {
+	fmt.Println("\nReading messages... (unsubscribe to leave, quit or Ctrl-C to exit)")
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	// left is closed by the reader once everything is unsubscribed or the
+	// connection is closed, closed tells the two cases apart. exiting is
+	// closed when the cli closes the connection itself.
+	left := make(chan struct{})
+	exiting := make(chan struct{})
+	defer close(exiting)
+	var closed bool
+	go func() {
+		defer close(left)
+
+		for {
+			message, err := tcpClient.Receive()
+			if err != nil {
+				select {
+				case <-exiting:
+					return
+				default:
+				}
+				if !errors.Is(err, io.EOF) {
+					logger.Error("Receive message error", zap.Error(err))
+				}
+				fmt.Println("Connection was closed")
+				closed = true
+
+				return
+			}
+
+			// pushed messages end with a new line, responses do not
+			fmt.Print(string(message))
+			if !strings.HasSuffix(string(message), "\n") {
+				fmt.Println()
+			}
+			if unsubscribed(string(message)) {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-left:
+			return !closed
+		case <-interrupt:
+			fmt.Println()
+
+			return false
+		case request, ok := <-lines:
+			if !ok || quit(string(request)) {
+				return false
+			}
+			if err := tcpClient.Write(request); err != nil {
+				logger.Error("Send client request error", zap.Error(err))
+				fmt.Println("Send client request error")
+
+				return false
+			}
+		}
+	}
+}
+
+func quit(request string) bool {
+	command := strings.ToLower(strings.TrimSpace(request))
+
+	return command == "quit" || command == "exit"
+}
@@ -9,7 +9,10 @@ import (
 	"umemory/internal"
 	"umemory/internal/compute"
 	"umemory/internal/network"
+	"umemory/internal/replication"
+	"umemory/internal/snapshot"
 	"umemory/internal/storage"
+	"umemory/internal/wal"
 
 	"github.com/joho/godotenv"
 
@@ -42,6 +45,141 @@ func main() {
 	}
 	defer logger.Sync()
 
+	if cfg.Engine.ShardsCount > 0 && (cfg.Engine.EngineType == storage.InMemoryEngine || cfg.Engine.EngineType == storage.OrderedEngine) {
+		// these engines keep the whole keyspace in a single shard
+		logger.Warn("engine.shards_count has no effect on the engine", zap.String("engine", cfg.Engine.EngineType), zap.Int("shards_count", cfg.Engine.ShardsCount))
+		fmt.Printf("engine.shards_count has no effect on the %s engine, use the sharded engine\n", cfg.Engine.EngineType)
+	}
+
+	databases, err := storage.NewDatabases(cfg, logger)
+	if err != nil {
+		logger.Error("Create storage engine error", zap.Error(err))
+		fmt.Println("Create storage engine error: " + err.Error())
+
+		return
+	}
+	defer databases.Close()
+	// every database is an engine of the same type, the first one tells
+	// which features they support
+	engine := databases.DB(0)
+	requestParser := compute.NewRequestParser()
+	handler := compute.NewComputeHandler(engine, requestParser, logger)
+	handler.SetDatabases(databases)
+	if err := handler.SetKeyspaceEvents(cfg.Notifications.KeyspaceEvents); err != nil {
+		logger.Error("Enable keyspace events error", zap.Error(err))
+		fmt.Println("Enable keyspace events error: " + err.Error())
+
+		return
+	}
+
+	if cfg.Replication.Role == replication.RoleFollower && (cfg.WAL.Directory != "" || cfg.Snapshot.Directory != "") {
+		// a follower gets its keyspace from the leader on every start
+		logger.Info("WAL and snapshots are disabled on a replication follower")
+		cfg.WAL.Directory = ""
+		cfg.Snapshot.Directory = ""
+	}
+
+	if capable, ok := engine.(compute.CapableStorage); ok && capable.Capabilities().Persistent &&
+		(cfg.WAL.Directory != "" || cfg.Snapshot.Directory != "") {
+		// the engine keeps its data on disk and logs writes by itself
+		logger.Info("WAL and snapshots are disabled for a persistent storage engine", zap.String("engine", cfg.Engine.EngineType))
+		cfg.WAL.Directory = ""
+		cfg.Snapshot.Directory = ""
+	}
+
+	var (
+		snapshots  *snapshot.Manager
+		walSegment int
+	)
+	if cfg.Snapshot.Directory != "" {
+		if _, ok := engine.(snapshot.Storage); !ok {
+			logger.Error("Storage engine does not support snapshots", zap.String("engine", cfg.Engine.EngineType))
+			fmt.Println("Storage engine does not support snapshots: " + cfg.Engine.EngineType)
+
+			return
+		}
+
+		snapshots, err = snapshot.NewManager(cfg, databases, logger)
+		if err != nil {
+			logger.Error("Create snapshot manager error", zap.Error(err))
+			fmt.Println("Create snapshot manager error: " + err.Error())
+
+			return
+		}
+
+		walSegment, err = snapshots.Load()
+		if err != nil {
+			logger.Error("Load snapshot error", zap.Error(err))
+			fmt.Println("Load snapshot error: " + err.Error())
+
+			return
+		}
+		snapshots.SetBlocker(handler)
+		handler.SetSnapshotter(snapshots)
+	}
+
+	var writeAheadLog *wal.WAL
+	if cfg.WAL.Directory != "" {
+		writeAheadLog, err = wal.NewWAL(cfg, logger)
+		if err != nil {
+			logger.Error("Create wal error", zap.Error(err))
+			fmt.Println("Create wal error: " + err.Error())
+
+			return
+		}
+		defer writeAheadLog.Close()
+
+		if err := writeAheadLog.Replay(walSegment, handler.Apply); err != nil {
+			logger.Error("Replay wal error", zap.Error(err))
+			fmt.Println("Replay wal error: " + err.Error())
+
+			return
+		}
+		handler.SetWAL(writeAheadLog)
+		if snapshots != nil {
+			snapshots.SetWAL(writeAheadLog)
+		}
+	}
+
+	var replicationNode interface{ Run(ctx context.Context) }
+	switch cfg.Replication.Role {
+	case "":
+	case replication.RoleLeader, replication.RoleFollower:
+		if _, ok := engine.(replication.Storage); !ok {
+			logger.Error("Storage engine does not support replication", zap.String("engine", cfg.Engine.EngineType))
+			fmt.Println("Storage engine does not support replication: " + cfg.Engine.EngineType)
+
+			return
+		}
+
+		if cfg.Replication.Role == replication.RoleLeader {
+			leader, err := replication.NewLeader(cfg, databases, handler, logger)
+			if err != nil {
+				logger.Error("Create replication leader error", zap.Error(err))
+				fmt.Println("Create replication leader error: " + err.Error())
+
+				return
+			}
+			handler.SetReplication(leader)
+			replicationNode = leader
+		} else {
+			follower, err := replication.NewFollower(cfg, databases, handler, logger)
+			if err != nil {
+				logger.Error("Create replication follower error", zap.Error(err))
+				fmt.Println("Create replication follower error: " + err.Error())
+
+				return
+			}
+			handler.SetReplication(follower)
+			replicationNode = follower
+		}
+	default:
+		logger.Error("Unknown replication role", zap.String("role", cfg.Replication.Role))
+		fmt.Println("Unknown replication role: " + cfg.Replication.Role)
+
+		return
+	}
+
 	server, err := network.NewTCPServer(cfg, logger)
 	if err != nil {
 		logger.Error("Create tcp server error", zap.Error(err))
@@ -50,22 +188,55 @@ func main() {
 		return
 	}
 
-	storage := storage.NewInMemoryStorage()
-	requestParser := compute.NewRequestParser()
-	handler := compute.NewComputeHandler(storage, requestParser, logger)
-
 	group, groupCtx := errgroup.WithContext(ctx)
 
+	group.Go(func() error {
+		databases.Run(groupCtx)
+
+		return nil
+	})
+
+	if writeAheadLog != nil {
+		group.Go(func() error {
+			writeAheadLog.Run(groupCtx)
+
+			return nil
+		})
+	}
+
+	if replicationNode != nil {
+		group.Go(func() error {
+			replicationNode.Run(groupCtx)
+
+			return nil
+		})
+	}
+
+	if snapshots != nil {
+		group.Go(func() error {
+			snapshots.Run(groupCtx)
+
+			return nil
+		})
+	}
+
 	group.Go(func() error {
 		server.Handle(groupCtx, handler)
 
 		return nil
 	})
 
-	if group.Wait() != nil {
+	if err := group.Wait(); err != nil {
 		logger.Error("Server wait error", zap.Error(err))
 		fmt.Println("Server wait error")
 
 		return
 	}
+
+	if snapshots != nil {
+		if err := snapshots.Save(); err != nil {
+			logger.Error("Save snapshot on shutdown error", zap.Error(err))
+			fmt.Println("Save snapshot on shutdown error: " + err.Error())
+		}
+	}
 }
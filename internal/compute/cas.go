@@ -0,0 +1,38 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errCASNotSupported = errors.New("Storage engine does not support cas")
+
+// compareAndSet sets a new value when the key holds the expected one. The
+// command is journaled as is, replaying it on the same keyspace gives the
+// same result.
+func (c *dbHandler) compareAndSet(args []string) (string, error) {
+	storage, ok := c.storage.(VersionedStorage)
+	if !ok {
+		c.logger.Error("storage does not implement VersionedStorage")
+
+		return "", errCASNotSupported
+	}
+
+	key, expected, value := args[0], args[1], args[2]
+	swapped, err := storage.CompareAndSet(key, expected, value)
+	if err != nil {
+		return "", err
+	}
+	if !swapped {
+		fmt.Printf("Value of %s is not %s\n", key, expected)
+
+		return "0", nil
+	}
+	if err := c.journal(CASCmd, args...); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Value %s saved\n", value)
+
+	return "1", nil
+}
@@ -1,17 +1,45 @@
 package compute
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"umemory/internal/network"
+	"umemory/internal/pubsub"
+	"umemory/internal/storage"
 
 	"go.uber.org/zap"
 )
 
 type ComputeHandler struct{
 	storage Storage
+	databases Databases
 	requestParser Parser
 	logger *zap.Logger
+
+	// writeMu keeps the order of writes in the storage, in the write-ahead
+	// log and in the replication stream the same.
+	writeMu sync.Mutex
+	wal WAL
+	replication ReplicationNode
+
+	snapshotter Snapshotter
+	broker *pubsub.Broker
+	keyspaceEvents eventClass
+
+	// txMu is held for reading by every executed command and for writing
+	// by exec, so transactions are applied in isolation.
+	txMu sync.RWMutex
 }
 
+var (
+	errSnapshotsDisabled = errors.New("Snapshots are disabled")
+	errReadOnlyReplica = errors.New("Write commands are not allowed on a read-only replica")
+	errNotifyingStorage = errors.New("Storage engine does not support expiration events")
+)
+
 func NewComputeHandler(
 	storage Storage,
 	requestParser Parser,
@@ -21,21 +49,138 @@ func NewComputeHandler(
 		storage: storage,
 		requestParser: requestParser,
 		logger: logger,
+		broker: pubsub.NewBroker(),
 	}
 }
 
-func (c *ComputeHandler) Handle(requestStr string) (string, error) {
+// SetWAL enables logging of every successful write command. It must be
+// called before the handler starts serving requests.
+func (c *ComputeHandler) SetWAL(wal WAL) {
+	c.wal = wal
+}
+
+// SetReplication makes the handler propagate writes to the replication
+// node and reject client writes when the node is a read-only follower.
+func (c *ComputeHandler) SetReplication(node ReplicationNode) {
+	c.replication = node
+}
+
+// SetSnapshotter enables the save and bgsave commands.
+func (c *ComputeHandler) SetSnapshotter(snapshotter Snapshotter) {
+	c.snapshotter = snapshotter
+}
+
+// BlockWrites runs fn while no write command is being applied. Write
+// commands take the lock only when they are journaled or snapshots are
+// saved, the cases where the journal position or the snapshot has to match
+// the keyspace.
+func (c *ComputeHandler) BlockWrites(fn func() error) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return fn()
+}
+
+// Handle executes a client request. Commands sent after multi are queued
+// in the session until exec or discard, a nil session handles the request
+// without connection state.
+func (c *ComputeHandler) Handle(session *network.Session, requestStr string) (string, error) {
 	command, args, err := c.requestParser.ParseArgs(requestStr)
 	if err != nil {
 		c.logger.Error("requestParser.ParseArgs error", zap.Error(err))
 		fmt.Printf("Arguments parse error: %s", err.Error())
+		failTransaction(session)
 		
 		return "", fmt.Errorf("Arguments parse error: %s", err.Error())
 	}
 
+	if session != nil && session.PushMode() && !isSubscribeCommand(command) {
+		return "", errSubscribeMode
+	}
+
+	switch command {
+	case MultiCmd:
+		return c.multi(session)
+	case ExecCmd:
+		return c.exec(session)
+	case DiscardCmd:
+		return c.discard(session)
+	case WatchCmd:
+		return c.watch(session, args)
+	case UnwatchCmd:
+		return c.unwatch(session)
+	case SubscribeCmd, PSubscribeCmd:
+		return c.subscribe(session, command, args)
+	case UnsubscribeCmd, PUnsubscribeCmd:
+		return c.unsubscribe(session, command, args)
+	case SelectCmd:
+		return c.selectDB(session, args)
+	}
+
+	if c.replication != nil && c.replication.ReadOnly() && IsWriteCommand(command) {
+		failTransaction(session)
+
+		return "", errReadOnlyReplica
+	}
+
+	if tx := transactionOf(session); tx != nil {
+		tx.commands = append(tx.commands, queuedCommand{command: command, args: args})
+
+		return queuedReply, nil
+	}
+
+	defer c.lock(command)()
+
+	db := c.db(selectedDB(session))
+	if growsMemory(command) {
+		if err := db.freeMemory(); err != nil {
+			return "", err
+		}
+	}
+
+	return db.execute(command, args)
+}
+
+// Apply executes an already parsed command, it is used to replay commands
+// restored from the write-ahead log or received from the replication leader.
+// Commands of other databases than the first one come wrapped in select.
+func (c *ComputeHandler) Apply(command string, args []string) error {
+	if err := c.requestParser.Validate(command, args); err != nil {
+		return fmt.Errorf("Arguments validate error: %w", err)
+	}
+
+	index := 0
+	if command == SelectCmd {
+		if len(args) < 2 {
+			return nil
+		}
+
+		var err error
+		if index, err = c.dbIndex(args[0]); err != nil {
+			return err
+		}
+		command, args = args[1], args[2:]
+	}
+
+	defer c.lock(command)()
+
+	_, err := c.db(index).execute(command, args)
+
+	return err
+}
+
+func (c *dbHandler) execute(command string, args []string) (string, error) {
+	if c.blocksWrites() && IsWriteCommand(command) {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+	}
+
 	switch command {
 	case GetCmd:
 		v, found := c.storage.Get(args[0])
+		if !found && c.holdsOtherType(args[0], storage.StringType) {
+			return "", storage.ErrWrongType
+		}
 		if !found {
 			c.logger.Error("storage.Get error: value not found")
 			fmt.Printf("Value by key=%s not found\n", args[0])
@@ -47,18 +192,171 @@ func (c *ComputeHandler) Handle(requestStr string) (string, error) {
 
 		return v, nil
 	case SetCmd:
+		if len(args) == 4 {
+			return c.setWithTTL(args)
+		}
+
 		c.storage.Set(args[0], args[1])
+		if err := c.journal(SetCmd, args[0], args[1]); err != nil {
+			return "", err
+		}
 
 		fmt.Printf("Value %s saved\n", args[1])
 
 		return "saved", nil
 	case DeleteCmd:
 		c.storage.Delete(args[0])
+		if err := c.journal(DeleteCmd, args[0]); err != nil {
+			return "", err
+		}
 
 		fmt.Printf("Value %s deleted\n", args[0])
 
 		return "deleted", nil
+	case ExpireCmd:
+		return c.expire(args)
+	case PExpireAtCmd:
+		return c.pexpireAt(args)
+	case TTLCmd:
+		return c.ttl(args[0], time.Second)
+	case PTTLCmd:
+		return c.ttl(args[0], time.Millisecond)
+	case PersistCmd:
+		return c.persist(args[0])
+	case InfoCmd:
+		return c.info(args)
+	case CASCmd:
+		return c.compareAndSet(args)
+	case PublishCmd:
+		return c.publish(args[0], args[1])
+	case MoveCmd:
+		return c.move(args)
+	case SwapDBCmd:
+		return c.swapDB(args)
+	case FlushDBCmd:
+		return c.flushDB()
+	case FlushAllCmd:
+		return c.flushAll()
+	case HistoryCmd, GetAtCmd, RevertCmd:
+		return c.executeHistory(command, args)
+	case IndexCmd, FindCmd, FindRangeCmd:
+		return c.executeIndex(command, args)
+	case MGetCmd, MSetCmd, MSetNXCmd, MDelCmd:
+		return c.executeMultiKey(command, args)
+	case ScanCmd, KeysCmd, DBSizeCmd:
+		return c.executeScan(command, args)
+	case RangeCmd, RevRangeCmd, PrefixCmd:
+		return c.executeRange(command, args)
+	case IncrCmd, DecrCmd, IncrByCmd, DecrByCmd, IncrByFloatCmd:
+		return c.executeCounter(command, args)
+	case LPushCmd, RPushCmd, LPopCmd, RPopCmd, LLenCmd, LRangeCmd, LIndexCmd, LRemCmd, LTrimCmd:
+		return c.executeList(command, args)
+	case HSetCmd, HGetCmd, HDelCmd, HGetAllCmd, HKeysCmd, HValsCmd, HLenCmd, HExistsCmd, HIncrByCmd:
+		return c.executeHash(command, args)
+	case SAddCmd, SRemCmd, SMembersCmd, SIsMemberCmd, SCardCmd, SPopCmd, SRandMemberCmd,
+		SInterCmd, SUnionCmd, SDiffCmd, SInterStoreCmd, SUnionStoreCmd, SDiffStoreCmd:
+		return c.executeSet(command, args)
+	case ZAddCmd, ZRemCmd, ZScoreCmd, ZIncrByCmd, ZRankCmd, ZRevRankCmd,
+		ZRangeCmd, ZRevRangeCmd, ZRangeByScoreCmd, ZCountCmd:
+		return c.executeSortedSet(command, args)
+	case SaveCmd:
+		return c.save()
+	case BgSaveCmd:
+		return c.bgsave()
 	default:
 		return "Unknown command", nil
 	}
 }
+
+func (c *ComputeHandler) journaled() bool {
+	return c.wal != nil || c.replication != nil
+}
+
+// blocksWrites reports whether write commands have to take writeMu, so
+// that BlockWrites sees a keyspace no write is halfway through.
+func (c *ComputeHandler) blocksWrites() bool {
+	return c.journaled() || c.snapshotter != nil
+}
+
+// journal appends a successfully applied write command to the write-ahead
+// log and the replication stream and publishes its keyspace event. Commands
+// are journaled in a form which gives the same result when replayed later,
+// e.g. relative expiration is stored as an absolute one.
+func (c *dbHandler) journal(command string, args ...string) error {
+	c.notifyCommand(command, args)
+
+	return c.appendJournal(command, args)
+}
+
+// appendJournal journals commands of other databases than the first one
+// wrapped in select, so replaying a command does not depend on the
+// commands journaled before it.
+func (c *dbHandler) appendJournal(command string, args []string) error {
+	if c.index != 0 {
+		args = append([]string{strconv.Itoa(c.index), command}, args...)
+		command = SelectCmd
+	}
+
+	return c.ComputeHandler.appendJournal(command, args)
+}
+
+func (c *ComputeHandler) appendJournal(command string, args []string) error {
+	if c.replication != nil {
+		c.replication.Propagate(command, args)
+	}
+
+	if c.wal == nil {
+		return nil
+	}
+
+	if err := c.wal.Append(command, args); err != nil {
+		c.logger.Error("wal.Append error", zap.String("command", command), zap.Error(err))
+
+		return errors.New("Write-ahead log error")
+	}
+
+	return nil
+}
+
+// holdsOtherType reports whether key exists and holds a value of another
+// type than expected.
+func (c *dbHandler) holdsOtherType(key string, expected storage.ValueType) bool {
+	typed, ok := c.storage.(TypedStorage)
+	if !ok {
+		return false
+	}
+
+	valueType, found := typed.Type(key)
+
+	return found && valueType != expected
+}
+
+func (c *ComputeHandler) save() (string, error) {
+	if c.snapshotter == nil {
+		return "", errSnapshotsDisabled
+	}
+
+	if err := c.snapshotter.Save(); err != nil {
+		c.logger.Error("snapshotter.Save error", zap.Error(err))
+
+		return "", fmt.Errorf("Save snapshot error: %s", err.Error())
+	}
+
+	fmt.Println("Snapshot saved")
+
+	return "snapshot saved", nil
+}
+
+func (c *ComputeHandler) bgsave() (string, error) {
+	if c.snapshotter == nil {
+		return "", errSnapshotsDisabled
+	}
+
+	if err := c.snapshotter.BackgroundSave(); err != nil {
+		return "", err
+	}
+
+	fmt.Println("Background saving started")
+
+	return "background saving started", nil
+}
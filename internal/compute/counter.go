@@ -0,0 +1,77 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"umemory/internal/storage"
+)
+
+var errCountersNotSupported = errors.New("Storage engine does not support counters")
+
+func (c *dbHandler) counterStorage() (CounterStorage, error) {
+	storage, ok := c.storage.(CounterStorage)
+	if !ok {
+		c.logger.Error("storage does not implement CounterStorage")
+
+		return nil, errCountersNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeCounter handles increments of numbers stored as strings, the
+// arguments are already validated. The read-modify-write is done by the
+// storage under its lock, so concurrent increments are not lost.
+func (c *dbHandler) executeCounter(command string, args []string) (string, error) {
+	counters, err := c.counterStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	if command == IncrByFloatCmd {
+		increment, _ := parseScore(args[1])
+
+		value, err := counters.IncrByFloat(key, increment)
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Value of %s is %s\n", key, formatScore(value))
+
+		return formatScore(value), nil
+	}
+
+	var increment int64
+	switch command {
+	case IncrCmd:
+		increment = 1
+	case DecrCmd:
+		increment = -1
+	case IncrByCmd:
+		increment, _ = strconv.ParseInt(args[1], 10, 64)
+	case DecrByCmd:
+		increment, _ = strconv.ParseInt(args[1], 10, 64)
+		if increment == math.MinInt64 {
+			return "", storage.ErrOverflow
+		}
+		increment = -increment
+	}
+
+	value, err := counters.IncrBy(key, increment)
+	if err != nil {
+		return "", err
+	}
+	if err := c.journal(command, args...); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Value of %s is %d\n", key, value)
+
+	return strconv.FormatInt(value, 10), nil
+}
@@ -0,0 +1,203 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"umemory/internal/network"
+)
+
+var (
+	errDBIndexOutOfRange = errors.New("DB index is out of range")
+	errSelectInsideMulti = errors.New("SELECT inside MULTI is not allowed")
+	errSameDB            = errors.New("source and destination objects are the same")
+	errFlushNotSupported = errors.New("Storage engine does not support flush")
+	errSwapNotSupported  = errors.New("Storage engine does not support swapdb")
+)
+
+// selectedDBKey stores the index of the database selected by a session.
+type selectedDBKey struct{}
+
+// dbHandler executes commands against one of the numbered databases, it
+// shares everything but the storage with the compute handler.
+type dbHandler struct {
+	*ComputeHandler
+	index   int
+	storage Storage
+}
+
+// SetDatabases makes the handler serve the numbered databases instead of
+// the storage it was created with, clients switch between them with
+// select. It must be called before the handler starts serving requests.
+func (c *ComputeHandler) SetDatabases(databases Databases) {
+	c.databases = databases
+}
+
+func (c *ComputeHandler) databasesCount() int {
+	if c.databases == nil {
+		return 1
+	}
+
+	return c.databases.Count()
+}
+
+// db returns the handler of the database by index. The database behind an
+// index changes on swapdb, so the handler must not outlive the command.
+func (c *ComputeHandler) db(index int) *dbHandler {
+	storage := c.storage
+	if c.databases != nil {
+		storage = Storage(c.databases.DB(index))
+	}
+
+	return &dbHandler{ComputeHandler: c, index: index, storage: storage}
+}
+
+func selectedDB(session *network.Session) int {
+	if session == nil {
+		return 0
+	}
+
+	index, _ := session.Value(selectedDBKey{}).(int)
+
+	return index
+}
+
+// dbIndex parses the index of an existing database.
+func (c *ComputeHandler) dbIndex(arg string) (int, error) {
+	index, err := strconv.Atoi(arg)
+	if err != nil || index < 0 || index >= c.databasesCount() {
+		return 0, errDBIndexOutOfRange
+	}
+
+	return index, nil
+}
+
+func (c *ComputeHandler) selectDB(session *network.Session, args []string) (string, error) {
+	if session == nil {
+		return "", errNoSession
+	}
+	if transactionOf(session) != nil {
+		return "", errSelectInsideMulti
+	}
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+
+	index, err := c.dbIndex(args[0])
+	if err != nil {
+		return "", err
+	}
+	session.SetValue(selectedDBKey{}, index)
+
+	fmt.Printf("Session %d selected database %d\n", session.ID(), index)
+
+	return "OK", nil
+}
+
+// lock takes the transaction lock for the command and returns the function
+// releasing it. swapdb changes the databases of all clients, so it is
+// executed exclusively like exec.
+func (c *ComputeHandler) lock(command string) func() {
+	if command == SwapDBCmd {
+		c.txMu.Lock()
+
+		return c.txMu.Unlock
+	}
+
+	c.txMu.RLock()
+
+	return c.txMu.RUnlock
+}
+
+func (c *dbHandler) move(args []string) (string, error) {
+	key := args[0]
+	to, err := c.dbIndex(args[1])
+	if err != nil {
+		return "", err
+	}
+	if to == c.index {
+		return "", errSameDB
+	}
+
+	moved, err := c.databases.Move(key, c.index, to)
+	if err != nil {
+		return "", err
+	}
+	if !moved {
+		fmt.Printf("Key %s was not moved to database %d\n", key, to)
+
+		return "0", nil
+	}
+
+	if err := c.journal(MoveCmd, args...); err != nil {
+		return "", err
+	}
+	c.notify(to, genericEvents, "move_to", key)
+
+	fmt.Printf("Key %s moved to database %d\n", key, to)
+
+	return "1", nil
+}
+
+func (c *dbHandler) swapDB(args []string) (string, error) {
+	a, err := c.dbIndex(args[0])
+	if err != nil {
+		return "", err
+	}
+	b, err := c.dbIndex(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	// persistent engines keep every database in its own place on disk, a
+	// swap would not survive a restart
+	if c.capabilities().Persistent {
+		return "", errSwapNotSupported
+	}
+
+	if a != b {
+		c.databases.Swap(a, b)
+	}
+	if err := c.journal(SwapDBCmd, args...); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Databases %d and %d swapped\n", a, b)
+
+	return "OK", nil
+}
+
+func (c *dbHandler) flushDB() (string, error) {
+	storage, ok := c.storage.(FlushableStorage)
+	if !ok {
+		return "", errFlushNotSupported
+	}
+
+	storage.Flush()
+	if err := c.journal(FlushDBCmd); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Database %d flushed\n", c.index)
+
+	return "OK", nil
+}
+
+func (c *dbHandler) flushAll() (string, error) {
+	for i := 0; i < c.databasesCount(); i++ {
+		if _, ok := c.db(i).storage.(FlushableStorage); !ok {
+			return "", errFlushNotSupported
+		}
+	}
+
+	for i := 0; i < c.databasesCount(); i++ {
+		c.db(i).storage.(FlushableStorage).Flush()
+	}
+	if err := c.journal(FlushAllCmd); err != nil {
+		return "", err
+	}
+
+	fmt.Println("All databases flushed")
+
+	return "OK", nil
+}
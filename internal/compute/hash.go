@@ -0,0 +1,133 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errHashesNotSupported = errors.New("Storage engine does not support hashes")
+
+func (c *dbHandler) hashStorage() (HashStorage, error) {
+	storage, ok := c.storage.(HashStorage)
+	if !ok {
+		c.logger.Error("storage does not implement HashStorage")
+
+		return nil, errHashesNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeHash handles hash commands, the arguments are already validated.
+func (c *dbHandler) executeHash(command string, args []string) (string, error) {
+	storage, err := c.hashStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	switch command {
+	case HSetCmd:
+		fields := make(map[string]string, len(args[1:])/2)
+		for i := 1; i+1 < len(args); i += 2 {
+			fields[args[i]] = args[i+1]
+		}
+
+		added, err := storage.HSet(key, fields)
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("%d fields added to hash %s\n", added, key)
+
+		return strconv.Itoa(added), nil
+	case HGetCmd:
+		value, found, err := storage.HGet(key, args[1])
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("Field %s of hash %s not found\n", args[1], key)
+
+			return nilReply, nil
+		}
+
+		fmt.Printf("Value found: %s\n", value)
+
+		return value, nil
+	case HDelCmd:
+		removed, err := storage.HDel(key, args[1:])
+		if err != nil {
+			return "", err
+		}
+		if removed > 0 {
+			if err := c.journal(command, args...); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Printf("%d fields removed from hash %s\n", removed, key)
+
+		return strconv.Itoa(removed), nil
+	case HGetAllCmd, HKeysCmd, HValsCmd:
+		read := storage.HGetAll
+		switch command {
+		case HKeysCmd:
+			read = storage.HKeys
+		case HValsCmd:
+			read = storage.HVals
+		}
+
+		values, err := read(key)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Values found: %v\n", values)
+
+		return arrayReply(values), nil
+	case HLenCmd:
+		length, err := storage.HLen(key)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Hash %s length is %d\n", key, length)
+
+		return strconv.Itoa(length), nil
+	case HExistsCmd:
+		found, err := storage.HExists(key, args[1])
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("Field %s of hash %s not found\n", args[1], key)
+
+			return "0", nil
+		}
+
+		fmt.Printf("Field %s of hash %s exists\n", args[1], key)
+
+		return "1", nil
+	case HIncrByCmd:
+		increment, _ := strconv.ParseInt(args[2], 10, 64)
+
+		value, err := storage.HIncrBy(key, args[1], increment)
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Field %s of hash %s is %d\n", args[1], key, value)
+
+		return strconv.FormatInt(value, 10), nil
+	default:
+		return "Unknown command", nil
+	}
+}
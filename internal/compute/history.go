@@ -0,0 +1,123 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"umemory/internal/storage"
+)
+
+var errHistoryNotSupported = errors.New("Storage engine does not support history")
+
+func (c *dbHandler) historyStorage() (HistoryStorage, error) {
+	storage, ok := c.storage.(HistoryStorage)
+	if !ok {
+		c.logger.Error("storage does not implement HistoryStorage")
+
+		return nil, errHistoryNotSupported
+	}
+
+	return storage, nil
+}
+
+// parseVersionOrTime parses a version number or an RFC 3339 timestamp, it
+// reports whether the argument is a timestamp.
+func parseVersionOrTime(arg string) (uint64, time.Time, error) {
+	if number, err := strconv.ParseUint(arg, 10, 64); err == nil {
+		return number, time.Time{}, nil
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, arg)
+	if err != nil {
+		return 0, time.Time{}, errors.New("expected a version or an RFC 3339 timestamp")
+	}
+
+	return 0, at, nil
+}
+
+// executeHistory handles commands reading and restoring versions of
+// string keys, the arguments are already validated.
+func (c *dbHandler) executeHistory(command string, args []string) (string, error) {
+	history, err := c.historyStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	switch command {
+	case HistoryCmd:
+		versions, err := history.History(key)
+		if err != nil {
+			return "", err
+		}
+
+		replies := make([]string, 0, len(versions))
+		for _, version := range versions {
+			replies = append(replies, versionReply(version))
+		}
+
+		fmt.Printf("%d versions of %s found\n", len(versions), key)
+
+		return arrayReply(replies), nil
+	case GetAtCmd:
+		number, at, _ := parseVersionOrTime(args[1])
+
+		var (
+			version storage.Version
+			found   bool
+		)
+		if at.IsZero() {
+			version, found, err = history.VersionAt(key, number)
+		} else {
+			version, found, err = history.VersionAtTime(key, at)
+		}
+		if err != nil {
+			return "", err
+		}
+		if !found || version.Deleted {
+			fmt.Printf("Version %s of %s not found\n", args[1], key)
+
+			return nilReply, nil
+		}
+
+		fmt.Printf("Version %d of %s found: %s\n", version.Version, key, version.Value)
+
+		return version.Value, nil
+	default:
+		number, _ := strconv.ParseUint(args[1], 10, 64)
+
+		value, exists, err := history.Revert(key, number)
+		if err != nil {
+			return "", err
+		}
+		// journaled as the resulting write, the history is not replicated
+		if exists {
+			err = c.journal(SetCmd, key, value)
+		} else {
+			err = c.journal(DeleteCmd, key)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Key %s reverted to version %d\n", key, number)
+
+		return "OK", nil
+	}
+}
+
+// versionReply formats a version as its number, time and value, a deleted
+// version has no value.
+func versionReply(version storage.Version) string {
+	value := version.Value
+	if version.Deleted {
+		value = nilReply
+	}
+
+	return arrayReply([]string{
+		strconv.FormatUint(version.Version, 10),
+		version.Time.Format(time.RFC3339Nano),
+		value,
+	})
+}
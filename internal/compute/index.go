@@ -0,0 +1,94 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"umemory/internal/storage"
+)
+
+var errIndexNotSupported = errors.New("Storage engine does not support indexes")
+
+func (c *dbHandler) indexStorage() (IndexStorage, error) {
+	storage, ok := c.storage.(IndexStorage)
+	if !ok {
+		c.logger.Error("storage does not implement IndexStorage")
+
+		return nil, errIndexNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeIndex handles commands managing and querying secondary indexes,
+// the arguments are already validated. Found keys are returned in the
+// order of their indexed values.
+func (c *dbHandler) executeIndex(command string, args []string) (string, error) {
+	indexes, err := c.indexStorage()
+	if err != nil {
+		return "", err
+	}
+
+	switch command {
+	case IndexCmd:
+		return c.manageIndex(indexes, args)
+	case FindCmd:
+		keys, err := indexes.Find(args[0], args[1])
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Keys found: %d\n", len(keys))
+
+		return arrayReply(keys), nil
+	default:
+		keys, err := indexes.FindRange(args[0], indexRange(args[1], minKey), indexRange(args[2], maxKey))
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Keys found: %d\n", len(keys))
+
+		return arrayReply(keys), nil
+	}
+}
+
+// manageIndex creates or drops an index. The command is journaled with the
+// subcommand and the options normalized.
+func (c *dbHandler) manageIndex(indexes IndexStorage, args []string) (string, error) {
+	if strings.ToLower(args[0]) == IndexDrop {
+		if err := indexes.DropIndex(args[1]); err != nil {
+			return "", err
+		}
+		if err := c.journal(IndexCmd, IndexDrop, args[1]); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Index %s dropped\n", args[1])
+
+		return "OK", nil
+	}
+
+	definition := storage.IndexDefinition{Name: args[1], Prefix: args[3], Path: args[5]}
+	if err := indexes.CreateIndex(definition); err != nil {
+		return "", err
+	}
+	err := c.journal(IndexCmd, IndexCreate, definition.Name, OnOption, definition.Prefix, FieldOption, definition.Path)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Index %s created\n", definition.Name)
+
+	return "OK", nil
+}
+
+// indexRange returns the bound of a findrange query, unbounded is the
+// argument meaning no bound.
+func indexRange(arg, unbounded string) storage.IndexRange {
+	if arg == unbounded {
+		return storage.IndexRange{Unbounded: true}
+	}
+
+	return storage.IndexRange{Value: arg}
+}
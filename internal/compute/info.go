@@ -0,0 +1,92 @@
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"umemory/internal/storage"
+)
+
+const (
+	EngineSection = "engine"
+	MemorySection = "memory"
+	CompressionSection = "compression"
+	ReplicationSection = "replication"
+)
+
+var infoSections = []string{EngineSection, MemorySection, CompressionSection, ReplicationSection}
+
+// InfoField is a single "name:value" line of the info command.
+type InfoField struct {
+	Name string
+	Value string
+}
+
+// info handles "info [section]" and returns "name:value" lines grouped by
+// sections, all sections are returned when none is given.
+func (c *ComputeHandler) info(args []string) (string, error) {
+	sections := infoSections
+	if len(args) == 1 {
+		sections = []string{strings.ToLower(args[0])}
+	}
+
+	var builder strings.Builder
+	for _, section := range sections {
+		var fields []InfoField
+		switch section {
+		case EngineSection:
+			fields = c.engineInfo()
+		case MemorySection:
+			fields = c.memoryInfo()
+		case CompressionSection:
+			fields = c.compressionInfo()
+		case ReplicationSection:
+			fields = c.replicationInfo()
+		default:
+			return "", fmt.Errorf("unknown info section %s", section)
+		}
+
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("# " + section + "\n")
+		for _, field := range fields {
+			builder.WriteString(field.Name + ":" + field.Value + "\n")
+		}
+	}
+
+	fmt.Print(builder.String())
+
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+func (c *ComputeHandler) engineInfo() []InfoField {
+	capabilities := c.capabilities()
+
+	return []InfoField{
+		{"persistent", strconv.FormatBool(capabilities.Persistent)},
+		{"ordered", strconv.FormatBool(capabilities.Ordered)},
+		{"ttl", strconv.FormatBool(capabilities.TTL)},
+	}
+}
+
+func (c *ComputeHandler) replicationInfo() []InfoField {
+	if c.replication == nil {
+		return []InfoField{{"role", "standalone"}}
+	}
+
+	return c.replication.Info()
+}
+
+// capabilities returns what the storage engine supports. Engines not
+// reporting capabilities are described by the interfaces they implement.
+func (c *ComputeHandler) capabilities() storage.Capabilities {
+	db := c.db(0)
+	if capable, ok := db.storage.(CapableStorage); ok {
+		return capable.Capabilities()
+	}
+
+	_, ttl := db.storage.(ExpirableStorage)
+
+	return storage.Capabilities{TTL: ttl}
+}
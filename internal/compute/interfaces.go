@@ -1,11 +1,217 @@
 package compute
 
+import (
+	"time"
+	"umemory/internal/storage"
+)
+
 type Storage interface {
 	Get(key string) (string, bool)
 	Set(key string, value string)
 	Delete(key string)
 }
 
+// Databases is implemented by storages holding several numbered databases,
+// each of them is a separate storage engine. Swap must be called while no
+// command is executed.
+type Databases interface {
+	Count() int
+	DB(index int) storage.Engine
+	Swap(a, b int)
+	Move(key string, from, to int) (bool, error)
+	OnExpired(fn func(db int, key string))
+}
+
+// FlushableStorage is implemented by storage engines able to remove all
+// keys at once.
+type FlushableStorage interface {
+	Flush()
+}
+
+// CapableStorage is implemented by storage engines reporting which
+// optional features they support.
+type CapableStorage interface {
+	Capabilities() storage.Capabilities
+}
+
+// ExpirableStorage is implemented by storage engines supporting keys
+// with time to live.
+type ExpirableStorage interface {
+	SetWithTTL(key string, value string, ttl time.Duration)
+	Expire(key string, ttl time.Duration) bool
+	TTL(key string) (time.Duration, bool)
+	Persist(key string) bool
+}
+
+// NotifyingStorage is implemented by storage engines reporting keys removed
+// on expiration, it is required by expired keyspace events.
+type NotifyingStorage interface {
+	OnExpired(fn func(key string))
+}
+
+// TypedStorage is implemented by storage engines holding values of
+// different types. Storage.Get reports keys of other types than string as
+// missing.
+type TypedStorage interface {
+	Type(key string) (storage.ValueType, bool)
+}
+
+// CounterStorage is implemented by storage engines supporting atomic
+// increments of numbers stored as strings. Increments of keys holding other
+// types return storage.ErrWrongType.
+type CounterStorage interface {
+	IncrBy(key string, increment int64) (int64, error)
+	IncrByFloat(key string, increment float64) (float64, error)
+}
+
+// VersionedStorage is implemented by storage engines counting
+// modifications of keys, it is required by watch and cas.
+type VersionedStorage interface {
+	Version(key string) uint64
+	CompareAndSet(key, expected, value string) (bool, error)
+}
+
+// MultiKeyStorage is implemented by storage engines reading and writing
+// several string keys at once. Every operation is atomic with respect to
+// concurrent writes, pairs hold keys followed by their values.
+type MultiKeyStorage interface {
+	MGet(keys []string) ([]string, []bool)
+	MSet(pairs []string)
+	MSetNX(pairs []string) bool
+	MDelete(keys []string) []bool
+}
+
+// ScanningStorage is implemented by storage engines able to list their
+// keys without locking the whole keyspace.
+type ScanningStorage interface {
+	Scan(cursor uint64, pattern string, count int) ([]string, uint64)
+	Keys(pattern string) []string
+	DBSize() int
+}
+
+// OrderedStorage is implemented by storage engines keeping keys in lexical
+// order. An empty end of a range means no upper bound and zero limit means
+// no limit.
+type OrderedStorage interface {
+	Range(start, end string, limit int, reverse bool) []storage.KeyValue
+	Prefix(prefix string, limit int) []storage.KeyValue
+}
+
+// ListStorage is implemented by storage engines supporting lists. List
+// operations on keys holding other types return storage.ErrWrongType.
+type ListStorage interface {
+	LPush(key string, values []string) (int, error)
+	RPush(key string, values []string) (int, error)
+	LPop(key string) (string, bool, error)
+	RPop(key string) (string, bool, error)
+	LLen(key string) (int, error)
+	LRange(key string, start, stop int) ([]string, error)
+	LIndex(key string, index int) (string, bool, error)
+	LRem(key string, count int, value string) (int, error)
+	LTrim(key string, start, stop int) error
+}
+
+// HashStorage is implemented by storage engines supporting hashes. Hash
+// operations on keys holding other types return storage.ErrWrongType.
+type HashStorage interface {
+	HSet(key string, fields map[string]string) (int, error)
+	HGet(key, field string) (string, bool, error)
+	HDel(key string, fields []string) (int, error)
+	HGetAll(key string) ([]string, error)
+	HKeys(key string) ([]string, error)
+	HVals(key string) ([]string, error)
+	HLen(key string) (int, error)
+	HExists(key, field string) (bool, error)
+	HIncrBy(key, field string, increment int64) (int64, error)
+}
+
+// SetStorage is implemented by storage engines supporting sets. Multi-key
+// operations are atomic with respect to concurrent writes. Set operations
+// on keys holding other types return storage.ErrWrongType.
+type SetStorage interface {
+	SAdd(key string, members []string) (int, error)
+	SRem(key string, members []string) (int, error)
+	SMembers(key string) ([]string, error)
+	SIsMember(key, member string) (bool, error)
+	SCard(key string) (int, error)
+	SPop(key string, count int) ([]string, error)
+	SRandMember(key string, count int) ([]string, error)
+	SInter(keys []string) ([]string, error)
+	SUnion(keys []string) ([]string, error)
+	SDiff(keys []string) ([]string, error)
+	SInterStore(destination string, keys []string) (int, error)
+	SUnionStore(destination string, keys []string) (int, error)
+	SDiffStore(destination string, keys []string) (int, error)
+}
+
+// SortedSetStorage is implemented by storage engines supporting sorted
+// sets. Sorted set operations on keys holding other types return
+// storage.ErrWrongType.
+type SortedSetStorage interface {
+	ZAdd(key string, members map[string]float64) (int, error)
+	ZRem(key string, members []string) (int, error)
+	ZScore(key, member string) (float64, bool, error)
+	ZIncrBy(key, member string, increment float64) (float64, error)
+	ZRank(key, member string, reverse bool) (int, bool, error)
+	ZRange(key string, start, stop int, reverse bool) ([]storage.ScoredMember, error)
+	ZRangeByScore(key string, min, max storage.ScoreBound) ([]storage.ScoredMember, error)
+	ZCount(key string, min, max storage.ScoreBound) (int, error)
+}
+
+// MemoryLimitedStorage is implemented by storage engines with a memory
+// limit. FreeMemory evicts keys to make room for a write command, it
+// returns the evicted keys and false when the limit is still exceeded.
+type MemoryLimitedStorage interface {
+	FreeMemory() ([]string, bool)
+	MemoryStats() storage.MemoryStats
+}
+
+// HistoryStorage is implemented by storage engines keeping versions of
+// string keys, the methods return storage.ErrHistoryDisabled unless the
+// history is configured.
+type HistoryStorage interface {
+	History(key string) ([]storage.Version, error)
+	VersionAt(key string, number uint64) (storage.Version, bool, error)
+	VersionAtTime(key string, at time.Time) (storage.Version, bool, error)
+	Revert(key string, number uint64) (string, bool, error)
+}
+
+// IndexStorage is implemented by storage engines maintaining secondary
+// indexes over JSON documents stored as strings.
+type IndexStorage interface {
+	CreateIndex(definition storage.IndexDefinition) error
+	DropIndex(name string) error
+	Find(name, value string) ([]string, error)
+	FindRange(name string, min, max storage.IndexRange) ([]string, error)
+}
+
+// CompressingStorage is implemented by storage engines compressing large
+// string values.
+type CompressingStorage interface {
+	CompressionStats() storage.CompressionStats
+}
+
 type Parser interface {
 	ParseArgs(s string) (string, []string, error)
+	Validate(command string, args []string) error
+}
+
+// WAL is a write-ahead log receiving every successfully applied write command.
+type WAL interface {
+	Append(command string, args []string) error
+}
+
+// ReplicationNode is the leader or a follower of the replication.
+// Propagate receives every applied write command, followers do not accept
+// write commands from clients.
+type ReplicationNode interface {
+	ReadOnly() bool
+	Propagate(command string, args []string)
+	Info() []InfoField
+}
+
+// Snapshotter saves snapshots of the keyspace on demand.
+type Snapshotter interface {
+	Save() error
+	BackgroundSave() error
 }
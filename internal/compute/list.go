@@ -0,0 +1,140 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errListsNotSupported = errors.New("Storage engine does not support lists")
+
+func (c *dbHandler) listStorage() (ListStorage, error) {
+	storage, ok := c.storage.(ListStorage)
+	if !ok {
+		c.logger.Error("storage does not implement ListStorage")
+
+		return nil, errListsNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeList handles list commands, the arguments are already validated.
+func (c *dbHandler) executeList(command string, args []string) (string, error) {
+	storage, err := c.listStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	switch command {
+	case LPushCmd, RPushCmd:
+		push := storage.LPush
+		if command == RPushCmd {
+			push = storage.RPush
+		}
+
+		length, err := push(key, args[1:])
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("List %s length is %d\n", key, length)
+
+		return strconv.Itoa(length), nil
+	case LPopCmd, RPopCmd:
+		pop := storage.LPop
+		if command == RPopCmd {
+			pop = storage.RPop
+		}
+
+		value, found, err := pop(key)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("List %s is empty\n", key)
+
+			return nilReply, nil
+		}
+		if err := c.journal(command, key); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Value popped: %s\n", value)
+
+		return value, nil
+	case LLenCmd:
+		length, err := storage.LLen(key)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("List %s length is %d\n", key, length)
+
+		return strconv.Itoa(length), nil
+	case LRangeCmd:
+		start, _ := strconv.Atoi(args[1])
+		stop, _ := strconv.Atoi(args[2])
+
+		values, err := storage.LRange(key, start, stop)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Values found: %v\n", values)
+
+		return arrayReply(values), nil
+	case LIndexCmd:
+		index, _ := strconv.Atoi(args[1])
+
+		value, found, err := storage.LIndex(key, index)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("Index %d of list %s is out of range\n", index, key)
+
+			return nilReply, nil
+		}
+
+		fmt.Printf("Value found: %s\n", value)
+
+		return value, nil
+	case LRemCmd:
+		count, _ := strconv.Atoi(args[1])
+
+		removed, err := storage.LRem(key, count, args[2])
+		if err != nil {
+			return "", err
+		}
+		if removed > 0 {
+			if err := c.journal(command, args...); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Printf("%d values removed from list %s\n", removed, key)
+
+		return strconv.Itoa(removed), nil
+	case LTrimCmd:
+		start, _ := strconv.Atoi(args[1])
+		stop, _ := strconv.Atoi(args[2])
+
+		if err := storage.LTrim(key, start, stop); err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("List %s trimmed\n", key)
+
+		return "trimmed", nil
+	default:
+		return "Unknown command", nil
+	}
+}
@@ -0,0 +1,91 @@
+package compute
+
+import (
+	"errors"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+var errOutOfMemory = errors.New("OOM command not allowed when used memory > 'max_memory'")
+
+// freeMemory makes room for a command growing the keyspace. The memory
+// limit is shared by the databases, so keys are evicted from the database
+// of the command first and from the others when it has nothing to evict.
+// Evicted keys are journaled as deletes, so they are not restored from the
+// write-ahead log and are removed on followers too.
+func (c *dbHandler) freeMemory() error {
+	storage, ok := c.storage.(MemoryLimitedStorage)
+	if !ok {
+		return nil
+	}
+
+	if c.blocksWrites() {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+	}
+
+	count := c.databasesCount()
+	for i := 0; i < count; i++ {
+		db := c.db((c.index + i) % count)
+		dbStorage, ok := db.storage.(MemoryLimitedStorage)
+		if !ok {
+			continue
+		}
+
+		evicted, ok := dbStorage.FreeMemory()
+		for _, key := range evicted {
+			c.notify(db.index, evictedEvents, "evicted", key)
+			if err := db.appendJournal(DeleteCmd, []string{key}); err != nil {
+				return err
+			}
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	c.logger.Warn("memory limit reached", zap.String("policy", storage.MemoryStats().Policy))
+
+	return errOutOfMemory
+}
+
+// compressionInfo reports the compressed string values of all databases,
+// compression_ratio is their original size divided by the compressed one.
+func (c *ComputeHandler) compressionInfo() []InfoField {
+	storage, ok := c.db(0).storage.(CompressingStorage)
+	if !ok {
+		return []InfoField{{"compression_threshold", "0"}}
+	}
+
+	stats := storage.CompressionStats()
+	ratio := 1.0
+	if stats.CompressedBytes > 0 {
+		ratio = float64(stats.RawBytes) / float64(stats.CompressedBytes)
+	}
+
+	return []InfoField{
+		{"compression_threshold", strconv.FormatInt(stats.Threshold, 10)},
+		{"compression_codec", stats.Codec},
+		{"compressed_values", strconv.FormatInt(stats.Values, 10)},
+		{"compressed_raw_bytes", strconv.FormatInt(stats.RawBytes, 10)},
+		{"compressed_bytes", strconv.FormatInt(stats.CompressedBytes, 10)},
+		{"compression_ratio", strconv.FormatFloat(ratio, 'f', 2, 64)},
+	}
+}
+
+func (c *ComputeHandler) memoryInfo() []InfoField {
+	storage, ok := c.db(0).storage.(MemoryLimitedStorage)
+	if !ok {
+		return []InfoField{{"max_memory", "0"}}
+	}
+
+	stats := storage.MemoryStats()
+
+	return []InfoField{
+		{"used_memory", strconv.FormatInt(stats.Used, 10)},
+		{"max_memory", strconv.FormatInt(stats.Max, 10)},
+		{"eviction_policy", stats.Policy},
+		{"evicted_keys", strconv.FormatUint(stats.EvictedKeys, 10)},
+	}
+}
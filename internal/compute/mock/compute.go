@@ -6,8 +6,11 @@ package mock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	compute "umemory/internal/compute"
+	storage "umemory/internal/storage"
 )
 
 // MockStorage is a mock of Storage interface.
@@ -72,6 +75,1557 @@ func (mr *MockStorageMockRecorder) Set(key, value interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStorage)(nil).Set), key, value)
 }
 
+// MockDatabases is a mock of Databases interface.
+type MockDatabases struct {
+	ctrl     *gomock.Controller
+	recorder *MockDatabasesMockRecorder
+}
+
+// MockDatabasesMockRecorder is the mock recorder for MockDatabases.
+type MockDatabasesMockRecorder struct {
+	mock *MockDatabases
+}
+
+// NewMockDatabases creates a new mock instance.
+func NewMockDatabases(ctrl *gomock.Controller) *MockDatabases {
+	mock := &MockDatabases{ctrl: ctrl}
+	mock.recorder = &MockDatabasesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDatabases) EXPECT() *MockDatabasesMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockDatabases) Count() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockDatabasesMockRecorder) Count() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockDatabases)(nil).Count))
+}
+
+// DB mocks base method.
+func (m *MockDatabases) DB(index int) storage.Engine {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DB", index)
+	ret0, _ := ret[0].(storage.Engine)
+	return ret0
+}
+
+// DB indicates an expected call of DB.
+func (mr *MockDatabasesMockRecorder) DB(index interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DB", reflect.TypeOf((*MockDatabases)(nil).DB), index)
+}
+
+// Move mocks base method.
+func (m *MockDatabases) Move(key string, from, to int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", key, from, to)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockDatabasesMockRecorder) Move(key, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockDatabases)(nil).Move), key, from, to)
+}
+
+// OnExpired mocks base method.
+func (m *MockDatabases) OnExpired(fn func(db int, key string)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnExpired", fn)
+}
+
+// OnExpired indicates an expected call of OnExpired.
+func (mr *MockDatabasesMockRecorder) OnExpired(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnExpired", reflect.TypeOf((*MockDatabases)(nil).OnExpired), fn)
+}
+
+// Swap mocks base method.
+func (m *MockDatabases) Swap(a, b int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Swap", a, b)
+}
+
+// Swap indicates an expected call of Swap.
+func (mr *MockDatabasesMockRecorder) Swap(a, b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Swap", reflect.TypeOf((*MockDatabases)(nil).Swap), a, b)
+}
+
+// MockFlushableStorage is a mock of FlushableStorage interface.
+type MockFlushableStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockFlushableStorageMockRecorder
+}
+
+// MockFlushableStorageMockRecorder is the mock recorder for MockFlushableStorage.
+type MockFlushableStorageMockRecorder struct {
+	mock *MockFlushableStorage
+}
+
+// NewMockFlushableStorage creates a new mock instance.
+func NewMockFlushableStorage(ctrl *gomock.Controller) *MockFlushableStorage {
+	mock := &MockFlushableStorage{ctrl: ctrl}
+	mock.recorder = &MockFlushableStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFlushableStorage) EXPECT() *MockFlushableStorageMockRecorder {
+	return m.recorder
+}
+
+// Flush mocks base method.
+func (m *MockFlushableStorage) Flush() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Flush")
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockFlushableStorageMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockFlushableStorage)(nil).Flush))
+}
+
+// MockCapableStorage is a mock of CapableStorage interface.
+type MockCapableStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockCapableStorageMockRecorder
+}
+
+// MockCapableStorageMockRecorder is the mock recorder for MockCapableStorage.
+type MockCapableStorageMockRecorder struct {
+	mock *MockCapableStorage
+}
+
+// NewMockCapableStorage creates a new mock instance.
+func NewMockCapableStorage(ctrl *gomock.Controller) *MockCapableStorage {
+	mock := &MockCapableStorage{ctrl: ctrl}
+	mock.recorder = &MockCapableStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCapableStorage) EXPECT() *MockCapableStorageMockRecorder {
+	return m.recorder
+}
+
+// Capabilities mocks base method.
+func (m *MockCapableStorage) Capabilities() storage.Capabilities {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capabilities")
+	ret0, _ := ret[0].(storage.Capabilities)
+	return ret0
+}
+
+// Capabilities indicates an expected call of Capabilities.
+func (mr *MockCapableStorageMockRecorder) Capabilities() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capabilities", reflect.TypeOf((*MockCapableStorage)(nil).Capabilities))
+}
+
+// MockExpirableStorage is a mock of ExpirableStorage interface.
+type MockExpirableStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockExpirableStorageMockRecorder
+}
+
+// MockExpirableStorageMockRecorder is the mock recorder for MockExpirableStorage.
+type MockExpirableStorageMockRecorder struct {
+	mock *MockExpirableStorage
+}
+
+// NewMockExpirableStorage creates a new mock instance.
+func NewMockExpirableStorage(ctrl *gomock.Controller) *MockExpirableStorage {
+	mock := &MockExpirableStorage{ctrl: ctrl}
+	mock.recorder = &MockExpirableStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExpirableStorage) EXPECT() *MockExpirableStorageMockRecorder {
+	return m.recorder
+}
+
+// Expire mocks base method.
+func (m *MockExpirableStorage) Expire(key string, ttl time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expire", key, ttl)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Expire indicates an expected call of Expire.
+func (mr *MockExpirableStorageMockRecorder) Expire(key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expire", reflect.TypeOf((*MockExpirableStorage)(nil).Expire), key, ttl)
+}
+
+// Persist mocks base method.
+func (m *MockExpirableStorage) Persist(key string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", key)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockExpirableStorageMockRecorder) Persist(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockExpirableStorage)(nil).Persist), key)
+}
+
+// SetWithTTL mocks base method.
+func (m *MockExpirableStorage) SetWithTTL(key, value string, ttl time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWithTTL", key, value, ttl)
+}
+
+// SetWithTTL indicates an expected call of SetWithTTL.
+func (mr *MockExpirableStorageMockRecorder) SetWithTTL(key, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithTTL", reflect.TypeOf((*MockExpirableStorage)(nil).SetWithTTL), key, value, ttl)
+}
+
+// TTL mocks base method.
+func (m *MockExpirableStorage) TTL(key string) (time.Duration, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TTL", key)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// TTL indicates an expected call of TTL.
+func (mr *MockExpirableStorageMockRecorder) TTL(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TTL", reflect.TypeOf((*MockExpirableStorage)(nil).TTL), key)
+}
+
+// MockNotifyingStorage is a mock of NotifyingStorage interface.
+type MockNotifyingStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifyingStorageMockRecorder
+}
+
+// MockNotifyingStorageMockRecorder is the mock recorder for MockNotifyingStorage.
+type MockNotifyingStorageMockRecorder struct {
+	mock *MockNotifyingStorage
+}
+
+// NewMockNotifyingStorage creates a new mock instance.
+func NewMockNotifyingStorage(ctrl *gomock.Controller) *MockNotifyingStorage {
+	mock := &MockNotifyingStorage{ctrl: ctrl}
+	mock.recorder = &MockNotifyingStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifyingStorage) EXPECT() *MockNotifyingStorageMockRecorder {
+	return m.recorder
+}
+
+// OnExpired mocks base method.
+func (m *MockNotifyingStorage) OnExpired(fn func(string)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnExpired", fn)
+}
+
+// OnExpired indicates an expected call of OnExpired.
+func (mr *MockNotifyingStorageMockRecorder) OnExpired(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnExpired", reflect.TypeOf((*MockNotifyingStorage)(nil).OnExpired), fn)
+}
+
+// MockTypedStorage is a mock of TypedStorage interface.
+type MockTypedStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockTypedStorageMockRecorder
+}
+
+// MockTypedStorageMockRecorder is the mock recorder for MockTypedStorage.
+type MockTypedStorageMockRecorder struct {
+	mock *MockTypedStorage
+}
+
+// NewMockTypedStorage creates a new mock instance.
+func NewMockTypedStorage(ctrl *gomock.Controller) *MockTypedStorage {
+	mock := &MockTypedStorage{ctrl: ctrl}
+	mock.recorder = &MockTypedStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTypedStorage) EXPECT() *MockTypedStorageMockRecorder {
+	return m.recorder
+}
+
+// Type mocks base method.
+func (m *MockTypedStorage) Type(key string) (storage.ValueType, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Type", key)
+	ret0, _ := ret[0].(storage.ValueType)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Type indicates an expected call of Type.
+func (mr *MockTypedStorageMockRecorder) Type(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Type", reflect.TypeOf((*MockTypedStorage)(nil).Type), key)
+}
+
+// MockCounterStorage is a mock of CounterStorage interface.
+type MockCounterStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockCounterStorageMockRecorder
+}
+
+// MockCounterStorageMockRecorder is the mock recorder for MockCounterStorage.
+type MockCounterStorageMockRecorder struct {
+	mock *MockCounterStorage
+}
+
+// NewMockCounterStorage creates a new mock instance.
+func NewMockCounterStorage(ctrl *gomock.Controller) *MockCounterStorage {
+	mock := &MockCounterStorage{ctrl: ctrl}
+	mock.recorder = &MockCounterStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCounterStorage) EXPECT() *MockCounterStorageMockRecorder {
+	return m.recorder
+}
+
+// IncrBy mocks base method.
+func (m *MockCounterStorage) IncrBy(key string, increment int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrBy", key, increment)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrBy indicates an expected call of IncrBy.
+func (mr *MockCounterStorageMockRecorder) IncrBy(key, increment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrBy", reflect.TypeOf((*MockCounterStorage)(nil).IncrBy), key, increment)
+}
+
+// IncrByFloat mocks base method.
+func (m *MockCounterStorage) IncrByFloat(key string, increment float64) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrByFloat", key, increment)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrByFloat indicates an expected call of IncrByFloat.
+func (mr *MockCounterStorageMockRecorder) IncrByFloat(key, increment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrByFloat", reflect.TypeOf((*MockCounterStorage)(nil).IncrByFloat), key, increment)
+}
+
+// MockVersionedStorage is a mock of VersionedStorage interface.
+type MockVersionedStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionedStorageMockRecorder
+}
+
+// MockVersionedStorageMockRecorder is the mock recorder for MockVersionedStorage.
+type MockVersionedStorageMockRecorder struct {
+	mock *MockVersionedStorage
+}
+
+// NewMockVersionedStorage creates a new mock instance.
+func NewMockVersionedStorage(ctrl *gomock.Controller) *MockVersionedStorage {
+	mock := &MockVersionedStorage{ctrl: ctrl}
+	mock.recorder = &MockVersionedStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVersionedStorage) EXPECT() *MockVersionedStorageMockRecorder {
+	return m.recorder
+}
+
+// CompareAndSet mocks base method.
+func (m *MockVersionedStorage) CompareAndSet(key, expected, value string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSet", key, expected, value)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSet indicates an expected call of CompareAndSet.
+func (mr *MockVersionedStorageMockRecorder) CompareAndSet(key, expected, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSet", reflect.TypeOf((*MockVersionedStorage)(nil).CompareAndSet), key, expected, value)
+}
+
+// Version mocks base method.
+func (m *MockVersionedStorage) Version(key string) uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", key)
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockVersionedStorageMockRecorder) Version(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockVersionedStorage)(nil).Version), key)
+}
+
+// MockMultiKeyStorage is a mock of MultiKeyStorage interface.
+type MockMultiKeyStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockMultiKeyStorageMockRecorder
+}
+
+// MockMultiKeyStorageMockRecorder is the mock recorder for MockMultiKeyStorage.
+type MockMultiKeyStorageMockRecorder struct {
+	mock *MockMultiKeyStorage
+}
+
+// NewMockMultiKeyStorage creates a new mock instance.
+func NewMockMultiKeyStorage(ctrl *gomock.Controller) *MockMultiKeyStorage {
+	mock := &MockMultiKeyStorage{ctrl: ctrl}
+	mock.recorder = &MockMultiKeyStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMultiKeyStorage) EXPECT() *MockMultiKeyStorageMockRecorder {
+	return m.recorder
+}
+
+// MDelete mocks base method.
+func (m *MockMultiKeyStorage) MDelete(keys []string) []bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MDelete", keys)
+	ret0, _ := ret[0].([]bool)
+	return ret0
+}
+
+// MDelete indicates an expected call of MDelete.
+func (mr *MockMultiKeyStorageMockRecorder) MDelete(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MDelete", reflect.TypeOf((*MockMultiKeyStorage)(nil).MDelete), keys)
+}
+
+// MGet mocks base method.
+func (m *MockMultiKeyStorage) MGet(keys []string) ([]string, []bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MGet", keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].([]bool)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockMultiKeyStorageMockRecorder) MGet(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockMultiKeyStorage)(nil).MGet), keys)
+}
+
+// MSet mocks base method.
+func (m *MockMultiKeyStorage) MSet(pairs []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MSet", pairs)
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockMultiKeyStorageMockRecorder) MSet(pairs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockMultiKeyStorage)(nil).MSet), pairs)
+}
+
+// MSetNX mocks base method.
+func (m *MockMultiKeyStorage) MSetNX(pairs []string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MSetNX", pairs)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// MSetNX indicates an expected call of MSetNX.
+func (mr *MockMultiKeyStorageMockRecorder) MSetNX(pairs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSetNX", reflect.TypeOf((*MockMultiKeyStorage)(nil).MSetNX), pairs)
+}
+
+// MockScanningStorage is a mock of ScanningStorage interface.
+type MockScanningStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockScanningStorageMockRecorder
+}
+
+// MockScanningStorageMockRecorder is the mock recorder for MockScanningStorage.
+type MockScanningStorageMockRecorder struct {
+	mock *MockScanningStorage
+}
+
+// NewMockScanningStorage creates a new mock instance.
+func NewMockScanningStorage(ctrl *gomock.Controller) *MockScanningStorage {
+	mock := &MockScanningStorage{ctrl: ctrl}
+	mock.recorder = &MockScanningStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScanningStorage) EXPECT() *MockScanningStorageMockRecorder {
+	return m.recorder
+}
+
+// DBSize mocks base method.
+func (m *MockScanningStorage) DBSize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DBSize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DBSize indicates an expected call of DBSize.
+func (mr *MockScanningStorageMockRecorder) DBSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DBSize", reflect.TypeOf((*MockScanningStorage)(nil).DBSize))
+}
+
+// Keys mocks base method.
+func (m *MockScanningStorage) Keys(pattern string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", pattern)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockScanningStorageMockRecorder) Keys(pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockScanningStorage)(nil).Keys), pattern)
+}
+
+// Scan mocks base method.
+func (m *MockScanningStorage) Scan(cursor uint64, pattern string, count int) ([]string, uint64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", cursor, pattern, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(uint64)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockScanningStorageMockRecorder) Scan(cursor, pattern, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockScanningStorage)(nil).Scan), cursor, pattern, count)
+}
+
+// MockOrderedStorage is a mock of OrderedStorage interface.
+type MockOrderedStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderedStorageMockRecorder
+}
+
+// MockOrderedStorageMockRecorder is the mock recorder for MockOrderedStorage.
+type MockOrderedStorageMockRecorder struct {
+	mock *MockOrderedStorage
+}
+
+// NewMockOrderedStorage creates a new mock instance.
+func NewMockOrderedStorage(ctrl *gomock.Controller) *MockOrderedStorage {
+	mock := &MockOrderedStorage{ctrl: ctrl}
+	mock.recorder = &MockOrderedStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderedStorage) EXPECT() *MockOrderedStorageMockRecorder {
+	return m.recorder
+}
+
+// Prefix mocks base method.
+func (m *MockOrderedStorage) Prefix(prefix string, limit int) []storage.KeyValue {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prefix", prefix, limit)
+	ret0, _ := ret[0].([]storage.KeyValue)
+	return ret0
+}
+
+// Prefix indicates an expected call of Prefix.
+func (mr *MockOrderedStorageMockRecorder) Prefix(prefix, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prefix", reflect.TypeOf((*MockOrderedStorage)(nil).Prefix), prefix, limit)
+}
+
+// Range mocks base method.
+func (m *MockOrderedStorage) Range(start, end string, limit int, reverse bool) []storage.KeyValue {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Range", start, end, limit, reverse)
+	ret0, _ := ret[0].([]storage.KeyValue)
+	return ret0
+}
+
+// Range indicates an expected call of Range.
+func (mr *MockOrderedStorageMockRecorder) Range(start, end, limit, reverse interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Range", reflect.TypeOf((*MockOrderedStorage)(nil).Range), start, end, limit, reverse)
+}
+
+// MockListStorage is a mock of ListStorage interface.
+type MockListStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockListStorageMockRecorder
+}
+
+// MockListStorageMockRecorder is the mock recorder for MockListStorage.
+type MockListStorageMockRecorder struct {
+	mock *MockListStorage
+}
+
+// NewMockListStorage creates a new mock instance.
+func NewMockListStorage(ctrl *gomock.Controller) *MockListStorage {
+	mock := &MockListStorage{ctrl: ctrl}
+	mock.recorder = &MockListStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockListStorage) EXPECT() *MockListStorageMockRecorder {
+	return m.recorder
+}
+
+// LIndex mocks base method.
+func (m *MockListStorage) LIndex(key string, index int) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LIndex", key, index)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LIndex indicates an expected call of LIndex.
+func (mr *MockListStorageMockRecorder) LIndex(key, index interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LIndex", reflect.TypeOf((*MockListStorage)(nil).LIndex), key, index)
+}
+
+// LLen mocks base method.
+func (m *MockListStorage) LLen(key string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LLen", key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LLen indicates an expected call of LLen.
+func (mr *MockListStorageMockRecorder) LLen(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LLen", reflect.TypeOf((*MockListStorage)(nil).LLen), key)
+}
+
+// LPop mocks base method.
+func (m *MockListStorage) LPop(key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPop", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LPop indicates an expected call of LPop.
+func (mr *MockListStorageMockRecorder) LPop(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPop", reflect.TypeOf((*MockListStorage)(nil).LPop), key)
+}
+
+// LPush mocks base method.
+func (m *MockListStorage) LPush(key string, values []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LPush", key, values)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LPush indicates an expected call of LPush.
+func (mr *MockListStorageMockRecorder) LPush(key, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LPush", reflect.TypeOf((*MockListStorage)(nil).LPush), key, values)
+}
+
+// LRange mocks base method.
+func (m *MockListStorage) LRange(key string, start, stop int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LRange", key, start, stop)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LRange indicates an expected call of LRange.
+func (mr *MockListStorageMockRecorder) LRange(key, start, stop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LRange", reflect.TypeOf((*MockListStorage)(nil).LRange), key, start, stop)
+}
+
+// LRem mocks base method.
+func (m *MockListStorage) LRem(key string, count int, value string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LRem", key, count, value)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LRem indicates an expected call of LRem.
+func (mr *MockListStorageMockRecorder) LRem(key, count, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LRem", reflect.TypeOf((*MockListStorage)(nil).LRem), key, count, value)
+}
+
+// LTrim mocks base method.
+func (m *MockListStorage) LTrim(key string, start, stop int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LTrim", key, start, stop)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LTrim indicates an expected call of LTrim.
+func (mr *MockListStorageMockRecorder) LTrim(key, start, stop interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LTrim", reflect.TypeOf((*MockListStorage)(nil).LTrim), key, start, stop)
+}
+
+// RPop mocks base method.
+func (m *MockListStorage) RPop(key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RPop", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RPop indicates an expected call of RPop.
+func (mr *MockListStorageMockRecorder) RPop(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPop", reflect.TypeOf((*MockListStorage)(nil).RPop), key)
+}
+
+// RPush mocks base method.
+func (m *MockListStorage) RPush(key string, values []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RPush", key, values)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RPush indicates an expected call of RPush.
+func (mr *MockListStorageMockRecorder) RPush(key, values interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPush", reflect.TypeOf((*MockListStorage)(nil).RPush), key, values)
+}
+
+// MockHashStorage is a mock of HashStorage interface.
+type MockHashStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHashStorageMockRecorder
+}
+
+// MockHashStorageMockRecorder is the mock recorder for MockHashStorage.
+type MockHashStorageMockRecorder struct {
+	mock *MockHashStorage
+}
+
+// NewMockHashStorage creates a new mock instance.
+func NewMockHashStorage(ctrl *gomock.Controller) *MockHashStorage {
+	mock := &MockHashStorage{ctrl: ctrl}
+	mock.recorder = &MockHashStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHashStorage) EXPECT() *MockHashStorageMockRecorder {
+	return m.recorder
+}
+
+// HDel mocks base method.
+func (m *MockHashStorage) HDel(key string, fields []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HDel", key, fields)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HDel indicates an expected call of HDel.
+func (mr *MockHashStorageMockRecorder) HDel(key, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HDel", reflect.TypeOf((*MockHashStorage)(nil).HDel), key, fields)
+}
+
+// HExists mocks base method.
+func (m *MockHashStorage) HExists(key, field string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HExists", key, field)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HExists indicates an expected call of HExists.
+func (mr *MockHashStorageMockRecorder) HExists(key, field interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HExists", reflect.TypeOf((*MockHashStorage)(nil).HExists), key, field)
+}
+
+// HGet mocks base method.
+func (m *MockHashStorage) HGet(key, field string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGet", key, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HGet indicates an expected call of HGet.
+func (mr *MockHashStorageMockRecorder) HGet(key, field interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGet", reflect.TypeOf((*MockHashStorage)(nil).HGet), key, field)
+}
+
+// HGetAll mocks base method.
+func (m *MockHashStorage) HGetAll(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HGetAll", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HGetAll indicates an expected call of HGetAll.
+func (mr *MockHashStorageMockRecorder) HGetAll(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HGetAll", reflect.TypeOf((*MockHashStorage)(nil).HGetAll), key)
+}
+
+// HIncrBy mocks base method.
+func (m *MockHashStorage) HIncrBy(key, field string, increment int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HIncrBy", key, field, increment)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HIncrBy indicates an expected call of HIncrBy.
+func (mr *MockHashStorageMockRecorder) HIncrBy(key, field, increment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HIncrBy", reflect.TypeOf((*MockHashStorage)(nil).HIncrBy), key, field, increment)
+}
+
+// HKeys mocks base method.
+func (m *MockHashStorage) HKeys(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HKeys", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HKeys indicates an expected call of HKeys.
+func (mr *MockHashStorageMockRecorder) HKeys(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HKeys", reflect.TypeOf((*MockHashStorage)(nil).HKeys), key)
+}
+
+// HLen mocks base method.
+func (m *MockHashStorage) HLen(key string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HLen", key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HLen indicates an expected call of HLen.
+func (mr *MockHashStorageMockRecorder) HLen(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HLen", reflect.TypeOf((*MockHashStorage)(nil).HLen), key)
+}
+
+// HSet mocks base method.
+func (m *MockHashStorage) HSet(key string, fields map[string]string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HSet", key, fields)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HSet indicates an expected call of HSet.
+func (mr *MockHashStorageMockRecorder) HSet(key, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HSet", reflect.TypeOf((*MockHashStorage)(nil).HSet), key, fields)
+}
+
+// HVals mocks base method.
+func (m *MockHashStorage) HVals(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HVals", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HVals indicates an expected call of HVals.
+func (mr *MockHashStorageMockRecorder) HVals(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HVals", reflect.TypeOf((*MockHashStorage)(nil).HVals), key)
+}
+
+// MockSetStorage is a mock of SetStorage interface.
+type MockSetStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockSetStorageMockRecorder
+}
+
+// MockSetStorageMockRecorder is the mock recorder for MockSetStorage.
+type MockSetStorageMockRecorder struct {
+	mock *MockSetStorage
+}
+
+// NewMockSetStorage creates a new mock instance.
+func NewMockSetStorage(ctrl *gomock.Controller) *MockSetStorage {
+	mock := &MockSetStorage{ctrl: ctrl}
+	mock.recorder = &MockSetStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSetStorage) EXPECT() *MockSetStorageMockRecorder {
+	return m.recorder
+}
+
+// SAdd mocks base method.
+func (m *MockSetStorage) SAdd(key string, members []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SAdd", key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SAdd indicates an expected call of SAdd.
+func (mr *MockSetStorageMockRecorder) SAdd(key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockSetStorage)(nil).SAdd), key, members)
+}
+
+// SCard mocks base method.
+func (m *MockSetStorage) SCard(key string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SCard", key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SCard indicates an expected call of SCard.
+func (mr *MockSetStorageMockRecorder) SCard(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SCard", reflect.TypeOf((*MockSetStorage)(nil).SCard), key)
+}
+
+// SDiff mocks base method.
+func (m *MockSetStorage) SDiff(keys []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SDiff", keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SDiff indicates an expected call of SDiff.
+func (mr *MockSetStorageMockRecorder) SDiff(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SDiff", reflect.TypeOf((*MockSetStorage)(nil).SDiff), keys)
+}
+
+// SDiffStore mocks base method.
+func (m *MockSetStorage) SDiffStore(destination string, keys []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SDiffStore", destination, keys)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SDiffStore indicates an expected call of SDiffStore.
+func (mr *MockSetStorageMockRecorder) SDiffStore(destination, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SDiffStore", reflect.TypeOf((*MockSetStorage)(nil).SDiffStore), destination, keys)
+}
+
+// SInter mocks base method.
+func (m *MockSetStorage) SInter(keys []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SInter", keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SInter indicates an expected call of SInter.
+func (mr *MockSetStorageMockRecorder) SInter(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SInter", reflect.TypeOf((*MockSetStorage)(nil).SInter), keys)
+}
+
+// SInterStore mocks base method.
+func (m *MockSetStorage) SInterStore(destination string, keys []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SInterStore", destination, keys)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SInterStore indicates an expected call of SInterStore.
+func (mr *MockSetStorageMockRecorder) SInterStore(destination, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SInterStore", reflect.TypeOf((*MockSetStorage)(nil).SInterStore), destination, keys)
+}
+
+// SIsMember mocks base method.
+func (m *MockSetStorage) SIsMember(key, member string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SIsMember", key, member)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SIsMember indicates an expected call of SIsMember.
+func (mr *MockSetStorageMockRecorder) SIsMember(key, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SIsMember", reflect.TypeOf((*MockSetStorage)(nil).SIsMember), key, member)
+}
+
+// SMembers mocks base method.
+func (m *MockSetStorage) SMembers(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers.
+func (mr *MockSetStorageMockRecorder) SMembers(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockSetStorage)(nil).SMembers), key)
+}
+
+// SPop mocks base method.
+func (m *MockSetStorage) SPop(key string, count int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SPop", key, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SPop indicates an expected call of SPop.
+func (mr *MockSetStorageMockRecorder) SPop(key, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SPop", reflect.TypeOf((*MockSetStorage)(nil).SPop), key, count)
+}
+
+// SRandMember mocks base method.
+func (m *MockSetStorage) SRandMember(key string, count int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SRandMember", key, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SRandMember indicates an expected call of SRandMember.
+func (mr *MockSetStorageMockRecorder) SRandMember(key, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRandMember", reflect.TypeOf((*MockSetStorage)(nil).SRandMember), key, count)
+}
+
+// SRem mocks base method.
+func (m *MockSetStorage) SRem(key string, members []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SRem", key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SRem indicates an expected call of SRem.
+func (mr *MockSetStorageMockRecorder) SRem(key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRem", reflect.TypeOf((*MockSetStorage)(nil).SRem), key, members)
+}
+
+// SUnion mocks base method.
+func (m *MockSetStorage) SUnion(keys []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SUnion", keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SUnion indicates an expected call of SUnion.
+func (mr *MockSetStorageMockRecorder) SUnion(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SUnion", reflect.TypeOf((*MockSetStorage)(nil).SUnion), keys)
+}
+
+// SUnionStore mocks base method.
+func (m *MockSetStorage) SUnionStore(destination string, keys []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SUnionStore", destination, keys)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SUnionStore indicates an expected call of SUnionStore.
+func (mr *MockSetStorageMockRecorder) SUnionStore(destination, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SUnionStore", reflect.TypeOf((*MockSetStorage)(nil).SUnionStore), destination, keys)
+}
+
+// MockSortedSetStorage is a mock of SortedSetStorage interface.
+type MockSortedSetStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockSortedSetStorageMockRecorder
+}
+
+// MockSortedSetStorageMockRecorder is the mock recorder for MockSortedSetStorage.
+type MockSortedSetStorageMockRecorder struct {
+	mock *MockSortedSetStorage
+}
+
+// NewMockSortedSetStorage creates a new mock instance.
+func NewMockSortedSetStorage(ctrl *gomock.Controller) *MockSortedSetStorage {
+	mock := &MockSortedSetStorage{ctrl: ctrl}
+	mock.recorder = &MockSortedSetStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSortedSetStorage) EXPECT() *MockSortedSetStorageMockRecorder {
+	return m.recorder
+}
+
+// ZAdd mocks base method.
+func (m *MockSortedSetStorage) ZAdd(key string, members map[string]float64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZAdd", key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZAdd indicates an expected call of ZAdd.
+func (mr *MockSortedSetStorageMockRecorder) ZAdd(key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZAdd", reflect.TypeOf((*MockSortedSetStorage)(nil).ZAdd), key, members)
+}
+
+// ZCount mocks base method.
+func (m *MockSortedSetStorage) ZCount(key string, min, max storage.ScoreBound) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZCount", key, min, max)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZCount indicates an expected call of ZCount.
+func (mr *MockSortedSetStorageMockRecorder) ZCount(key, min, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZCount", reflect.TypeOf((*MockSortedSetStorage)(nil).ZCount), key, min, max)
+}
+
+// ZIncrBy mocks base method.
+func (m *MockSortedSetStorage) ZIncrBy(key, member string, increment float64) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZIncrBy", key, member, increment)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZIncrBy indicates an expected call of ZIncrBy.
+func (mr *MockSortedSetStorageMockRecorder) ZIncrBy(key, member, increment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZIncrBy", reflect.TypeOf((*MockSortedSetStorage)(nil).ZIncrBy), key, member, increment)
+}
+
+// ZRange mocks base method.
+func (m *MockSortedSetStorage) ZRange(key string, start, stop int, reverse bool) ([]storage.ScoredMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRange", key, start, stop, reverse)
+	ret0, _ := ret[0].([]storage.ScoredMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZRange indicates an expected call of ZRange.
+func (mr *MockSortedSetStorageMockRecorder) ZRange(key, start, stop, reverse interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRange", reflect.TypeOf((*MockSortedSetStorage)(nil).ZRange), key, start, stop, reverse)
+}
+
+// ZRangeByScore mocks base method.
+func (m *MockSortedSetStorage) ZRangeByScore(key string, min, max storage.ScoreBound) ([]storage.ScoredMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRangeByScore", key, min, max)
+	ret0, _ := ret[0].([]storage.ScoredMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZRangeByScore indicates an expected call of ZRangeByScore.
+func (mr *MockSortedSetStorageMockRecorder) ZRangeByScore(key, min, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRangeByScore", reflect.TypeOf((*MockSortedSetStorage)(nil).ZRangeByScore), key, min, max)
+}
+
+// ZRank mocks base method.
+func (m *MockSortedSetStorage) ZRank(key, member string, reverse bool) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRank", key, member, reverse)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ZRank indicates an expected call of ZRank.
+func (mr *MockSortedSetStorageMockRecorder) ZRank(key, member, reverse interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRank", reflect.TypeOf((*MockSortedSetStorage)(nil).ZRank), key, member, reverse)
+}
+
+// ZRem mocks base method.
+func (m *MockSortedSetStorage) ZRem(key string, members []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZRem", key, members)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ZRem indicates an expected call of ZRem.
+func (mr *MockSortedSetStorageMockRecorder) ZRem(key, members interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZRem", reflect.TypeOf((*MockSortedSetStorage)(nil).ZRem), key, members)
+}
+
+// ZScore mocks base method.
+func (m *MockSortedSetStorage) ZScore(key, member string) (float64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ZScore", key, member)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ZScore indicates an expected call of ZScore.
+func (mr *MockSortedSetStorageMockRecorder) ZScore(key, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZScore", reflect.TypeOf((*MockSortedSetStorage)(nil).ZScore), key, member)
+}
+
+// MockMemoryLimitedStorage is a mock of MemoryLimitedStorage interface.
+type MockMemoryLimitedStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockMemoryLimitedStorageMockRecorder
+}
+
+// MockMemoryLimitedStorageMockRecorder is the mock recorder for MockMemoryLimitedStorage.
+type MockMemoryLimitedStorageMockRecorder struct {
+	mock *MockMemoryLimitedStorage
+}
+
+// NewMockMemoryLimitedStorage creates a new mock instance.
+func NewMockMemoryLimitedStorage(ctrl *gomock.Controller) *MockMemoryLimitedStorage {
+	mock := &MockMemoryLimitedStorage{ctrl: ctrl}
+	mock.recorder = &MockMemoryLimitedStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMemoryLimitedStorage) EXPECT() *MockMemoryLimitedStorageMockRecorder {
+	return m.recorder
+}
+
+// FreeMemory mocks base method.
+func (m *MockMemoryLimitedStorage) FreeMemory() ([]string, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreeMemory")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// FreeMemory indicates an expected call of FreeMemory.
+func (mr *MockMemoryLimitedStorageMockRecorder) FreeMemory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreeMemory", reflect.TypeOf((*MockMemoryLimitedStorage)(nil).FreeMemory))
+}
+
+// MemoryStats mocks base method.
+func (m *MockMemoryLimitedStorage) MemoryStats() storage.MemoryStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MemoryStats")
+	ret0, _ := ret[0].(storage.MemoryStats)
+	return ret0
+}
+
+// MemoryStats indicates an expected call of MemoryStats.
+func (mr *MockMemoryLimitedStorageMockRecorder) MemoryStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemoryStats", reflect.TypeOf((*MockMemoryLimitedStorage)(nil).MemoryStats))
+}
+
+// MockHistoryStorage is a mock of HistoryStorage interface.
+type MockHistoryStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryStorageMockRecorder
+}
+
+// MockHistoryStorageMockRecorder is the mock recorder for MockHistoryStorage.
+type MockHistoryStorageMockRecorder struct {
+	mock *MockHistoryStorage
+}
+
+// NewMockHistoryStorage creates a new mock instance.
+func NewMockHistoryStorage(ctrl *gomock.Controller) *MockHistoryStorage {
+	mock := &MockHistoryStorage{ctrl: ctrl}
+	mock.recorder = &MockHistoryStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistoryStorage) EXPECT() *MockHistoryStorageMockRecorder {
+	return m.recorder
+}
+
+// History mocks base method.
+func (m *MockHistoryStorage) History(key string) ([]storage.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", key)
+	ret0, _ := ret[0].([]storage.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockHistoryStorageMockRecorder) History(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockHistoryStorage)(nil).History), key)
+}
+
+// Revert mocks base method.
+func (m *MockHistoryStorage) Revert(key string, number uint64) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", key, number)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockHistoryStorageMockRecorder) Revert(key, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockHistoryStorage)(nil).Revert), key, number)
+}
+
+// VersionAt mocks base method.
+func (m *MockHistoryStorage) VersionAt(key string, number uint64) (storage.Version, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VersionAt", key, number)
+	ret0, _ := ret[0].(storage.Version)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VersionAt indicates an expected call of VersionAt.
+func (mr *MockHistoryStorageMockRecorder) VersionAt(key, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionAt", reflect.TypeOf((*MockHistoryStorage)(nil).VersionAt), key, number)
+}
+
+// VersionAtTime mocks base method.
+func (m *MockHistoryStorage) VersionAtTime(key string, at time.Time) (storage.Version, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VersionAtTime", key, at)
+	ret0, _ := ret[0].(storage.Version)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VersionAtTime indicates an expected call of VersionAtTime.
+func (mr *MockHistoryStorageMockRecorder) VersionAtTime(key, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionAtTime", reflect.TypeOf((*MockHistoryStorage)(nil).VersionAtTime), key, at)
+}
+
+// MockIndexStorage is a mock of IndexStorage interface.
+type MockIndexStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockIndexStorageMockRecorder
+}
+
+// MockIndexStorageMockRecorder is the mock recorder for MockIndexStorage.
+type MockIndexStorageMockRecorder struct {
+	mock *MockIndexStorage
+}
+
+// NewMockIndexStorage creates a new mock instance.
+func NewMockIndexStorage(ctrl *gomock.Controller) *MockIndexStorage {
+	mock := &MockIndexStorage{ctrl: ctrl}
+	mock.recorder = &MockIndexStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIndexStorage) EXPECT() *MockIndexStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateIndex mocks base method.
+func (m *MockIndexStorage) CreateIndex(definition storage.IndexDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIndex", definition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIndex indicates an expected call of CreateIndex.
+func (mr *MockIndexStorageMockRecorder) CreateIndex(definition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIndex", reflect.TypeOf((*MockIndexStorage)(nil).CreateIndex), definition)
+}
+
+// DropIndex mocks base method.
+func (m *MockIndexStorage) DropIndex(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropIndex", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropIndex indicates an expected call of DropIndex.
+func (mr *MockIndexStorageMockRecorder) DropIndex(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropIndex", reflect.TypeOf((*MockIndexStorage)(nil).DropIndex), name)
+}
+
+// Find mocks base method.
+func (m *MockIndexStorage) Find(name, value string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", name, value)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockIndexStorageMockRecorder) Find(name, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockIndexStorage)(nil).Find), name, value)
+}
+
+// FindRange mocks base method.
+func (m *MockIndexStorage) FindRange(name string, min, max storage.IndexRange) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRange", name, min, max)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRange indicates an expected call of FindRange.
+func (mr *MockIndexStorageMockRecorder) FindRange(name, min, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRange", reflect.TypeOf((*MockIndexStorage)(nil).FindRange), name, min, max)
+}
+
+// MockCompressingStorage is a mock of CompressingStorage interface.
+type MockCompressingStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompressingStorageMockRecorder
+}
+
+// MockCompressingStorageMockRecorder is the mock recorder for MockCompressingStorage.
+type MockCompressingStorageMockRecorder struct {
+	mock *MockCompressingStorage
+}
+
+// NewMockCompressingStorage creates a new mock instance.
+func NewMockCompressingStorage(ctrl *gomock.Controller) *MockCompressingStorage {
+	mock := &MockCompressingStorage{ctrl: ctrl}
+	mock.recorder = &MockCompressingStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompressingStorage) EXPECT() *MockCompressingStorageMockRecorder {
+	return m.recorder
+}
+
+// CompressionStats mocks base method.
+func (m *MockCompressingStorage) CompressionStats() storage.CompressionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompressionStats")
+	ret0, _ := ret[0].(storage.CompressionStats)
+	return ret0
+}
+
+// CompressionStats indicates an expected call of CompressionStats.
+func (mr *MockCompressingStorageMockRecorder) CompressionStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompressionStats", reflect.TypeOf((*MockCompressingStorage)(nil).CompressionStats))
+}
+
 // MockParser is a mock of Parser interface.
 type MockParser struct {
 	ctrl     *gomock.Controller
@@ -110,3 +1664,168 @@ func (mr *MockParserMockRecorder) ParseArgs(s interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseArgs", reflect.TypeOf((*MockParser)(nil).ParseArgs), s)
 }
+
+// Validate mocks base method.
+func (m *MockParser) Validate(command string, args []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", command, args)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockParserMockRecorder) Validate(command, args interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockParser)(nil).Validate), command, args)
+}
+
+// MockWAL is a mock of WAL interface.
+type MockWAL struct {
+	ctrl     *gomock.Controller
+	recorder *MockWALMockRecorder
+}
+
+// MockWALMockRecorder is the mock recorder for MockWAL.
+type MockWALMockRecorder struct {
+	mock *MockWAL
+}
+
+// NewMockWAL creates a new mock instance.
+func NewMockWAL(ctrl *gomock.Controller) *MockWAL {
+	mock := &MockWAL{ctrl: ctrl}
+	mock.recorder = &MockWALMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWAL) EXPECT() *MockWALMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockWAL) Append(command string, args []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", command, args)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockWALMockRecorder) Append(command, args interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockWAL)(nil).Append), command, args)
+}
+
+// MockReplicationNode is a mock of ReplicationNode interface.
+type MockReplicationNode struct {
+	ctrl     *gomock.Controller
+	recorder *MockReplicationNodeMockRecorder
+}
+
+// MockReplicationNodeMockRecorder is the mock recorder for MockReplicationNode.
+type MockReplicationNodeMockRecorder struct {
+	mock *MockReplicationNode
+}
+
+// NewMockReplicationNode creates a new mock instance.
+func NewMockReplicationNode(ctrl *gomock.Controller) *MockReplicationNode {
+	mock := &MockReplicationNode{ctrl: ctrl}
+	mock.recorder = &MockReplicationNodeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReplicationNode) EXPECT() *MockReplicationNodeMockRecorder {
+	return m.recorder
+}
+
+// Info mocks base method.
+func (m *MockReplicationNode) Info() []compute.InfoField {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Info")
+	ret0, _ := ret[0].([]compute.InfoField)
+	return ret0
+}
+
+// Info indicates an expected call of Info.
+func (mr *MockReplicationNodeMockRecorder) Info() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockReplicationNode)(nil).Info))
+}
+
+// Propagate mocks base method.
+func (m *MockReplicationNode) Propagate(command string, args []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Propagate", command, args)
+}
+
+// Propagate indicates an expected call of Propagate.
+func (mr *MockReplicationNodeMockRecorder) Propagate(command, args interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Propagate", reflect.TypeOf((*MockReplicationNode)(nil).Propagate), command, args)
+}
+
+// ReadOnly mocks base method.
+func (m *MockReplicationNode) ReadOnly() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadOnly")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ReadOnly indicates an expected call of ReadOnly.
+func (mr *MockReplicationNodeMockRecorder) ReadOnly() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOnly", reflect.TypeOf((*MockReplicationNode)(nil).ReadOnly))
+}
+
+// MockSnapshotter is a mock of Snapshotter interface.
+type MockSnapshotter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotterMockRecorder
+}
+
+// MockSnapshotterMockRecorder is the mock recorder for MockSnapshotter.
+type MockSnapshotterMockRecorder struct {
+	mock *MockSnapshotter
+}
+
+// NewMockSnapshotter creates a new mock instance.
+func NewMockSnapshotter(ctrl *gomock.Controller) *MockSnapshotter {
+	mock := &MockSnapshotter{ctrl: ctrl}
+	mock.recorder = &MockSnapshotterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnapshotter) EXPECT() *MockSnapshotterMockRecorder {
+	return m.recorder
+}
+
+// BackgroundSave mocks base method.
+func (m *MockSnapshotter) BackgroundSave() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackgroundSave")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BackgroundSave indicates an expected call of BackgroundSave.
+func (mr *MockSnapshotterMockRecorder) BackgroundSave() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackgroundSave", reflect.TypeOf((*MockSnapshotter)(nil).BackgroundSave))
+}
+
+// Save mocks base method.
+func (m *MockSnapshotter) Save() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockSnapshotterMockRecorder) Save() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockSnapshotter)(nil).Save))
+}
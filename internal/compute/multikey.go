@@ -0,0 +1,86 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errMultiKeyNotSupported = errors.New("Storage engine does not support multi-key commands")
+
+func (c *dbHandler) multiKeyStorage() (MultiKeyStorage, error) {
+	storage, ok := c.storage.(MultiKeyStorage)
+	if !ok {
+		c.logger.Error("storage does not implement MultiKeyStorage")
+
+		return nil, errMultiKeyNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeMultiKey handles commands working with several string keys at
+// once, the arguments are already validated.
+func (c *dbHandler) executeMultiKey(command string, args []string) (string, error) {
+	storage, err := c.multiKeyStorage()
+	if err != nil {
+		return "", err
+	}
+
+	switch command {
+	case MGetCmd:
+		values, found := storage.MGet(args)
+		for i := range values {
+			if !found[i] {
+				values[i] = nilReply
+			}
+		}
+
+		fmt.Printf("Values found: %v\n", values)
+
+		return arrayReply(values), nil
+	case MSetCmd:
+		storage.MSet(args)
+		if err := c.journal(MSetCmd, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("%d values saved\n", len(args)/2)
+
+		return "OK", nil
+	case MSetNXCmd:
+		if !storage.MSetNX(args) {
+			fmt.Println("Values not saved, some of the keys exist")
+
+			return "0", nil
+		}
+		// journaled as mset, the keys are known to be missing
+		if err := c.journal(MSetCmd, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("%d values saved\n", len(args)/2)
+
+		return "1", nil
+	default:
+		deleted := storage.MDelete(args)
+
+		replies := make([]string, len(args))
+		removed := make([]string, 0, len(args))
+		for i, key := range args {
+			replies[i] = "0"
+			if deleted[i] {
+				replies[i] = "1"
+				removed = append(removed, key)
+			}
+		}
+		if len(removed) > 0 {
+			if err := c.journal(MDelCmd, removed...); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Printf("%d values deleted\n", len(removed))
+
+		return arrayReply(replies), nil
+	}
+}
@@ -0,0 +1,144 @@
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// eventClass is a set of keyspace event classes.
+type eventClass uint16
+
+const (
+	genericEvents eventClass = 1 << iota
+	stringEvents
+	listEvents
+	hashEvents
+	setEvents
+	zsetEvents
+	expiredEvents
+	evictedEvents
+
+	allEvents = genericEvents | stringEvents | listEvents | hashEvents | setEvents | zsetEvents | expiredEvents | evictedEvents
+)
+
+var eventClasses = map[string]eventClass{
+	"generic": genericEvents,
+	"string":  stringEvents,
+	"list":    listEvents,
+	"hash":    hashEvents,
+	"set":     setEvents,
+	"zset":    zsetEvents,
+	"expired": expiredEvents,
+	"evicted": evictedEvents,
+	"all":     allEvents,
+}
+
+type keyspaceEvent struct {
+	class eventClass
+	name  string
+}
+
+// commandEvents maps journaled commands to the events they emit, the key
+// of an event is the first argument of the command.
+var commandEvents = map[string]keyspaceEvent{
+	SetCmd:         {stringEvents, "set"},
+	CASCmd:         {stringEvents, "set"},
+	IncrCmd:        {stringEvents, "incrby"},
+	DecrCmd:        {stringEvents, "incrby"},
+	IncrByCmd:      {stringEvents, "incrby"},
+	DecrByCmd:      {stringEvents, "incrby"},
+	IncrByFloatCmd: {stringEvents, "incrbyfloat"},
+	DeleteCmd:      {genericEvents, "del"},
+	PExpireAtCmd:   {genericEvents, "expire"},
+	PersistCmd:     {genericEvents, "persist"},
+	MoveCmd:        {genericEvents, "move_from"},
+	LPushCmd:       {listEvents, "lpush"},
+	RPushCmd:       {listEvents, "rpush"},
+	LPopCmd:        {listEvents, "lpop"},
+	RPopCmd:        {listEvents, "rpop"},
+	LRemCmd:        {listEvents, "lrem"},
+	LTrimCmd:       {listEvents, "ltrim"},
+	HSetCmd:        {hashEvents, "hset"},
+	HDelCmd:        {hashEvents, "hdel"},
+	HIncrByCmd:     {hashEvents, "hincrby"},
+	SAddCmd:        {setEvents, "sadd"},
+	SRemCmd:        {setEvents, "srem"},
+	SInterStoreCmd: {setEvents, "sinterstore"},
+	SUnionStoreCmd: {setEvents, "sunionstore"},
+	SDiffStoreCmd:  {setEvents, "sdiffstore"},
+	ZAddCmd:        {zsetEvents, "zadd"},
+	ZRemCmd:        {zsetEvents, "zrem"},
+	ZIncrByCmd:     {zsetEvents, "zincrby"},
+}
+
+// SetKeyspaceEvents enables publishing of keyspace events of the given
+// classes, no events are published by default. It must be called before
+// the handler starts serving requests.
+func (c *ComputeHandler) SetKeyspaceEvents(classes []string) error {
+	var events eventClass
+	for _, name := range classes {
+		class, found := eventClasses[strings.ToLower(name)]
+		if !found {
+			return fmt.Errorf("unknown keyspace event class %q", name)
+		}
+		events |= class
+	}
+
+	if events&expiredEvents != 0 {
+		if c.databases != nil {
+			c.databases.OnExpired(func(db int, key string) {
+				c.notify(db, expiredEvents, "expired", key)
+			})
+		} else if storage, ok := c.storage.(NotifyingStorage); ok {
+			storage.OnExpired(func(key string) {
+				c.notify(0, expiredEvents, "expired", key)
+			})
+		} else {
+			return errNotifyingStorage
+		}
+	}
+	c.keyspaceEvents = events
+
+	return nil
+}
+
+// notifyCommand publishes the event of an applied write command.
+func (c *dbHandler) notifyCommand(command string, args []string) {
+	if c.keyspaceEvents == 0 || len(args) == 0 {
+		return
+	}
+
+	// multi-key commands emit an event for every key
+	switch command {
+	case MSetCmd:
+		for i := 0; i < len(args); i += 2 {
+			c.notify(c.index, stringEvents, "set", args[i])
+		}
+
+		return
+	case MDelCmd:
+		for _, key := range args {
+			c.notify(c.index, genericEvents, "del", key)
+		}
+
+		return
+	}
+
+	if event, found := commandEvents[command]; found {
+		c.notify(c.index, event.class, event.name, args[0])
+	}
+}
+
+// notify publishes the event to the __keyspace@<db>__:<key> channel with
+// the event as the message and to the __keyevent@<db>__:<event> channel with
+// the key as the message, clients filter them with psubscribe.
+func (c *ComputeHandler) notify(db int, class eventClass, event, key string) {
+	if c.keyspaceEvents&class == 0 {
+		return
+	}
+
+	suffix := "@" + strconv.Itoa(db) + "__:"
+	c.broker.Publish("__keyspace"+suffix+key, event)
+	c.broker.Publish("__keyevent"+suffix+event, key)
+}
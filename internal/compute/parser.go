@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"umemory/internal/network"
 )
 
 const (
@@ -13,15 +16,209 @@ const (
 	GetCmd string = "get"
 	SetCmd string = "set"
 	DeleteCmd string = "delete"
+	ExpireCmd string = "expire"
+	TTLCmd string = "ttl"
+	PTTLCmd string = "pttl"
+	PersistCmd string = "persist"
+	PExpireAtCmd string = "pexpireat"
+	SaveCmd string = "save"
+	BgSaveCmd string = "bgsave"
+	InfoCmd string = "info"
+	LPushCmd string = "lpush"
+	RPushCmd string = "rpush"
+	LPopCmd string = "lpop"
+	RPopCmd string = "rpop"
+	LLenCmd string = "llen"
+	LRangeCmd string = "lrange"
+	LIndexCmd string = "lindex"
+	LRemCmd string = "lrem"
+	LTrimCmd string = "ltrim"
+	HSetCmd string = "hset"
+	HGetCmd string = "hget"
+	HDelCmd string = "hdel"
+	HGetAllCmd string = "hgetall"
+	HKeysCmd string = "hkeys"
+	HValsCmd string = "hvals"
+	HLenCmd string = "hlen"
+	HExistsCmd string = "hexists"
+	HIncrByCmd string = "hincrby"
+	SAddCmd string = "sadd"
+	SRemCmd string = "srem"
+	SMembersCmd string = "smembers"
+	SIsMemberCmd string = "sismember"
+	SCardCmd string = "scard"
+	SPopCmd string = "spop"
+	SRandMemberCmd string = "srandmember"
+	SInterCmd string = "sinter"
+	SUnionCmd string = "sunion"
+	SDiffCmd string = "sdiff"
+	SInterStoreCmd string = "sinterstore"
+	SUnionStoreCmd string = "sunionstore"
+	SDiffStoreCmd string = "sdiffstore"
+	ZAddCmd string = "zadd"
+	ZRemCmd string = "zrem"
+	ZScoreCmd string = "zscore"
+	ZIncrByCmd string = "zincrby"
+	ZRankCmd string = "zrank"
+	ZRevRankCmd string = "zrevrank"
+	ZRangeCmd string = "zrange"
+	ZRevRangeCmd string = "zrevrange"
+	ZRangeByScoreCmd string = "zrangebyscore"
+	ZCountCmd string = "zcount"
+	IncrCmd string = "incr"
+	DecrCmd string = "decr"
+	IncrByCmd string = "incrby"
+	DecrByCmd string = "decrby"
+	IncrByFloatCmd string = "incrbyfloat"
+	MultiCmd string = "multi"
+	ExecCmd string = "exec"
+	DiscardCmd string = "discard"
+	WatchCmd string = "watch"
+	UnwatchCmd string = "unwatch"
+	CASCmd string = "cas"
+	ScanCmd string = "scan"
+	KeysCmd string = "keys"
+	DBSizeCmd string = "dbsize"
+	RangeCmd string = "range"
+	RevRangeCmd string = "revrange"
+	PrefixCmd string = "prefix"
+	SubscribeCmd string = "subscribe"
+	UnsubscribeCmd string = "unsubscribe"
+	PSubscribeCmd string = "psubscribe"
+	PUnsubscribeCmd string = "punsubscribe"
+	PublishCmd string = "publish"
+	SelectCmd string = "select"
+	MoveCmd string = "move"
+	SwapDBCmd string = "swapdb"
+	FlushDBCmd string = "flushdb"
+	FlushAllCmd string = "flushall"
+	MGetCmd string = "mget"
+	MSetCmd string = "mset"
+	MSetNXCmd string = "msetnx"
+	MDelCmd string = "mdel"
+	HistoryCmd string = "history"
+	GetAtCmd string = "getat"
+	RevertCmd string = "revert"
+	IndexCmd string = "index"
+	FindCmd string = "find"
+	FindRangeCmd string = "findrange"
+
+	// set options
+	ExOption string = "EX"
+	PxOption string = "PX"
+	PxatOption string = "PXAT"
+
+	// sorted set range options
+	WithScoresOption string = "WITHSCORES"
+
+	// scan options
+	MatchOption string = "MATCH"
+	CountOption string = "COUNT"
+
+	// range and prefix options
+	LimitOption string = "LIMIT"
+
+	// index subcommands and options
+	IndexCreate string = "create"
+	IndexDrop string = "drop"
+	OnOption string = "ON"
+	FieldOption string = "FIELD"
 )
 
+var writeCommands = map[string]struct{}{
+	SetCmd: {},
+	DeleteCmd: {},
+	ExpireCmd: {},
+	PExpireAtCmd: {},
+	PersistCmd: {},
+	LPushCmd: {},
+	RPushCmd: {},
+	LPopCmd: {},
+	RPopCmd: {},
+	LRemCmd: {},
+	LTrimCmd: {},
+	HSetCmd: {},
+	HDelCmd: {},
+	HIncrByCmd: {},
+	SAddCmd: {},
+	SRemCmd: {},
+	SPopCmd: {},
+	SInterStoreCmd: {},
+	SUnionStoreCmd: {},
+	SDiffStoreCmd: {},
+	ZAddCmd: {},
+	ZRemCmd: {},
+	ZIncrByCmd: {},
+	IncrCmd: {},
+	DecrCmd: {},
+	IncrByCmd: {},
+	DecrByCmd: {},
+	IncrByFloatCmd: {},
+	CASCmd: {},
+	MoveCmd: {},
+	SwapDBCmd: {},
+	FlushDBCmd: {},
+	FlushAllCmd: {},
+	MSetCmd: {},
+	MSetNXCmd: {},
+	MDelCmd: {},
+	RevertCmd: {},
+	IndexCmd: {},
+}
+
+// growCommands may increase the memory used by the storage, they are
+// rejected when the memory limit is reached and nothing can be evicted.
+var growCommands = map[string]struct{}{
+	SetCmd: {},
+	LPushCmd: {},
+	RPushCmd: {},
+	HSetCmd: {},
+	HIncrByCmd: {},
+	SAddCmd: {},
+	SInterStoreCmd: {},
+	SUnionStoreCmd: {},
+	SDiffStoreCmd: {},
+	ZAddCmd: {},
+	ZIncrByCmd: {},
+	IncrCmd: {},
+	DecrCmd: {},
+	IncrByCmd: {},
+	DecrByCmd: {},
+	IncrByFloatCmd: {},
+	CASCmd: {},
+	MSetCmd: {},
+	MSetNXCmd: {},
+	RevertCmd: {},
+	IndexCmd: {},
+}
+
+// IsWriteCommand reports whether the command modifies the keyspace.
+func IsWriteCommand(command string) bool {
+	_, found := writeCommands[command]
+
+	return found
+}
+
+func growsMemory(command string) bool {
+	_, found := growCommands[command]
+
+	return found
+}
+
 type RequestParser struct{}
 
 func NewRequestParser() *RequestParser {
 	return &RequestParser{}
 }
 
+// ParseArgs parses a plain text request or a binary one, see
+// network.EncodeRequest. Arguments of a binary request may hold any bytes,
+// plain text arguments are restricted to the available symbols.
 func (b *RequestParser) ParseArgs(s string) (string, []string, error) {
+	if network.IsBinaryRequest([]byte(s)) {
+		return b.parseBinary(s)
+	}
+
 	rawArgs := strings.Split(s, " ")
 	command := strings.Trim(rawArgs[0], "\t\n ")
 	args := make([]string, 0, len(rawArgs[1:]))
@@ -29,7 +226,10 @@ func (b *RequestParser) ParseArgs(s string) (string, []string, error) {
 		args = append(args, strings.Trim(arg, "\t\n "))
 	}
 
-	err := b.validate(command, args)
+	err := b.Validate(command, args)
+	if err == nil {
+		err = validateSymbols(args)
+	}
 	if err != nil {
 		fmt.Println("ParseArgs validate error: " + err.Error())
 		return "", nil, err
@@ -44,7 +244,7 @@ func (b *RequestParser) ParseArgs(s string) (string, []string, error) {
 	return command, args, nil
 }
 
-func (b *RequestParser) validate(command string, args []string) error {
+func (b *RequestParser) Validate(command string, args []string) error {
 	ln := len(args)
 
 	switch command {
@@ -53,17 +253,307 @@ func (b *RequestParser) validate(command string, args []string) error {
 			return fmt.Errorf("expected 1 argument, got %d", ln)
 		}
 	case SetCmd:
-		if ln != 2 {
-			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		if ln != 2 && ln != 4 {
+			return fmt.Errorf("expected 2 or 4 arguments, got %d", ln)
 		}
-	case DeleteCmd:
+		if ln == 4 {
+			option := strings.ToUpper(args[2])
+			if option != ExOption && option != PxOption && option != PxatOption {
+				return fmt.Errorf("unknown set option %s", args[2])
+			}
+			amount, err := parsePositiveInt(args[3])
+			if err != nil {
+				return errors.New("invalid expire time in set")
+			}
+			if option == PxatOption {
+				break
+			}
+			if _, err := expireDuration(amount, expireUnit(option)); err != nil {
+				return errors.New("invalid expire time in set")
+			}
+		}
+	case DeleteCmd, TTLCmd, PTTLCmd, PersistCmd, LPopCmd, RPopCmd, LLenCmd,
+		HGetAllCmd, HKeysCmd, HValsCmd, HLenCmd, SMembersCmd, SCardCmd, IncrCmd, DecrCmd, KeysCmd, HistoryCmd:
 		if ln != 1 {
 			return fmt.Errorf("expected 1 argument, got %d", ln)
 		}
+	case InfoCmd:
+		if ln > 1 {
+			return fmt.Errorf("expected at most 1 argument, got %d", ln)
+		}
+	case SaveCmd, BgSaveCmd, MultiCmd, ExecCmd, DiscardCmd, UnwatchCmd, DBSizeCmd, FlushDBCmd, FlushAllCmd:
+		if ln != 0 {
+			return fmt.Errorf("expected 0 arguments, got %d", ln)
+		}
+	case ExpireCmd, PExpireAtCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		amount, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.New("value is not an integer or out of range")
+		}
+		if command == ExpireCmd {
+			if _, err := expireDuration(amount, time.Second); err != nil {
+				return errors.New("invalid expire time in expire")
+			}
+		}
+	case IncrByCmd, DecrByCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if _, err := strconv.ParseInt(args[1], 10, 64); err != nil {
+			return errors.New("value is not an integer or out of range")
+		}
+	case IncrByFloatCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if _, err := parseScore(args[1]); err != nil {
+			return err
+		}
+	case HSetCmd:
+		if ln < 3 || ln%2 == 0 {
+			return fmt.Errorf("expected key and field value pairs, got %d arguments", ln)
+		}
+	case HGetCmd, HExistsCmd, SIsMemberCmd, ZScoreCmd, ZRankCmd, ZRevRankCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+	case ScanCmd:
+		if ln != 1 && ln != 3 && ln != 5 {
+			return fmt.Errorf("expected 1, 3 or 5 arguments, got %d", ln)
+		}
+		if _, err := strconv.ParseUint(args[0], 10, 64); err != nil {
+			return errors.New("invalid cursor")
+		}
+		for i := 1; i+1 < ln; i += 2 {
+			switch strings.ToUpper(args[i]) {
+			case MatchOption:
+			case CountOption:
+				if _, err := parsePositiveInt(args[i+1]); err != nil {
+					return errors.New("value is not an integer or out of range")
+				}
+			default:
+				return fmt.Errorf("unknown scan option %s", args[i])
+			}
+		}
+	case RangeCmd, RevRangeCmd, PrefixCmd:
+		bounds := 2
+		if command == PrefixCmd {
+			bounds = 1
+		}
+		if ln != bounds && ln != bounds+2 {
+			return fmt.Errorf("expected %d or %d arguments, got %d", bounds, bounds+2, ln)
+		}
+		if ln == bounds+2 {
+			if strings.ToUpper(args[bounds]) != LimitOption {
+				return fmt.Errorf("unknown %s option %s", command, args[bounds])
+			}
+			if _, err := parsePositiveInt(args[bounds+1]); err != nil {
+				return errors.New("value is not an integer or out of range")
+			}
+		}
+	case UnsubscribeCmd, PUnsubscribeCmd:
+	case PublishCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+	case SelectCmd:
+		if ln < 1 {
+			return fmt.Errorf("expected at least 1 argument, got %d", ln)
+		}
+		if err := validateDBIndex(args[0]); err != nil {
+			return err
+		}
+		// select followed by a command is the journaled form of a command
+		// of another database than the first one
+		if ln > 1 {
+			return b.Validate(args[1], args[2:])
+		}
+	case MoveCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if err := validateDBIndex(args[1]); err != nil {
+			return err
+		}
+	case SwapDBCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		for _, arg := range args {
+			if err := validateDBIndex(arg); err != nil {
+				return err
+			}
+		}
+	case CASCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+	case HIncrByCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+		if _, err := strconv.ParseInt(args[2], 10, 64); err != nil {
+			return errors.New("value is not an integer or out of range")
+		}
+	case ZAddCmd:
+		if ln < 3 || ln%2 == 0 {
+			return fmt.Errorf("expected key and score member pairs, got %d arguments", ln)
+		}
+		for i := 1; i < ln; i += 2 {
+			if _, err := parseScore(args[i]); err != nil {
+				return err
+			}
+		}
+	case ZIncrByCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+		if _, err := parseScore(args[1]); err != nil {
+			return err
+		}
+	case ZRangeCmd, ZRevRangeCmd, ZRangeByScoreCmd:
+		if ln != 3 && ln != 4 {
+			return fmt.Errorf("expected 3 or 4 arguments, got %d", ln)
+		}
+		if ln == 4 && strings.ToUpper(args[3]) != WithScoresOption {
+			return fmt.Errorf("unknown %s option %s", command, args[3])
+		}
+		if command == ZRangeByScoreCmd {
+			if err := validateScoreRange(args[1], args[2]); err != nil {
+				return err
+			}
+
+			break
+		}
+		for _, arg := range args[1:3] {
+			if _, err := strconv.Atoi(arg); err != nil {
+				return errors.New("value is not an integer or out of range")
+			}
+		}
+	case ZCountCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+		if err := validateScoreRange(args[1], args[2]); err != nil {
+			return err
+		}
+	case SInterCmd, SUnionCmd, SDiffCmd, WatchCmd, SubscribeCmd, PSubscribeCmd, MGetCmd, MDelCmd:
+		if ln < 1 {
+			return fmt.Errorf("expected at least 1 argument, got %d", ln)
+		}
+	case GetAtCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if _, _, err := parseVersionOrTime(args[1]); err != nil {
+			return err
+		}
+	case RevertCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if _, err := strconv.ParseUint(args[1], 10, 64); err != nil {
+			return errors.New("version is not a positive integer")
+		}
+	case IndexCmd:
+		if ln == 0 {
+			return errors.New("expected index subcommand")
+		}
+		switch strings.ToLower(args[0]) {
+		case IndexCreate:
+			if ln != 6 {
+				return fmt.Errorf("expected 6 arguments, got %d", ln)
+			}
+			if strings.ToUpper(args[2]) != OnOption || strings.ToUpper(args[4]) != FieldOption {
+				return errors.New("expected index create name ON prefix FIELD path")
+			}
+		case IndexDrop:
+			if ln != 2 {
+				return fmt.Errorf("expected 2 arguments, got %d", ln)
+			}
+		default:
+			return fmt.Errorf("unknown index subcommand %s", args[0])
+		}
+	case FindCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+	case FindRangeCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+	case MSetCmd, MSetNXCmd:
+		if ln < 2 || ln%2 != 0 {
+			return fmt.Errorf("expected key value pairs, got %d arguments", ln)
+		}
+	case SPopCmd, SRandMemberCmd:
+		if ln != 1 && ln != 2 {
+			return fmt.Errorf("expected 1 or 2 arguments, got %d", ln)
+		}
+		if ln == 2 {
+			count, err := strconv.Atoi(args[1])
+			if err != nil || (command == SPopCmd && count < 0) {
+				return errors.New("value is out of range, must be positive")
+			}
+		}
+	case LPushCmd, RPushCmd, HDelCmd, SAddCmd, SRemCmd, SInterStoreCmd, SUnionStoreCmd, SDiffStoreCmd, ZRemCmd:
+		if ln < 2 {
+			return fmt.Errorf("expected at least 2 arguments, got %d", ln)
+		}
+	case LIndexCmd:
+		if ln != 2 {
+			return fmt.Errorf("expected 2 arguments, got %d", ln)
+		}
+		if _, err := strconv.Atoi(args[1]); err != nil {
+			return errors.New("value is not an integer or out of range")
+		}
+	case LRangeCmd, LTrimCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+		for _, arg := range args[1:] {
+			if _, err := strconv.Atoi(arg); err != nil {
+				return errors.New("value is not an integer or out of range")
+			}
+		}
+	case LRemCmd:
+		if ln != 3 {
+			return fmt.Errorf("expected 3 arguments, got %d", ln)
+		}
+		if _, err := strconv.Atoi(args[1]); err != nil {
+			return errors.New("value is not an integer or out of range")
+		}
 	default:
 		return errors.New("Unknown command")
 	}
-	
+
+	return nil
+}
+
+func (b *RequestParser) parseBinary(s string) (string, []string, error) {
+	rawArgs, err := network.DecodeRequest([]byte(s))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rawArgs) == 0 {
+		return "", nil, errors.New("Empty binary request")
+	}
+
+	command, args := rawArgs[0], rawArgs[1:]
+	if err := b.Validate(command, args); err != nil {
+		fmt.Println("ParseArgs validate error: " + err.Error())
+		return "", nil, err
+	}
+
+	return command, args, nil
+}
+
+// validateSymbols checks the arguments of a plain text request, the first
+// argument is the key.
+func validateSymbols(args []string) error {
 	r, err := regexp.Compile(availSymbolsRegexp)
 	if err != nil {
 		return errors.New("Compile regexp error")
@@ -79,4 +569,22 @@ func (b *RequestParser) validate(command string, args []string) error {
 	return nil
 }
 
+func validateDBIndex(s string) error {
+	if index, err := strconv.Atoi(s); err != nil || index < 0 {
+		return errors.New("invalid DB index")
+	}
 
+	return nil
+}
+
+func parsePositiveInt(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("expected positive number, got %d", n)
+	}
+
+	return n, nil
+}
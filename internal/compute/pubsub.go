@@ -0,0 +1,161 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"umemory/internal/network"
+	"umemory/internal/pubsub"
+)
+
+var (
+	errSubscribeMode        = errors.New("Only (P)SUBSCRIBE / (P)UNSUBSCRIBE are allowed in subscribe mode")
+	errSubscribeInsideMulti = errors.New("SUBSCRIBE inside MULTI is not allowed")
+)
+
+// subscriptionsKey stores the subscriber of a session.
+type subscriptionsKey struct{}
+
+// subscriber pushes messages published to the channels and patterns a
+// client is subscribed to to its session.
+type subscriber struct {
+	session  *network.Session
+	channels map[string]struct{}
+	patterns map[string]struct{}
+}
+
+func (s *subscriber) ID() uint64 {
+	return s.session.ID()
+}
+
+func (s *subscriber) Deliver(message pubsub.Message) bool {
+	if message.Pattern == "" {
+		return s.session.Push(arrayReply([]string{"message", message.Channel, message.Payload}))
+	}
+
+	return s.session.Push(arrayReply([]string{"pmessage", message.Pattern, message.Channel, message.Payload}))
+}
+
+func (s *subscriber) count() int {
+	return len(s.channels) + len(s.patterns)
+}
+
+func isSubscribeCommand(command string) bool {
+	switch command {
+	case SubscribeCmd, UnsubscribeCmd, PSubscribeCmd, PUnsubscribeCmd:
+		return true
+	default:
+		return false
+	}
+}
+
+// subscriberOf returns the subscriber of the session, it is unsubscribed
+// from everything when the connection is closed.
+func (c *ComputeHandler) subscriberOf(session *network.Session) *subscriber {
+	s, _ := session.Value(subscriptionsKey{}).(*subscriber)
+	if s != nil {
+		return s
+	}
+
+	s = &subscriber{
+		session:  session,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+	session.SetValue(subscriptionsKey{}, s)
+	session.OnClose(func() {
+		for channel := range s.channels {
+			c.broker.Unsubscribe(s, channel)
+		}
+		for pattern := range s.patterns {
+			c.broker.PUnsubscribe(s, pattern)
+		}
+	})
+
+	return s
+}
+
+// subscribe handles subscribe and psubscribe. The connection switches to
+// the push mode, where only subscription commands are allowed, until it is
+// unsubscribed from everything.
+func (c *ComputeHandler) subscribe(session *network.Session, command string, names []string) (string, error) {
+	if session == nil {
+		return "", errNoSession
+	}
+	if transactionOf(session) != nil {
+		return "", errSubscribeInsideMulti
+	}
+
+	s := c.subscriberOf(session)
+	replies := make([]string, 0, len(names))
+	for _, name := range names {
+		if command == SubscribeCmd {
+			if _, found := s.channels[name]; !found {
+				s.channels[name] = struct{}{}
+				c.broker.Subscribe(s, name)
+			}
+		} else if _, found := s.patterns[name]; !found {
+			s.patterns[name] = struct{}{}
+			c.broker.PSubscribe(s, name)
+		}
+
+		replies = append(replies, arrayReply([]string{command, name, strconv.Itoa(s.count())}))
+	}
+	session.SetPushMode(true)
+
+	fmt.Printf("Session %d subscribed to %v\n", session.ID(), names)
+
+	return strings.Join(replies, "\n"), nil
+}
+
+// unsubscribe handles unsubscribe and punsubscribe, without names the
+// client is unsubscribed from all channels or patterns.
+func (c *ComputeHandler) unsubscribe(session *network.Session, command string, names []string) (string, error) {
+	if session == nil {
+		return "", errNoSession
+	}
+
+	s := c.subscriberOf(session)
+	subscriptions := s.channels
+	if command == PUnsubscribeCmd {
+		subscriptions = s.patterns
+	}
+	if len(names) == 0 {
+		for name := range subscriptions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return arrayReply([]string{command, nilReply, strconv.Itoa(s.count())}), nil
+	}
+
+	replies := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, found := subscriptions[name]; found {
+			delete(subscriptions, name)
+			if command == UnsubscribeCmd {
+				c.broker.Unsubscribe(s, name)
+			} else {
+				c.broker.PUnsubscribe(s, name)
+			}
+		}
+
+		replies = append(replies, arrayReply([]string{command, name, strconv.Itoa(s.count())}))
+	}
+	session.SetPushMode(s.count() > 0)
+
+	fmt.Printf("Session %d unsubscribed from %v\n", session.ID(), names)
+
+	return strings.Join(replies, "\n"), nil
+}
+
+func (c *ComputeHandler) publish(channel, payload string) (string, error) {
+	delivered := c.broker.Publish(channel, payload)
+
+	fmt.Printf("Message published to %s delivered %d times\n", channel, delivered)
+
+	return strconv.Itoa(delivered), nil
+}
@@ -0,0 +1,69 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"umemory/internal/storage"
+)
+
+const (
+	// minKey and maxKey are range bounds meaning the first and the last key.
+	minKey = "-"
+	maxKey = "+"
+)
+
+var errRangeNotSupported = errors.New("Storage engine does not support range queries, use the ordered engine")
+
+// executeRange handles queries of keys in lexical order, the arguments are
+// already validated. Keys and values are returned in pairs, the value of a
+// key holding another type than string is nil.
+func (c *dbHandler) executeRange(command string, args []string) (string, error) {
+	ordered, ok := c.storage.(OrderedStorage)
+	if !ok {
+		c.logger.Error("storage does not implement OrderedStorage")
+
+		return "", errRangeNotSupported
+	}
+
+	var (
+		keyValues []storage.KeyValue
+		limit     int
+	)
+	switch command {
+	case RangeCmd, RevRangeCmd:
+		if len(args) == 4 {
+			limit, _ = strconv.Atoi(args[3])
+		}
+
+		start, end := args[0], args[1]
+		if start == minKey {
+			start = ""
+		}
+		if end == maxKey {
+			end = ""
+		}
+		keyValues = ordered.Range(start, end, limit, command == RevRangeCmd)
+	case PrefixCmd:
+		if len(args) == 3 {
+			limit, _ = strconv.Atoi(args[2])
+		}
+
+		keyValues = ordered.Prefix(args[0], limit)
+	default:
+		return "Unknown command", nil
+	}
+
+	values := make([]string, 0, 2*len(keyValues))
+	for _, keyValue := range keyValues {
+		value := keyValue.Value
+		if keyValue.Type != storage.StringType {
+			value = nilReply
+		}
+		values = append(values, keyValue.Key, value)
+	}
+
+	fmt.Printf("Keys found: %d\n", len(keyValues))
+
+	return arrayReply(values), nil
+}
@@ -0,0 +1,41 @@
+package compute
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	nilReply        = "(nil)"
+	emptyArrayReply = "(empty array)"
+)
+
+// arrayReply formats values as numbered lines like "1) value". Lines of
+// multiline values, e.g. nested arrays, are aligned with the first one.
+func arrayReply(values []string) string {
+	if len(values) == 0 {
+		return emptyArrayReply
+	}
+
+	var builder strings.Builder
+	for i, value := range values {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+
+		prefix := strconv.Itoa(i+1) + ") "
+		builder.WriteString(prefix)
+		builder.WriteString(strings.ReplaceAll(value, "\n", "\n"+strings.Repeat(" ", len(prefix))))
+	}
+
+	return builder.String()
+}
+
+// singleReply returns the only value or nil when there is none.
+func singleReply(values []string) string {
+	if len(values) == 0 {
+		return nilReply
+	}
+
+	return values[0]
+}
@@ -0,0 +1,56 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const defaultScanCount = 10
+
+var errScanNotSupported = errors.New("Storage engine does not support scanning keys")
+
+// executeScan handles commands listing keys, the arguments are already
+// validated.
+func (c *dbHandler) executeScan(command string, args []string) (string, error) {
+	storage, ok := c.storage.(ScanningStorage)
+	if !ok {
+		c.logger.Error("storage does not implement ScanningStorage")
+
+		return "", errScanNotSupported
+	}
+
+	switch command {
+	case ScanCmd:
+		cursor, _ := strconv.ParseUint(args[0], 10, 64)
+		pattern, count := "", defaultScanCount
+		for i := 1; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == MatchOption {
+				pattern = args[i+1]
+			} else {
+				count, _ = strconv.Atoi(args[i+1])
+			}
+		}
+
+		keys, next := storage.Scan(cursor, pattern, count)
+
+		fmt.Printf("Keys found: %v, next cursor: %d\n", keys, next)
+
+		return arrayReply([]string{strconv.FormatUint(next, 10), arrayReply(keys)}), nil
+	case KeysCmd:
+		keys := storage.Keys(args[0])
+
+		fmt.Printf("Keys found: %v\n", keys)
+
+		return arrayReply(keys), nil
+	case DBSizeCmd:
+		size := storage.DBSize()
+
+		fmt.Printf("Database size: %d\n", size)
+
+		return strconv.Itoa(size), nil
+	default:
+		return "Unknown command", nil
+	}
+}
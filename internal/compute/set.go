@@ -0,0 +1,164 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errSetsNotSupported = errors.New("Storage engine does not support sets")
+
+func (c *dbHandler) setStorage() (SetStorage, error) {
+	storage, ok := c.storage.(SetStorage)
+	if !ok {
+		c.logger.Error("storage does not implement SetStorage")
+
+		return nil, errSetsNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeSet handles set commands, the arguments are already validated.
+func (c *dbHandler) executeSet(command string, args []string) (string, error) {
+	storage, err := c.setStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	switch command {
+	case SAddCmd, SRemCmd:
+		update := storage.SAdd
+		if command == SRemCmd {
+			update = storage.SRem
+		}
+
+		changed, err := update(key, args[1:])
+		if err != nil {
+			return "", err
+		}
+		if changed > 0 {
+			if err := c.journal(command, args...); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Printf("%d members of set %s changed\n", changed, key)
+
+		return strconv.Itoa(changed), nil
+	case SMembersCmd, SInterCmd, SUnionCmd, SDiffCmd:
+		read := storage.SInter
+		switch command {
+		case SMembersCmd:
+			read = func(keys []string) ([]string, error) {
+				return storage.SMembers(keys[0])
+			}
+		case SUnionCmd:
+			read = storage.SUnion
+		case SDiffCmd:
+			read = storage.SDiff
+		}
+
+		members, err := read(args)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Members found: %v\n", members)
+
+		return arrayReply(members), nil
+	case SIsMemberCmd:
+		found, err := storage.SIsMember(key, args[1])
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("%s is not a member of set %s\n", args[1], key)
+
+			return "0", nil
+		}
+
+		fmt.Printf("%s is a member of set %s\n", args[1], key)
+
+		return "1", nil
+	case SCardCmd:
+		length, err := storage.SCard(key)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Set %s size is %d\n", key, length)
+
+		return strconv.Itoa(length), nil
+	case SPopCmd:
+		return c.spop(storage, args)
+	case SRandMemberCmd:
+		count := 1
+		if len(args) == 2 {
+			count, _ = strconv.Atoi(args[1])
+		}
+
+		members, err := storage.SRandMember(key, count)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Members found: %v\n", members)
+
+		if len(args) == 1 {
+			return singleReply(members), nil
+		}
+
+		return arrayReply(members), nil
+	case SInterStoreCmd, SUnionStoreCmd, SDiffStoreCmd:
+		store := storage.SInterStore
+		switch command {
+		case SUnionStoreCmd:
+			store = storage.SUnionStore
+		case SDiffStoreCmd:
+			store = storage.SDiffStore
+		}
+
+		length, err := store(key, args[1:])
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Set %s size is %d\n", key, length)
+
+		return strconv.Itoa(length), nil
+	default:
+		return "Unknown command", nil
+	}
+}
+
+// spop handles "spop key [count]". Popped members are random, so they are
+// journaled as removed with srem to be replayed the same way.
+func (c *dbHandler) spop(storage SetStorage, args []string) (string, error) {
+	count := 1
+	if len(args) == 2 {
+		count, _ = strconv.Atoi(args[1])
+	}
+
+	members, err := storage.SPop(args[0], count)
+	if err != nil {
+		return "", err
+	}
+	if len(members) > 0 {
+		if err := c.journal(SRemCmd, append([]string{args[0]}, members...)...); err != nil {
+			return "", err
+		}
+	}
+
+	fmt.Printf("Members popped: %v\n", members)
+
+	if len(args) == 1 {
+		return singleReply(members), nil
+	}
+
+	return arrayReply(members), nil
+}
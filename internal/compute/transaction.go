@@ -0,0 +1,205 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"umemory/internal/network"
+)
+
+const queuedReply = "QUEUED"
+
+var (
+	errNestedMulti         = errors.New("MULTI calls can not be nested")
+	errExecWithoutMulti    = errors.New("EXEC without MULTI")
+	errDiscardWithoutMulti = errors.New("DISCARD without MULTI")
+	errTransactionAborted  = errors.New("EXECABORT Transaction discarded because of previous errors")
+	errNoSession           = errors.New("Command requires a client session")
+	errWatchInsideMulti    = errors.New("WATCH inside MULTI is not allowed")
+	errWatchNotSupported   = errors.New("Storage engine does not support watch")
+)
+
+// transactionKey stores the open transaction in a session.
+type transactionKey struct{}
+
+// watchKey stores the versions of the watched keys in a session.
+type watchKey struct{}
+
+// watchedKey is a key of a database watched by a session.
+type watchedKey struct {
+	db  int
+	key string
+}
+
+// watchedVersion is the version of a watched key together with the storage
+// holding it, so swapping databases modifies the watched keys too.
+type watchedVersion struct {
+	storage VersionedStorage
+	version uint64
+}
+
+// transaction holds the commands queued by a client after multi.
+type transaction struct {
+	commands []queuedCommand
+	// failed is set when a command could not be queued, exec discards
+	// such a transaction.
+	failed bool
+}
+
+type queuedCommand struct {
+	command string
+	args    []string
+}
+
+func transactionOf(session *network.Session) *transaction {
+	if session == nil {
+		return nil
+	}
+
+	tx, _ := session.Value(transactionKey{}).(*transaction)
+
+	return tx
+}
+
+// failTransaction makes exec discard the open transaction of the session
+// because a command was rejected before it could be queued.
+func failTransaction(session *network.Session) {
+	if tx := transactionOf(session); tx != nil {
+		tx.failed = true
+	}
+}
+
+func (c *ComputeHandler) multi(session *network.Session) (string, error) {
+	if session == nil {
+		return "", errNoSession
+	}
+	if transactionOf(session) != nil {
+		return "", errNestedMulti
+	}
+
+	session.SetValue(transactionKey{}, &transaction{})
+
+	fmt.Println("Transaction started")
+
+	return "OK", nil
+}
+
+func (c *ComputeHandler) discard(session *network.Session) (string, error) {
+	if transactionOf(session) == nil {
+		return "", errDiscardWithoutMulti
+	}
+
+	session.SetValue(transactionKey{}, nil)
+	session.SetValue(watchKey{}, nil)
+
+	fmt.Println("Transaction discarded")
+
+	return "OK", nil
+}
+
+// exec runs the queued commands while no other command is executed, so
+// other clients see either none or all of the transaction writes. A
+// command failing at runtime does not stop the rest, its error is
+// returned in place of its result. Nothing is run when a watched key was
+// modified since watch.
+func (c *ComputeHandler) exec(session *network.Session) (string, error) {
+	tx := transactionOf(session)
+	if tx == nil {
+		return "", errExecWithoutMulti
+	}
+
+	watched, _ := session.Value(watchKey{}).(map[watchedKey]watchedVersion)
+	session.SetValue(transactionKey{}, nil)
+	session.SetValue(watchKey{}, nil)
+	if tx.failed {
+		return "", errTransactionAborted
+	}
+
+	c.txMu.Lock()
+	defer c.txMu.Unlock()
+
+	if c.watchedModified(watched) {
+		fmt.Println("Transaction aborted, watched keys were modified")
+
+		return nilReply, nil
+	}
+
+	db := c.db(selectedDB(session))
+	results := make([]string, 0, len(tx.commands))
+	for _, queued := range tx.commands {
+		result, err := db.executeQueued(queued.command, queued.args)
+		if err != nil {
+			result = "(error) " + err.Error()
+		}
+		results = append(results, result)
+	}
+
+	fmt.Printf("Transaction executed %d commands\n", len(results))
+
+	return arrayReply(results), nil
+}
+
+func (c *dbHandler) executeQueued(command string, args []string) (string, error) {
+	if growsMemory(command) {
+		if err := c.freeMemory(); err != nil {
+			return "", err
+		}
+	}
+
+	return c.execute(command, args)
+}
+
+// watch makes the next exec of the session fail when any of the keys is
+// modified before it. A key watched again keeps its first version.
+func (c *ComputeHandler) watch(session *network.Session, keys []string) (string, error) {
+	if session == nil {
+		return "", errNoSession
+	}
+	if transactionOf(session) != nil {
+		return "", errWatchInsideMulti
+	}
+
+	index := selectedDB(session)
+	storage, ok := c.db(index).storage.(VersionedStorage)
+	if !ok {
+		c.logger.Error("storage does not implement VersionedStorage")
+
+		return "", errWatchNotSupported
+	}
+
+	watched, _ := session.Value(watchKey{}).(map[watchedKey]watchedVersion)
+	if watched == nil {
+		watched = make(map[watchedKey]watchedVersion, len(keys))
+		session.SetValue(watchKey{}, watched)
+	}
+	for _, key := range keys {
+		if _, found := watched[watchedKey{index, key}]; !found {
+			watched[watchedKey{index, key}] = watchedVersion{storage, storage.Version(key)}
+		}
+	}
+
+	fmt.Printf("Watching keys %v\n", keys)
+
+	return "OK", nil
+}
+
+func (c *ComputeHandler) unwatch(session *network.Session) (string, error) {
+	if session != nil {
+		session.SetValue(watchKey{}, nil)
+	}
+
+	return "OK", nil
+}
+
+// watchedModified reports whether any of the watched keys has another
+// version now or its database was swapped. It must be called while no
+// command is executed.
+func (c *ComputeHandler) watchedModified(watched map[watchedKey]watchedVersion) bool {
+	for key, watchedVersion := range watched {
+		storage, ok := c.db(key.db).storage.(VersionedStorage)
+		if !ok || storage != watchedVersion.storage || storage.Version(key.key) != watchedVersion.version {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,184 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errExpirationNotSupported = errors.New("Storage engine does not support key expiration")
+
+func (c *dbHandler) expirableStorage() (ExpirableStorage, error) {
+	storage, ok := c.storage.(ExpirableStorage)
+	if !ok || !c.capabilities().TTL {
+		c.logger.Error("storage does not implement ExpirableStorage")
+
+		return nil, errExpirationNotSupported
+	}
+
+	return storage, nil
+}
+
+// setWithTTL handles "set key value EX seconds", "set key value PX milliseconds"
+// and "set key value PXAT unix-time-milliseconds".
+func (c *dbHandler) setWithTTL(args []string) (string, error) {
+	storage, err := c.expirableStorage()
+	if err != nil {
+		return "", err
+	}
+
+	amount, err := parsePositiveInt(args[3])
+	if err != nil {
+		return "", errors.New("invalid expire time in set")
+	}
+
+	var ttl time.Duration
+	switch option := strings.ToUpper(args[2]); option {
+	case ExOption, PxOption:
+		ttl, err = expireDuration(amount, expireUnit(option))
+		if err != nil {
+			return "", errors.New("invalid expire time in set")
+		}
+	case PxatOption:
+		ttl = time.Until(time.UnixMilli(amount))
+	}
+	deadline := time.Now().Add(ttl)
+
+	storage.SetWithTTL(args[0], args[1], ttl)
+	err = c.journal(SetCmd, args[0], args[1], PxatOption, strconv.FormatInt(deadline.UnixMilli(), 10))
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Value %s saved with expiration at %s\n", args[1], deadline)
+
+	return "saved", nil
+}
+
+func (c *dbHandler) expire(args []string) (string, error) {
+	seconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", errors.New("value is not an integer or out of range")
+	}
+
+	ttl, err := expireDuration(seconds, time.Second)
+	if err != nil {
+		return "", errors.New("invalid expire time in expire")
+	}
+
+	return c.expireIn(args[0], ttl)
+}
+
+func (c *dbHandler) pexpireAt(args []string) (string, error) {
+	milliseconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", errors.New("value is not an integer or out of range")
+	}
+
+	return c.expireIn(args[0], time.Until(time.UnixMilli(milliseconds)))
+}
+
+func (c *dbHandler) expireIn(key string, ttl time.Duration) (string, error) {
+	storage, err := c.expirableStorage()
+	if err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(ttl)
+	if !storage.Expire(key, ttl) {
+		fmt.Printf("Key %s not found\n", key)
+
+		return "0", nil
+	}
+
+	if ttl <= 0 {
+		err = c.journal(DeleteCmd, key)
+	} else {
+		err = c.journal(PExpireAtCmd, key, strconv.FormatInt(deadline.UnixMilli(), 10))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Key %s expires at %s\n", key, deadline)
+
+	return "1", nil
+}
+
+// ttl returns the remaining time to live in the given unit, -1 for keys
+// without expiration and -2 for missing keys.
+func (c *dbHandler) ttl(key string, unit time.Duration) (string, error) {
+	storage, err := c.expirableStorage()
+	if err != nil {
+		return "", err
+	}
+
+	ttl, found := storage.TTL(key)
+	if !found {
+		fmt.Printf("Key %s not found\n", key)
+
+		return "-2", nil
+	}
+	if ttl < 0 {
+		fmt.Printf("Key %s has no expiration\n", key)
+
+		return "-1", nil
+	}
+
+	remaining := int64((ttl + unit/2) / unit)
+
+	fmt.Printf("Key %s expires in %d%s\n", key, remaining, unitSuffix(unit))
+
+	return strconv.FormatInt(remaining, 10), nil
+}
+
+func (c *dbHandler) persist(key string) (string, error) {
+	storage, err := c.expirableStorage()
+	if err != nil {
+		return "", err
+	}
+
+	if !storage.Persist(key) {
+		fmt.Printf("Key %s not found or has no expiration\n", key)
+
+		return "0", nil
+	}
+	if err := c.journal(PersistCmd, key); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Expiration removed from key %s\n", key)
+
+	return "1", nil
+}
+
+// expireUnit returns the unit of the amount given with the set option.
+func expireUnit(option string) time.Duration {
+	if option == PxOption {
+		return time.Millisecond
+	}
+
+	return time.Second
+}
+
+// expireDuration converts the amount of units to a duration, amounts that
+// overflow the duration are rejected instead of wrapping to past times.
+func expireDuration(amount int64, unit time.Duration) (time.Duration, error) {
+	limit := math.MaxInt64 / int64(unit)
+	if amount > limit || amount < -limit {
+		return 0, fmt.Errorf("expire time %d%s is out of range", amount, unitSuffix(unit))
+	}
+
+	return time.Duration(amount) * unit, nil
+}
+
+func unitSuffix(unit time.Duration) string {
+	if unit == time.Millisecond {
+		return "ms"
+	}
+
+	return "s"
+}
@@ -0,0 +1,211 @@
+package compute
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"umemory/internal/storage"
+)
+
+var (
+	errSortedSetsNotSupported = errors.New("Storage engine does not support sorted sets")
+	errNotFloat               = errors.New("value is not a valid float")
+	errBoundNotFloat          = errors.New("min or max is not a float")
+)
+
+func (c *dbHandler) sortedSetStorage() (SortedSetStorage, error) {
+	storage, ok := c.storage.(SortedSetStorage)
+	if !ok {
+		c.logger.Error("storage does not implement SortedSetStorage")
+
+		return nil, errSortedSetsNotSupported
+	}
+
+	return storage, nil
+}
+
+// executeSortedSet handles sorted set commands, the arguments are already
+// validated.
+func (c *dbHandler) executeSortedSet(command string, args []string) (string, error) {
+	sortedSets, err := c.sortedSetStorage()
+	if err != nil {
+		return "", err
+	}
+
+	key := args[0]
+	switch command {
+	case ZAddCmd:
+		members := make(map[string]float64, len(args[1:])/2)
+		for i := 1; i+1 < len(args); i += 2 {
+			members[args[i+1]], _ = parseScore(args[i])
+		}
+
+		added, err := sortedSets.ZAdd(key, members)
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("%d members added to sorted set %s\n", added, key)
+
+		return strconv.Itoa(added), nil
+	case ZRemCmd:
+		removed, err := sortedSets.ZRem(key, args[1:])
+		if err != nil {
+			return "", err
+		}
+		if removed > 0 {
+			if err := c.journal(command, args...); err != nil {
+				return "", err
+			}
+		}
+
+		fmt.Printf("%d members removed from sorted set %s\n", removed, key)
+
+		return strconv.Itoa(removed), nil
+	case ZScoreCmd:
+		score, found, err := sortedSets.ZScore(key, args[1])
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("Member %s of sorted set %s not found\n", args[1], key)
+
+			return nilReply, nil
+		}
+
+		fmt.Printf("Score found: %s\n", formatScore(score))
+
+		return formatScore(score), nil
+	case ZIncrByCmd:
+		increment, _ := parseScore(args[1])
+
+		score, err := sortedSets.ZIncrBy(key, args[2], increment)
+		if err != nil {
+			return "", err
+		}
+		if err := c.journal(command, args...); err != nil {
+			return "", err
+		}
+
+		fmt.Printf("Score of %s in sorted set %s is %s\n", args[2], key, formatScore(score))
+
+		return formatScore(score), nil
+	case ZRankCmd, ZRevRankCmd:
+		rank, found, err := sortedSets.ZRank(key, args[1], command == ZRevRankCmd)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			fmt.Printf("Member %s of sorted set %s not found\n", args[1], key)
+
+			return nilReply, nil
+		}
+
+		fmt.Printf("Rank of %s in sorted set %s is %d\n", args[1], key, rank)
+
+		return strconv.Itoa(rank), nil
+	case ZRangeCmd, ZRevRangeCmd:
+		start, _ := strconv.Atoi(args[1])
+		stop, _ := strconv.Atoi(args[2])
+
+		members, err := sortedSets.ZRange(key, start, stop, command == ZRevRangeCmd)
+		if err != nil {
+			return "", err
+		}
+
+		return scoredMembersReply(members, len(args) == 4), nil
+	case ZRangeByScoreCmd:
+		min, _ := parseScoreBound(args[1])
+		max, _ := parseScoreBound(args[2])
+
+		members, err := sortedSets.ZRangeByScore(key, min, max)
+		if err != nil {
+			return "", err
+		}
+
+		return scoredMembersReply(members, len(args) == 4), nil
+	case ZCountCmd:
+		min, _ := parseScoreBound(args[1])
+		max, _ := parseScoreBound(args[2])
+
+		count, err := sortedSets.ZCount(key, min, max)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Printf("%d members of sorted set %s are in range\n", count, key)
+
+		return strconv.Itoa(count), nil
+	default:
+		return "Unknown command", nil
+	}
+}
+
+func scoredMembersReply(members []storage.ScoredMember, withScores bool) string {
+	values := make([]string, 0, 2*len(members))
+	for _, member := range members {
+		values = append(values, member.Member)
+		if withScores {
+			values = append(values, formatScore(member.Score))
+		}
+	}
+
+	fmt.Printf("Members found: %v\n", values)
+
+	return arrayReply(values)
+}
+
+// parseScore parses a score, infinity is written as inf, +inf or -inf.
+func parseScore(s string) (float64, error) {
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, errNotFloat
+	}
+
+	return score, nil
+}
+
+// parseScoreBound parses a score range bound, the bound is exclusive when
+// prefixed with "(".
+func parseScoreBound(s string) (storage.ScoreBound, error) {
+	var bound storage.ScoreBound
+	if strings.HasPrefix(s, "(") {
+		bound.Exclusive = true
+		s = s[1:]
+	}
+
+	score, err := parseScore(s)
+	if err != nil {
+		return storage.ScoreBound{}, errBoundNotFloat
+	}
+	bound.Value = score
+
+	return bound, nil
+}
+
+func validateScoreRange(min, max string) error {
+	if _, err := parseScoreBound(min); err != nil {
+		return err
+	}
+	if _, err := parseScoreBound(max); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
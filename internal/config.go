@@ -2,7 +2,10 @@ package internal
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -11,26 +14,154 @@ import (
 )
 
 type Config struct {
-	Engine struct {
-		EngineType string `yaml:"engine_type"`
-	} `yaml:"engine"`
-	Network struct {
-		Address string `yaml:"address"`
-		MaxConnections int `yaml:"max_connections",omitempty`
-		MaxMessageSize int `yaml:"max_message_size",omitempty`
-		IdleTimeout time.Duration `yaml:"idle_timeout",omitempty`
-	} `yaml:"network"`
-	Logging struct {
-		Level string `yaml:"level"`
-		Output string `yaml:"output"`
-	} `yaml:"logging"`
+	Engine        EngineConfig        `yaml:"engine"`
+	WAL           WALConfig           `yaml:"wal"`
+	Snapshot      SnapshotConfig      `yaml:"snapshot"`
+	Replication   ReplicationConfig   `yaml:"replication"`
+	Network       NetworkConfig       `yaml:"network"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Logging       LoggingConfig       `yaml:"logging"`
+}
+
+// EngineConfig configures the storage engine. MaxMemory of zero means no
+// memory limit, EvictionPolicy defaults to noeviction. Databases is the
+// number of numbered databases, 16 by default.
+type EngineConfig struct {
+	EngineType         string            `yaml:"engine_type"`
+	Databases          int               `yaml:"databases,omitempty"`
+	ShardsCount        int               `yaml:"shards_count,omitempty"`
+	ExpirationInterval time.Duration     `yaml:"expiration_interval,omitempty"`
+	MaxMemory          Size              `yaml:"max_memory,omitempty"`
+	EvictionPolicy     string            `yaml:"eviction_policy,omitempty"`
+	Compression        CompressionConfig `yaml:"compression,omitempty"`
+	History            HistoryConfig     `yaml:"history,omitempty"`
+	Disk               DiskConfig        `yaml:"disk,omitempty"`
+}
+
+// DiskConfig configures the disk engine. Every database keeps its tables in
+// a subdirectory of Directory, a memtable is written to a table once it
+// grows to MemtableSize and level 0 tables are compacted once there are
+// CompactionThreshold of them.
+type DiskConfig struct {
+	Directory           string `yaml:"directory"`
+	MemtableSize        Size   `yaml:"memtable_size,omitempty"`
+	BlockSize           Size   `yaml:"block_size,omitempty"`
+	CompactionThreshold int    `yaml:"compaction_threshold,omitempty"`
+}
+
+// HistoryConfig configures the history of string keys. Up to Versions
+// versions of a key are kept, versions replaced more than Retention ago are
+// dropped. History is disabled when both are zero.
+type HistoryConfig struct {
+	Versions  int           `yaml:"versions,omitempty"`
+	Retention time.Duration `yaml:"retention,omitempty"`
+}
+
+// CompressionConfig configures compression of string values. Values from
+// Threshold bytes are kept compressed by Codec, compression is disabled
+// when Threshold is zero.
+type CompressionConfig struct {
+	Threshold Size   `yaml:"threshold,omitempty"`
+	Codec     string `yaml:"codec,omitempty"`
+}
+
+// WALConfig configures the write-ahead log. The log is disabled when
+// Directory is empty.
+type WALConfig struct {
+	Directory      string `yaml:"directory"`
+	FsyncPolicy    string `yaml:"fsync_policy,omitempty"`
+	MaxSegmentSize Size   `yaml:"max_segment_size,omitempty"`
+}
+
+// SnapshotConfig configures keyspace snapshots. Snapshots are disabled when
+// Directory is empty, periodic snapshots are disabled when Interval is zero.
+type SnapshotConfig struct {
+	Directory string        `yaml:"directory"`
+	Interval  time.Duration `yaml:"interval,omitempty"`
+	Retain    int           `yaml:"retain,omitempty"`
+}
+
+// ReplicationConfig configures leader/follower replication, it is disabled
+// when Role is empty. A leader accepts followers on Address, a follower
+// connects to LeaderAddress.
+type ReplicationConfig struct {
+	Role              string        `yaml:"role"`
+	Address           string        `yaml:"address,omitempty"`
+	LeaderAddress     string        `yaml:"leader_address,omitempty"`
+	BacklogSize       int           `yaml:"backlog_size,omitempty"`
+	ReconnectInterval time.Duration `yaml:"reconnect_interval,omitempty"`
+}
+
+type NetworkConfig struct {
+	Address        string        `yaml:"address"`
+	MaxConnections int           `yaml:"max_connections,omitempty"`
+	MaxMessageSize int           `yaml:"max_message_size,omitempty"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout,omitempty"`
+}
+
+// NotificationsConfig configures keyspace events published to subscribed
+// clients. KeyspaceEvents lists the enabled event classes: generic, string,
+// list, hash, set, zset, expired, evicted or all. Events are disabled when
+// the list is empty.
+type NotificationsConfig struct {
+	KeyspaceEvents []string `yaml:"keyspace_events,omitempty"`
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Output string `yaml:"output"`
+}
+
+// Size is a number of bytes, in config it can be written as a plain
+// number or with a KB, MB or GB suffix.
+type Size int64
+
+func (s *Size) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseSize(value.Value)
+	if err != nil {
+		return err
+	}
+
+	*s = size
+
+	return nil
+}
+
+func ParseSize(str string) (Size, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	str = strings.ToUpper(strings.TrimSpace(str))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			multiplier = unit.multiplier
+
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("Invalid size %q", str)
+	}
+
+	return Size(n * multiplier), nil
 }
 
 func GetConfig() (Config, error) {
 	err := godotenv.Load(".env")
 	if err != nil {
 		return Config{}, fmt.Errorf("Load env error: %w", err)
-   }
+	}
 	cfgPath := os.Getenv("CONFIG_PATH")
 
 	data, err := os.ReadFile(cfgPath)
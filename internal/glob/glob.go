@@ -0,0 +1,102 @@
+package glob
+
+// Match reports whether s matches the glob-style pattern like Redis
+// does: * matches any sequence of bytes, ? matches a single byte, [abc],
+// [a-z] and [^a] match a byte of a class, and \ escapes the next byte.
+func Match(pattern, s string) bool {
+	var (
+		px, sx int
+		// the position to retry from when the last * should match one more
+		// byte
+		starPx, starSx = -1, -1
+	)
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starSx = px, sx+1
+				px++
+
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+
+					continue
+				}
+			case '[':
+				if sx < len(s) {
+					if end, matched := matchClass(pattern, px, s[sx]); matched {
+						px = end
+						sx++
+
+						continue
+					}
+				}
+			case '\\':
+				if px+1 < len(pattern) {
+					c = pattern[px+1]
+					px++
+				}
+				fallthrough
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+
+					continue
+				}
+			}
+		}
+		if starPx >= 0 && starSx <= len(s) {
+			px, sx = starPx+1, starSx
+			starSx++
+
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// matchClass matches c against the class starting at pattern[start], which
+// is '['. It returns the position after the class, an unterminated class
+// ends with the pattern.
+func matchClass(pattern string, start int, c byte) (int, bool) {
+	i := start + 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == c {
+				matched = true
+			}
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			low, high := pattern[i], pattern[i+2]
+			if low > high {
+				low, high = high, low
+			}
+			if c >= low && c <= high {
+				matched = true
+			}
+			i += 2
+		case pattern[i] == c:
+			matched = true
+		}
+	}
+	if i < len(pattern) {
+		// skip the closing bracket
+		i++
+	}
+
+	return i, matched != negate
+}
@@ -1,5 +1,7 @@
 package network
 
+// Handler handles requests of client connections. The session keeps the
+// connection state between requests.
 type Handler interface {
-	Handle(requestStr string) (string, error)
+	Handle(session *Session, requestStr string) (string, error)
 }
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	network "umemory/internal/network"
 )
 
 // MockHandler is a mock of Handler interface.
@@ -34,16 +35,16 @@ func (m *MockHandler) EXPECT() *MockHandlerMockRecorder {
 }
 
 // Handle mocks base method.
-func (m *MockHandler) Handle(requestStr string) (string, error) {
+func (m *MockHandler) Handle(session *network.Session, requestStr string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Handle", requestStr)
+	ret := m.ctrl.Call(m, "Handle", session, requestStr)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Handle indicates an expected call of Handle.
-func (mr *MockHandlerMockRecorder) Handle(requestStr interface{}) *gomock.Call {
+func (mr *MockHandlerMockRecorder) Handle(session, requestStr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockHandler)(nil).Handle), requestStr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockHandler)(nil).Handle), session, requestStr)
 }
@@ -0,0 +1,207 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// A binary request is a frame of length prefixed arguments, so arguments
+// may hold spaces, new lines or any other bytes:
+//
+//	*<arguments count>\r\n$<length>\r\n<argument>\r\n...
+//
+// The first argument is the command. The response to a binary request is
+// framed too, as $<length>\r\n<result>\r\n or -<length>\r\n<error>\r\n, and
+// messages pushed to a session sending binary requests are framed as
+// ><length>\r\n<message>\r\n. Requests not starting with * are plain text.
+const (
+	requestPrefix  = '*'
+	argumentPrefix = '$'
+
+	resultFrame byte = '$'
+	errorFrame  byte = '-'
+	pushFrame   byte = '>'
+)
+
+var (
+	errMalformedRequest  = errors.New("Malformed binary request")
+	errMalformedResponse = errors.New("Malformed binary response")
+)
+
+// ResponseError is an error returned by the server in response to a binary
+// request.
+type ResponseError struct {
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// IsBinaryRequest reports whether the request is a binary one.
+func IsBinaryRequest(request []byte) bool {
+	return len(request) > 0 && request[0] == requestPrefix
+}
+
+// EncodeRequest encodes the command and its arguments as a binary request.
+func EncodeRequest(args ...string) []byte {
+	size := 16
+	for _, arg := range args {
+		size += len(arg) + 16
+	}
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, requestPrefix)
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, argumentPrefix)
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+
+	return buf
+}
+
+// DecodeRequest decodes a complete binary request into the command and its
+// arguments.
+func DecodeRequest(request []byte) ([]string, error) {
+	args, size, err := parseRequest(request, len(request))
+	if errors.Is(err, errSmallBuffer) {
+		return nil, errMalformedRequest
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size != len(request) {
+		return nil, errMalformedRequest
+	}
+
+	return args, nil
+}
+
+// requestSize returns the size of the binary request at the beginning of
+// data or zero when the request is not complete yet. It returns
+// errSmallBuffer for a request that cannot fit into limit bytes.
+func requestSize(data []byte, limit int) (int, error) {
+	_, size, err := parseRequest(data, limit)
+
+	return size, err
+}
+
+// parseRequest parses the request of at most limit bytes, data must not be
+// longer than limit. Lengths are checked against the limit before they are
+// added to positions, so huge lengths cannot overflow them.
+func parseRequest(data []byte, limit int) ([]string, int, error) {
+	count, pos, err := parseHeader(data, 0, requestPrefix)
+	if err != nil || pos == 0 {
+		return nil, 0, err
+	}
+
+	args := make([]string, 0, min(count, 64))
+	for i := 0; i < count; i++ {
+		length, next, err := parseHeader(data, pos, argumentPrefix)
+		if err != nil || next == 0 {
+			return nil, 0, err
+		}
+		if length > limit-next-2 {
+			return nil, 0, errSmallBuffer
+		}
+
+		end := next + length
+		if len(data) < end+2 {
+			return nil, 0, nil
+		}
+		if data[end] != '\r' || data[end+1] != '\n' {
+			return nil, 0, errMalformedRequest
+		}
+
+		args = append(args, string(data[next:end]))
+		pos = end + 2
+	}
+
+	return args, pos, nil
+}
+
+// parseHeader parses <prefix><number>\r\n at pos and returns the number and
+// the position following the header, or zero position when the header is
+// not complete yet.
+func parseHeader(data []byte, pos int, prefix byte) (int, int, error) {
+	if len(data) <= pos {
+		return 0, 0, nil
+	}
+	if data[pos] != prefix {
+		return 0, 0, errMalformedRequest
+	}
+
+	end := bytes.Index(data[pos:], []byte("\r\n"))
+	if end < 0 {
+		if len(data)-pos > 20 {
+			return 0, 0, errMalformedRequest
+		}
+
+		return 0, 0, nil
+	}
+
+	number, err := strconv.Atoi(string(data[pos+1 : pos+end]))
+	if err != nil || number < 0 {
+		return 0, 0, errMalformedRequest
+	}
+
+	return number, pos + end + 2, nil
+}
+
+// encodeResponse frames the result of a binary request or its error.
+func encodeResponse(result string, err error) []byte {
+	if err != nil {
+		return encodeFrame(errorFrame, err.Error())
+	}
+
+	return encodeFrame(resultFrame, result)
+}
+
+func encodeFrame(frameType byte, payload string) []byte {
+	buf := make([]byte, 0, len(payload)+16)
+	buf = append(buf, frameType)
+	buf = strconv.AppendInt(buf, int64(len(payload)), 10)
+	buf = append(buf, "\r\n"...)
+	buf = append(buf, payload...)
+
+	return append(buf, "\r\n"...)
+}
+
+// parseFrame parses the frame of at most limit bytes at the beginning of
+// data. It returns zero size when the frame is not complete yet and
+// errSmallBuffer when it cannot fit into limit bytes.
+func parseFrame(data []byte, limit int) (byte, string, int, error) {
+	if len(data) == 0 {
+		return 0, "", 0, nil
+	}
+
+	frameType := data[0]
+	if frameType != resultFrame && frameType != errorFrame && frameType != pushFrame {
+		return 0, "", 0, errMalformedResponse
+	}
+
+	end := bytes.Index(data, []byte("\r\n"))
+	if end < 0 {
+		return 0, "", 0, nil
+	}
+	length, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || length < 0 {
+		return 0, "", 0, errMalformedResponse
+	}
+
+	start := end + 2
+	if length > limit-start-2 {
+		return 0, "", 0, errSmallBuffer
+	}
+	if len(data) < start+length+2 {
+		return 0, "", 0, nil
+	}
+
+	return frameType, string(data[start : start+length]), start + length + 2, nil
+}
@@ -0,0 +1,148 @@
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pushQueueSize is the number of pushed messages buffered for a client, a
+// client falling further behind is disconnected.
+const pushQueueSize = 1024
+
+var lastSessionID atomic.Uint64
+
+// Session is the state of a client connection kept between its requests,
+// e.g. an open transaction. Requests of a connection are handled one by
+// one, so values are not safe for concurrent use. Messages can be pushed
+// to the client from any goroutine.
+type Session struct {
+	id         uint64
+	remoteAddr string
+	values     map[any]any
+
+	// pushed holds messages written to the client asynchronously, done is
+	// closed when the connection is closed or dropped.
+	pushed   chan string
+	done     chan struct{}
+	doneOnce sync.Once
+	pushMode atomic.Bool
+	// binary is set once the client sends a binary request, messages
+	// pushed to it are framed from then on.
+	binary atomic.Bool
+
+	closeOnce sync.Once
+	mu        sync.Mutex
+	onClose   []func()
+}
+
+func NewSession(remoteAddr string) *Session {
+	return &Session{
+		id:         lastSessionID.Add(1),
+		remoteAddr: remoteAddr,
+		values:     make(map[any]any),
+		pushed:     make(chan string, pushQueueSize),
+		done:       make(chan struct{}),
+	}
+}
+
+// ID returns the session identifier unique within the process.
+func (s *Session) ID() uint64 {
+	return s.id
+}
+
+func (s *Session) RemoteAddr() string {
+	return s.remoteAddr
+}
+
+// Value returns the value stored by key or nil. Like with context values,
+// handlers should use keys of their own unexported types to avoid
+// collisions.
+func (s *Session) Value(key any) any {
+	return s.values[key]
+}
+
+// SetValue stores the value by key, a nil value removes the key.
+func (s *Session) SetValue(key, value any) {
+	if value == nil {
+		delete(s.values, key)
+
+		return
+	}
+
+	s.values[key] = value
+}
+
+// Push queues a message to be written to the client asynchronously. It
+// returns false when the connection is closed, or when the client does not
+// read fast enough, then the connection is dropped.
+func (s *Session) Push(message string) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	select {
+	case s.pushed <- message:
+		return true
+	default:
+		s.drop()
+
+		return false
+	}
+}
+
+// Pushed returns the queue of pushed messages.
+func (s *Session) Pushed() <-chan string {
+	return s.pushed
+}
+
+// Done returns a channel closed when the connection is closed or dropped.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// SetPushMode switches the connection to the mode of a subscriber waiting
+// for pushed messages, the server does not close such connection when it
+// is idle.
+func (s *Session) SetPushMode(enabled bool) {
+	s.pushMode.Store(enabled)
+}
+
+func (s *Session) PushMode() bool {
+	return s.pushMode.Load()
+}
+
+// OnClose registers fn to be called once the connection is closed, e.g. to
+// release resources held by the session.
+func (s *Session) OnClose(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onClose = append(s.onClose, fn)
+}
+
+// Close marks the connection closed and calls the functions registered by
+// OnClose. It is called by the server when the connection is closed.
+func (s *Session) Close() {
+	s.drop()
+
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		onClose := s.onClose
+		s.onClose = nil
+		s.mu.Unlock()
+
+		for _, fn := range onClose {
+			fn()
+		}
+	})
+}
+
+// drop marks the connection closed without calling the close functions, it
+// is safe to call while pushing.
+func (s *Session) drop() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
@@ -16,6 +16,11 @@ type TCPClient struct {
 	idleTimeout        *time.Duration
 	connectionDeadline *time.Time
 	logger             *zap.Logger
+
+	// buffered keeps the bytes read after the last binary frame, pushed
+	// keeps the messages pushed while a binary response was awaited.
+	buffered []byte
+	pushed   []string
 }
 
 type TCPClientConfig struct {
@@ -67,6 +72,158 @@ func (c *TCPClient) Send(request []byte) ([]byte, error) {
 	return response[:count], nil
 }
 
+// SendArgs sends the command and its arguments as a binary request, so they
+// may hold spaces, new lines or any other bytes, and returns the result. An
+// error returned by the server is a *ResponseError.
+func (c *TCPClient) SendArgs(args ...string) (string, error) {
+	err := c.setConnectionDeadline()
+	if err != nil {
+		c.logger.Error("TCPClient SendArgs: setIdleTimeout error", zap.Error(err))
+
+		return "", errors.New("Client internal error")
+	}
+
+	if _, err = c.conn.Write(EncodeRequest(args...)); err != nil {
+		c.logger.Error("TCPClient SendArgs: connection.Write request error", zap.Error(err))
+
+		return "", errors.New("Client send data error")
+	}
+
+	for {
+		frameType, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+
+		switch frameType {
+		case pushFrame:
+			c.pushed = append(c.pushed, payload)
+		case errorFrame:
+			return "", &ResponseError{Message: payload}
+		default:
+			return payload, nil
+		}
+	}
+}
+
+// ReceiveMessage waits for a message pushed by the server to a client
+// sending binary requests, see SendArgs and Receive.
+func (c *TCPClient) ReceiveMessage() (string, error) {
+	if len(c.pushed) > 0 {
+		message := c.pushed[0]
+		c.pushed = c.pushed[1:]
+
+		return message, nil
+	}
+
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		c.logger.Error("TCPClient ReceiveMessage: SetDeadline error", zap.Error(err))
+
+		return "", errors.New("Client internal error")
+	}
+
+	for {
+		frameType, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+		if frameType == pushFrame {
+			return payload, nil
+		}
+
+		c.logger.Warn("TCPClient ReceiveMessage: unexpected response frame", zap.String("frame", string(frameType)))
+	}
+}
+
+// readFrame reads the next binary frame, the frame may not be larger than
+// the max message size.
+func (c *TCPClient) readFrame() (byte, string, error) {
+	for {
+		frameType, payload, size, err := parseFrame(c.buffered, c.maxMessageSize)
+		if errors.Is(err, errSmallBuffer) {
+			c.logger.Error("TCPClient readFrame: frame is larger than maxMessageSize", zap.Int("maxMessageSize", c.maxMessageSize))
+			c.buffered = nil
+
+			return 0, "", errors.New("Small buffer size")
+		}
+		if err != nil {
+			c.logger.Error("TCPClient readFrame: parse frame error", zap.Error(err))
+			c.buffered = nil
+
+			return 0, "", err
+		}
+		if size > 0 {
+			c.buffered = c.buffered[size:]
+
+			return frameType, payload, nil
+		}
+		if len(c.buffered) >= c.maxMessageSize {
+			c.logger.Error("TCPClient readFrame: frame is larger than maxMessageSize", zap.Int("maxMessageSize", c.maxMessageSize))
+			c.buffered = nil
+
+			return 0, "", errors.New("Small buffer size")
+		}
+
+		data := make([]byte, c.maxMessageSize-len(c.buffered))
+		count, err := c.conn.Read(data)
+		c.buffered = append(c.buffered, data[:count]...)
+		if err == io.EOF && count == 0 {
+			return 0, "", err
+		}
+		if err != nil && err != io.EOF {
+			c.logger.Error("TCPClient readFrame: connection.Read error", zap.Error(err))
+
+			return 0, "", errors.New("Client read data error")
+		}
+	}
+}
+
+// Write sends the request without waiting for the response, which is
+// read by Receive together with pushed messages.
+func (c *TCPClient) Write(request []byte) error {
+	var deadline time.Time
+	if c.idleTimeout != nil {
+		deadline = time.Now().Add(*c.idleTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		c.logger.Error("TCPClient Write: SetWriteDeadline error", zap.Error(err))
+
+		return errors.New("Client internal error")
+	}
+
+	if _, err := c.conn.Write(request); err != nil {
+		c.logger.Error("TCPClient Write: connection.Write request error", zap.Error(err))
+
+		return errors.New("Client send data error")
+	}
+
+	return nil
+}
+
+// Receive waits for a message pushed by the server, e.g. a message
+// published to a subscribed channel. Pushed messages may come at any time,
+// so the connection deadline is not applied.
+func (c *TCPClient) Receive() ([]byte, error) {
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		c.logger.Error("TCPClient Receive: SetDeadline error", zap.Error(err))
+
+		return nil, errors.New("Client internal error")
+	}
+
+	message := make([]byte, c.maxMessageSize)
+	count, err := c.conn.Read(message)
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		c.logger.Error("TCPClient Receive: connection.Read message error", zap.Error(err))
+
+		return nil, errors.New("Client read data error")
+	}
+
+	return message[:count], nil
+}
+
 func (c *TCPClient) setConnectionDeadline() error {
 	var deadline time.Time
 	if c.connectionDeadline != nil {
@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 	"time"
 	"umemory/internal"
 
 	"go.uber.org/zap"
 )
 
+// pushWriteTimeout limits writing a pushed message to a client.
+const pushWriteTimeout = 10 * time.Second
+
 type TCPServer struct {
 	listener  net.Listener
 
@@ -56,6 +60,13 @@ func NewTCPServer(config internal.Config, logger *zap.Logger) (*TCPServer, error
 func (s *TCPServer) Handle(ctx context.Context, handler Handler) {
 	defer s.listener.Close()
 
+	// Accept does not watch the context, closing the listener unblocks it
+	// on shutdown.
+	go func() {
+		<-ctx.Done()
+		s.listener.Close()
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -73,7 +84,13 @@ func (s *TCPServer) Handle(ctx context.Context, handler Handler) {
 
 			s.activeConnections <- struct{}{}
 			go func(conn net.Conn) {
+				session := NewSession(conn.RemoteAddr().String())
+				// writeMu keeps responses and pushed messages from interleaving
+				var writeMu sync.Mutex
+				go s.pushMessages(conn, session, &writeMu)
+
 				defer func() {
+					session.Close()
 					s.logger.Info("TCP Server closes connection", zap.String("Local Address", conn.LocalAddr().String()), zap.String("Remote Address", conn.RemoteAddr().String()))
 					if err := conn.Close(); err != nil {
 						s.logger.Error("Connection close error", zap.Error(err))
@@ -81,12 +98,27 @@ func (s *TCPServer) Handle(ctx context.Context, handler Handler) {
 					<-s.activeConnections
 				}()
 
-				buffer := make([]byte, s.bufferSize)
+				reader := &requestReader{conn: conn, buffer: make([]byte, s.bufferSize)}
 
 				for {
 					resMsg := ""
-					res, err := s.handleConnection(ctx, conn, buffer, handler)
-					if err != nil {
+					res, binary, err := s.handleConnection(ctx, conn, session, reader, handler)
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if isDone(session) {
+						s.logger.Warn("Client does not read pushed messages, disconnecting", zap.String("address", conn.RemoteAddr().String()))
+
+						break
+					}
+					if binary {
+						// a binary request is always answered, even with an
+						// empty result, so the client can match responses
+						if err != nil {
+							s.logger.Error("TCP server: handleConnection error", zap.Error(err))
+						}
+						resMsg = string(encodeResponse(res, err))
+					} else if err != nil {
 						s.logger.Error("TCP server: handleConnection error", zap.Error(err))
 						resMsg = err.Error()
 						if resMsg == "" {
@@ -100,7 +132,10 @@ func (s *TCPServer) Handle(ctx context.Context, handler Handler) {
 						continue
 					}
 
-					if _, err := connection.Write([]byte(resMsg)); err != nil {
+					writeMu.Lock()
+					_, err = connection.Write([]byte(resMsg))
+					writeMu.Unlock()
+					if err != nil {
 						s.logger.Error(
 							"Write data to connection error",
 							zap.String("address", connection.RemoteAddr().String()),
@@ -109,49 +144,189 @@ func (s *TCPServer) Handle(ctx context.Context, handler Handler) {
 
 						break
 					}
+					if reader.broken {
+						// the rest of the broken binary request cannot be
+						// told apart from the following requests
+						break
+					}
 				}
 			}(connection)
 		}
 	}
 }
 
-func (s *TCPServer) handleConnection(ctx context.Context, connection net.Conn, buffer []byte, handler Handler) (string, error) {
-	if s.idleTimeout != 0 {
+// pushMessages writes the messages pushed to the session until it is
+// closed. Messages are followed by a new line, or framed for a client
+// sending binary requests, so a client can tell them apart when it reads
+// several at once. When the session is dropped, the
+// pending read is interrupted to close the connection.
+func (s *TCPServer) pushMessages(conn net.Conn, session *Session, writeMu *sync.Mutex) {
+	for {
+		select {
+		case <-session.Done():
+			_ = conn.SetReadDeadline(time.Now())
+
+			return
+		case message := <-session.Pushed():
+			data := []byte(message + "\n")
+			if session.binary.Load() {
+				data = encodeFrame(pushFrame, message)
+			}
+
+			writeMu.Lock()
+			err := conn.SetWriteDeadline(time.Now().Add(pushWriteTimeout))
+			if err == nil {
+				_, err = conn.Write(data)
+			}
+			writeMu.Unlock()
+
+			if err != nil {
+				s.logger.Error("Write pushed message error", zap.String("address", conn.RemoteAddr().String()), zap.Error(err))
+				session.drop()
+			}
+		}
+	}
+}
+
+func isDone(session *Session) bool {
+	select {
+	case <-session.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *TCPServer) handleConnection(ctx context.Context, connection net.Conn, session *Session, reader *requestReader, handler Handler) (string, bool, error) {
+	if session.PushMode() {
+		// a subscribed client may stay silent, the idle deadline is lifted.
+		// The session is checked afterwards, so a deadline set by a dropped
+		// session is not lost.
+		if err := connection.SetReadDeadline(time.Time{}); err != nil {
+			return "", false, errors.New("Set read deadline for connection error")
+		}
+		if isDone(session) {
+			return "", false, io.EOF
+		}
+	} else if s.idleTimeout != 0 {
 		if err := connection.SetReadDeadline(time.Now().Add(s.idleTimeout)); err != nil {
 			s.logger.Error("Set read deadline for connection error", zap.Error(err))
 
-			return "", errors.New("Set read deadline for connection error")
+			return "", false, errors.New("Set read deadline for connection error")
 		}
 		if err := connection.SetWriteDeadline(time.Now().Add(s.idleTimeout)); err != nil {
 			s.logger.Warn("Set write deadline for connection error", zap.Error(err))
 			
-			return "", errors.New("Set write deadline for connection error")
+			return "", false, errors.New("Set write deadline for connection error")
 		}
 	}
 
-	readBytesCount, err := connection.Read(buffer)
-	if err != nil && err != io.EOF {
+	request, binary, err := reader.next()
+	if err == io.EOF {
+		return "", false, io.EOF
+	}
+	if errors.Is(err, errSmallBuffer) {
+		s.logger.Error("Read data error: small buffer size", zap.Int("buffer_size", s.bufferSize))
+
+		return "", binary, errors.New("Read data error: small buffer size")
+	}
+	if errors.Is(err, errMalformedRequest) {
+		return "", binary, err
+	}
+	if err != nil {
 		s.logger.Error(
 			"Read data from connection error",
 			zap.String("address", connection.RemoteAddr().String()),
 			zap.Error(err),
 		)
 
-		return "", errors.New("Read data from connection error")
+		return "", binary, errors.New("Read data from connection error")
 	}
-	if readBytesCount >= s.bufferSize {
-		s.logger.Error("Read data error: small buffer size", zap.Int("buffer_size", s.bufferSize), zap.Int("readBytesCount", readBytesCount))
-		
-		return "", errors.New("Read data error: small buffer size")
+	if len(request) == 0 {
+		return "", false, nil
 	}
-	if readBytesCount == 0 {
-		return "", nil
+	if binary {
+		session.binary.Store(true)
 	}
 
-	response, err := handler.Handle(string(buffer[:readBytesCount]))
+	response, err := handler.Handle(session, string(request))
 	if err != nil {
-		return "", err
+		return "", binary, err
 	}
 
-	return response, nil
+	return response, binary, nil
+}
+
+var errSmallBuffer = errors.New("small buffer size")
+
+// requestReader reads the requests of a connection. A plain text request
+// is whatever a single read returns. A binary request is read until its
+// frame is complete, the bytes following it are kept for the next request.
+type requestReader struct {
+	conn     net.Conn
+	buffer   []byte
+	buffered int
+	// broken is set when a binary request is malformed or larger than the
+	// buffer, the connection is closed then.
+	broken bool
+}
+
+// next returns the next request and whether it is a binary one. The
+// request is valid until the following call.
+func (r *requestReader) next() ([]byte, bool, error) {
+	for {
+		if r.buffered > 0 {
+			data := r.buffer[:r.buffered]
+			if !IsBinaryRequest(data) {
+				r.buffered = 0
+
+				return data, false, nil
+			}
+
+			size, err := requestSize(data, len(r.buffer))
+			if err != nil {
+				r.buffered = 0
+				r.broken = true
+
+				return nil, true, err
+			}
+			if size > 0 {
+				request := append([]byte(nil), data[:size]...)
+				r.buffered = copy(r.buffer, data[size:])
+
+				return request, true, nil
+			}
+			if r.buffered == len(r.buffer) {
+				r.buffered = 0
+				r.broken = true
+
+				return nil, true, errSmallBuffer
+			}
+		}
+
+		// pending is set while the rest of a binary request is awaited
+		pending := r.buffered > 0
+		count, err := r.conn.Read(r.buffer[r.buffered:])
+		r.buffered += count
+		if err == io.EOF && count == 0 {
+			r.buffered = 0
+
+			return nil, pending, io.EOF
+		}
+		if err != nil && err != io.EOF {
+			r.buffered = 0
+
+			return nil, pending, err
+		}
+		if !pending && !IsBinaryRequest(r.buffer[:r.buffered]) {
+			if count >= len(r.buffer) {
+				r.buffered = 0
+
+				return nil, false, errSmallBuffer
+			}
+			if count == 0 {
+				return nil, false, nil
+			}
+		}
+	}
 }
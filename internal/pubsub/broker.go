@@ -0,0 +1,119 @@
+package pubsub
+
+import (
+	"sync"
+	"umemory/internal/glob"
+)
+
+// Message is a message published to a channel. Pattern is the pattern the
+// subscriber matched the channel with, it is empty for subscriptions to
+// the channel itself.
+type Message struct {
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// Subscriber receives messages of the channels it is subscribed to.
+// Deliver is called while publishing, so it must not block.
+type Subscriber interface {
+	ID() uint64
+	Deliver(message Message) bool
+}
+
+// Broker delivers published messages to the subscribers of channels and of
+// glob-style channel patterns.
+type Broker struct {
+	mu       sync.RWMutex
+	channels map[string]map[uint64]Subscriber
+	patterns map[string]map[uint64]Subscriber
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		channels: make(map[string]map[uint64]Subscriber),
+		patterns: make(map[string]map[uint64]Subscriber),
+	}
+}
+
+func (b *Broker) Subscribe(subscriber Subscriber, channel string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	add(b.channels, channel, subscriber)
+}
+
+func (b *Broker) Unsubscribe(subscriber Subscriber, channel string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remove(b.channels, channel, subscriber)
+}
+
+// PSubscribe subscribes to all channels matching the glob-style pattern.
+func (b *Broker) PSubscribe(subscriber Subscriber, pattern string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	add(b.patterns, pattern, subscriber)
+}
+
+func (b *Broker) PUnsubscribe(subscriber Subscriber, pattern string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remove(b.patterns, pattern, subscriber)
+}
+
+// Publish delivers the payload to the subscribers of the channel and of
+// the patterns matching it. It returns the number of deliveries, a
+// subscriber matching several patterns receives the message several times.
+func (b *Broker) Publish(channel, payload string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var delivered int
+	for _, subscriber := range b.channels[channel] {
+		if subscriber.Deliver(Message{Channel: channel, Payload: payload}) {
+			delivered++
+		}
+	}
+	for pattern, subscribers := range b.patterns {
+		if !glob.Match(pattern, channel) {
+			continue
+		}
+
+		for _, subscriber := range subscribers {
+			if subscriber.Deliver(Message{Pattern: pattern, Channel: channel, Payload: payload}) {
+				delivered++
+			}
+		}
+	}
+
+	return delivered
+}
+
+// Stats returns the number of channels and patterns having subscribers.
+func (b *Broker) Stats() (int, int) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.channels), len(b.patterns)
+}
+
+func add(subscriptions map[string]map[uint64]Subscriber, name string, subscriber Subscriber) {
+	subscribers, found := subscriptions[name]
+	if !found {
+		subscribers = make(map[uint64]Subscriber)
+		subscriptions[name] = subscribers
+	}
+	subscribers[subscriber.ID()] = subscriber
+}
+
+func remove(subscriptions map[string]map[uint64]Subscriber, name string, subscriber Subscriber) {
+	subscribers := subscriptions[name]
+	delete(subscribers, subscriber.ID())
+	if len(subscribers) == 0 {
+		delete(subscriptions, name)
+	}
+}
@@ -0,0 +1,202 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"umemory/internal"
+	"umemory/internal/compute"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const defaultReconnectInterval = time.Second
+
+// Applier applies a write received from the leader.
+type Applier interface {
+	Apply(command string, args []string) error
+}
+
+// Follower keeps a read-only copy of the leader keyspace: it loads a full
+// snapshot on the first sync and then applies the stream of writes,
+// reconnecting with its offset when the connection breaks. A write that
+// fails to apply drops the connection and forces a full sync.
+type Follower struct {
+	leaderAddress     string
+	reconnectInterval time.Duration
+
+	storage Storage
+	applier Applier
+
+	mu            sync.Mutex
+	replicationID string
+	offset        uint64
+	connected     bool
+
+	logger *zap.Logger
+}
+
+func NewFollower(config internal.Config, storage Storage, applier Applier, logger *zap.Logger) (*Follower, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+	if config.Replication.LeaderAddress == "" {
+		return nil, errors.New("replication leader address is not set")
+	}
+
+	follower := &Follower{
+		leaderAddress:     config.Replication.LeaderAddress,
+		reconnectInterval: defaultReconnectInterval,
+		storage:           storage,
+		applier:           applier,
+		logger:            logger,
+	}
+	if config.Replication.ReconnectInterval > 0 {
+		follower.reconnectInterval = config.Replication.ReconnectInterval
+	}
+
+	return follower, nil
+}
+
+func (f *Follower) ReadOnly() bool {
+	return true
+}
+
+// Propagate does nothing, followers do not replicate further.
+func (f *Follower) Propagate(string, []string) {}
+
+func (f *Follower) Info() []compute.InfoField {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	linkStatus := "down"
+	if f.connected {
+		linkStatus = "up"
+	}
+
+	return []compute.InfoField{
+		{Name: "role", Value: RoleFollower},
+		{Name: "leader_address", Value: f.leaderAddress},
+		{Name: "link_status", Value: linkStatus},
+		{Name: "replication_id", Value: f.replicationID},
+		{Name: "offset", Value: strconv.FormatUint(f.offset, 10)},
+	}
+}
+
+// Run keeps the follower connected to the leader until ctx is done.
+func (f *Follower) Run(ctx context.Context) {
+	for {
+		err := f.sync(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		f.logger.Warn("Replication: connection to leader lost", zap.String("leader", f.leaderAddress), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.reconnectInterval):
+		}
+	}
+}
+
+func (f *Follower) sync(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", f.leaderAddress)
+	if err != nil {
+		return fmt.Errorf("connect to leader error: %w", err)
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	f.mu.Lock()
+	request := syncRequest{replicationID: f.replicationID, offset: f.offset}
+	f.mu.Unlock()
+
+	if err := writeFrame(conn, encodeSyncRequest(request)); err != nil {
+		return fmt.Errorf("send sync request error: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	payload, err := readFrame(reader)
+	if err != nil {
+		return fmt.Errorf("read sync response error: %w", err)
+	}
+	response, err := decodeSyncResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	if response.full {
+		records := make([]storage.Record, 0, min(response.recordsCount, 1<<20))
+		for i := uint64(0); i < response.recordsCount; i++ {
+			record, err := storage.ReadRecord(reader)
+			if err != nil {
+				return fmt.Errorf("read snapshot record error: %w", err)
+			}
+			records = append(records, record)
+		}
+		f.storage.Load(records)
+	}
+
+	f.mu.Lock()
+	if response.full {
+		f.offset = response.offset
+	}
+	f.replicationID = response.replicationID
+	f.connected = true
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.connected = false
+		f.mu.Unlock()
+	}()
+
+	f.logger.Info(
+		"Replication: synced with leader",
+		zap.String("leader", f.leaderAddress),
+		zap.Bool("full_sync", response.full),
+		zap.Uint64("offset", response.offset),
+	)
+
+	for {
+		payload, err := readFrame(reader)
+		if err != nil {
+			return err
+		}
+		w, err := decodeWrite(payload)
+		if err != nil {
+			return err
+		}
+
+		if err := f.applier.Apply(w.command, w.args); err != nil {
+			// the keyspace no longer matches the leader, forgetting the
+			// replication id makes the next sync a full one
+			f.mu.Lock()
+			f.replicationID = ""
+			f.mu.Unlock()
+
+			return fmt.Errorf("apply %s write error: %w", w.command, err)
+		}
+
+		f.mu.Lock()
+		f.offset = w.offset
+		f.mu.Unlock()
+	}
+}
@@ -0,0 +1,315 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"umemory/internal"
+	"umemory/internal/compute"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RoleLeader   = "leader"
+	RoleFollower = "follower"
+
+	defaultBacklogSize = 10000
+	// followerQueueSize is the number of writes buffered for a follower,
+	// a follower falling further behind is disconnected and resyncs.
+	followerQueueSize = 4096
+	handshakeTimeout  = 10 * time.Second
+)
+
+type Storage interface {
+	Dump() []storage.Record
+	Load(records []storage.Record)
+}
+
+// WriteBlocker runs fn while no write commands are applied, so that the
+// keyspace copy sent to a follower matches the replication offset.
+type WriteBlocker interface {
+	BlockWrites(fn func() error) error
+}
+
+// Leader streams every applied write to the connected followers. Recent
+// writes are kept in a backlog, so a follower reconnecting after a short
+// break continues from its offset instead of loading a full snapshot.
+type Leader struct {
+	listener net.Listener
+	storage  Storage
+	blocker  WriteBlocker
+
+	mu            sync.Mutex
+	replicationID string
+	offset        uint64
+	// backlog is a ring of the last writes, the write with offset o is
+	// stored at o % len(backlog).
+	backlog    [][]byte
+	backlogLen int
+	followers  map[*followerConn]struct{}
+
+	logger *zap.Logger
+}
+
+type followerConn struct {
+	conn   net.Conn
+	writes chan []byte
+}
+
+func NewLeader(config internal.Config, storage Storage, blocker WriteBlocker, logger *zap.Logger) (*Leader, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+	if config.Replication.Address == "" {
+		return nil, errors.New("replication address is not set")
+	}
+
+	replicationID, err := newReplicationID()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", config.Replication.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	leader := &Leader{
+		listener:      listener,
+		storage:       storage,
+		blocker:       blocker,
+		replicationID: replicationID,
+		followers:     make(map[*followerConn]struct{}),
+		logger:        logger,
+	}
+
+	backlogSize := defaultBacklogSize
+	if config.Replication.BacklogSize > 0 {
+		backlogSize = config.Replication.BacklogSize
+	}
+	leader.backlog = make([][]byte, backlogSize)
+
+	return leader, nil
+}
+
+func (l *Leader) ReadOnly() bool {
+	return false
+}
+
+// Propagate assigns the next offset to the write and queues it to every
+// follower. It is called in the order writes are applied.
+func (l *Leader) Propagate(command string, args []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.offset++
+	frame := encodeWrite(write{offset: l.offset, command: command, args: args})
+
+	l.backlog[l.offset%uint64(len(l.backlog))] = frame
+	if l.backlogLen < len(l.backlog) {
+		l.backlogLen++
+	}
+
+	for follower := range l.followers {
+		select {
+		case follower.writes <- frame:
+		default:
+			l.logger.Warn("Replication: follower is too slow, disconnecting", zap.String("address", follower.conn.RemoteAddr().String()))
+			l.removeFollower(follower)
+		}
+	}
+}
+
+func (l *Leader) Info() []compute.InfoField {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return []compute.InfoField{
+		{Name: "role", Value: RoleLeader},
+		{Name: "address", Value: l.listener.Addr().String()},
+		{Name: "replication_id", Value: l.replicationID},
+		{Name: "offset", Value: strconv.FormatUint(l.offset, 10)},
+		{Name: "connected_followers", Value: strconv.Itoa(len(l.followers))},
+	}
+}
+
+// Run accepts followers until ctx is done.
+func (l *Leader) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		l.listener.Close()
+
+		l.mu.Lock()
+		for follower := range l.followers {
+			l.removeFollower(follower)
+		}
+		l.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			l.logger.Error("Replication: accept error", zap.Error(err))
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			if err := l.serve(ctx, conn); err != nil {
+				l.logger.Warn("Replication: follower disconnected", zap.String("address", conn.RemoteAddr().String()), zap.Error(err))
+			}
+		}()
+	}
+}
+
+func (l *Leader) serve(ctx context.Context, conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	if err := conn.SetReadDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return err
+	}
+	payload, err := readFrame(reader)
+	if err != nil {
+		return fmt.Errorf("read sync request error: %w", err)
+	}
+	request, err := decodeSyncRequest(payload)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	follower := &followerConn{conn: conn, writes: make(chan []byte, followerQueueSize)}
+	var (
+		response syncResponse
+		records  []storage.Record
+		pending  [][]byte
+	)
+	err = l.blocker.BlockWrites(func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		response = syncResponse{replicationID: l.replicationID, offset: l.offset}
+		if backlog, ok := l.backlogAfter(request); ok {
+			pending = backlog
+		} else {
+			response.full = true
+			records = l.storage.Dump()
+			response.recordsCount = uint64(len(records))
+		}
+		l.followers[follower] = struct{}{}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		l.mu.Lock()
+		l.removeFollower(follower)
+		l.mu.Unlock()
+	}()
+
+	l.logger.Info(
+		"Replication: follower connected",
+		zap.String("address", conn.RemoteAddr().String()),
+		zap.Bool("full_sync", response.full),
+		zap.Uint64("offset", response.offset),
+	)
+
+	if err := writeFrame(writer, encodeSyncResponse(response)); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := storage.WriteRecord(writer, record); err != nil {
+			return err
+		}
+	}
+	for _, frame := range pending {
+		if err := writeFrame(writer, frame); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case frame, ok := <-follower.writes:
+			if !ok {
+				return errors.New("follower removed")
+			}
+			if err := writeFrame(writer, frame); err != nil {
+				return err
+			}
+			if len(follower.writes) == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// backlogAfter returns the writes a follower misses, if it follows this
+// leader and its offset is still covered by the backlog. l.mu must be held.
+func (l *Leader) backlogAfter(request syncRequest) ([][]byte, bool) {
+	if request.replicationID != l.replicationID || request.offset > l.offset {
+		return nil, false
+	}
+
+	missed := l.offset - request.offset
+	if missed > uint64(l.backlogLen) {
+		return nil, false
+	}
+
+	pending := make([][]byte, 0, missed)
+	for offset := request.offset + 1; offset <= l.offset; offset++ {
+		pending = append(pending, l.backlog[offset%uint64(len(l.backlog))])
+	}
+
+	return pending, true
+}
+
+// removeFollower closes the follower queue once. l.mu must be held.
+func (l *Leader) removeFollower(follower *followerConn) {
+	if _, found := l.followers[follower]; !found {
+		return
+	}
+
+	delete(l.followers, follower)
+	close(follower.writes)
+}
+
+func newReplicationID() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate replication id error: %w", err)
+	}
+
+	return hex.EncodeToString(buf), nil
+}
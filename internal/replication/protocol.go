@@ -0,0 +1,196 @@
+package replication
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Every message is a frame: [payload length uint32][payload], the first
+// payload byte is the message type. A full sync frame is followed by the
+// snapshot records written with storage.WriteRecord outside of frames.
+const (
+	syncMessage     byte = 'S'
+	fullSyncMessage byte = 'F'
+	continueMessage byte = 'C'
+	writeMessage    byte = 'W'
+
+	maxFrameSize = 1 << 30
+)
+
+var errMalformedMessage = errors.New("malformed replication message")
+
+// syncRequest is sent by a follower after connecting, it carries the
+// position the follower has already applied.
+type syncRequest struct {
+	replicationID string
+	offset        uint64
+}
+
+// syncResponse tells the follower to either load the snapshot of recordsCount
+// records followed by the stream, or to continue from its offset.
+type syncResponse struct {
+	full          bool
+	replicationID string
+	offset        uint64
+	recordsCount  uint64
+}
+
+// write is a single write command with its replication offset.
+type write struct {
+	offset  uint64
+	command string
+	args    []string
+}
+
+func encodeSyncRequest(request syncRequest) []byte {
+	payload := []byte{syncMessage}
+	payload = appendString(payload, request.replicationID)
+
+	return binary.AppendUvarint(payload, request.offset)
+}
+
+func encodeSyncResponse(response syncResponse) []byte {
+	payload := []byte{continueMessage}
+	if response.full {
+		payload[0] = fullSyncMessage
+	}
+	payload = appendString(payload, response.replicationID)
+	payload = binary.AppendUvarint(payload, response.offset)
+
+	return binary.AppendUvarint(payload, response.recordsCount)
+}
+
+func encodeWrite(w write) []byte {
+	payload := []byte{writeMessage}
+	payload = binary.AppendUvarint(payload, w.offset)
+	payload = binary.AppendUvarint(payload, uint64(len(w.args)))
+	payload = appendString(payload, w.command)
+	for _, arg := range w.args {
+		payload = appendString(payload, arg)
+	}
+
+	return payload
+}
+
+func decodeSyncRequest(payload []byte) (syncRequest, error) {
+	if len(payload) == 0 || payload[0] != syncMessage {
+		return syncRequest{}, errMalformedMessage
+	}
+
+	d := decoder{payload: payload[1:]}
+	request := syncRequest{
+		replicationID: d.string(),
+		offset:        d.uvarint(),
+	}
+
+	return request, d.err
+}
+
+func decodeSyncResponse(payload []byte) (syncResponse, error) {
+	if len(payload) == 0 || (payload[0] != fullSyncMessage && payload[0] != continueMessage) {
+		return syncResponse{}, errMalformedMessage
+	}
+
+	d := decoder{payload: payload[1:]}
+	response := syncResponse{
+		full:          payload[0] == fullSyncMessage,
+		replicationID: d.string(),
+		offset:        d.uvarint(),
+		recordsCount:  d.uvarint(),
+	}
+
+	return response, d.err
+}
+
+func decodeWrite(payload []byte) (write, error) {
+	if len(payload) == 0 || payload[0] != writeMessage {
+		return write{}, errMalformedMessage
+	}
+
+	d := decoder{payload: payload[1:]}
+	w := write{offset: d.uvarint()}
+	argsCount := d.uvarint()
+	w.command = d.string()
+	for i := uint64(0); i < argsCount && d.err == nil; i++ {
+		w.args = append(w.args, d.string())
+	}
+
+	return w, d.err
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	header := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+
+	return err
+}
+
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(header)
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("replication frame of %d bytes is too big", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+
+	return append(buf, s...)
+}
+
+// decoder reads values from a payload remembering the first error.
+type decoder struct {
+	payload []byte
+	err     error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(d.payload)
+	if n <= 0 {
+		d.err = errMalformedMessage
+
+		return 0
+	}
+	d.payload = d.payload[n:]
+
+	return value
+}
+
+func (d *decoder) string() string {
+	length := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if uint64(len(d.payload)) < length {
+		d.err = errMalformedMessage
+
+		return ""
+	}
+
+	s := string(d.payload[:length])
+	d.payload = d.payload[length:]
+
+	return s
+}
@@ -0,0 +1,440 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// magic identifies the snapshot format, the version changes together
+	// with the storage record encoding.
+	magic           = "UMSNAP03"
+	footerSize      = 4
+	defaultRetain   = 3
+	filePrefix      = "snapshot_"
+	fileSuffix      = ".snap"
+	tempFilePattern = "snapshot_*.tmp"
+)
+
+var (
+	ErrSaveInProgress = errors.New("Background save already in progress")
+
+	errCorrupted = errors.New("snapshot is corrupted")
+)
+
+type Storage interface {
+	Dump() []storage.Record
+	Load(records []storage.Record)
+}
+
+// WAL is the write-ahead log, snapshots make its old segments unnecessary.
+type WAL interface {
+	Rotate() (int, error)
+	RemoveSegments(upTo int) error
+}
+
+// WriteBlocker runs fn while no write commands are applied, so that the
+// keyspace copy and the write-ahead log cut describe the same state.
+type WriteBlocker interface {
+	BlockWrites(fn func() error) error
+}
+
+// Manager writes point-in-time snapshots of the keyspace and loads the
+// newest valid one on startup.
+type Manager struct {
+	directory string
+	interval  time.Duration
+	retain    int
+
+	storage Storage
+	wal     WAL
+	blocker WriteBlocker
+
+	saveMu     sync.Mutex
+	bgSaving   atomic.Bool
+	bgSaveWait sync.WaitGroup
+
+	logger *zap.Logger
+}
+
+func NewManager(config internal.Config, storage Storage, logger *zap.Logger) (*Manager, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+	if config.Snapshot.Directory == "" {
+		return nil, errors.New("snapshot directory is not set")
+	}
+
+	manager := &Manager{
+		directory: config.Snapshot.Directory,
+		interval:  config.Snapshot.Interval,
+		retain:    defaultRetain,
+		storage:   storage,
+		logger:    logger,
+	}
+	if config.Snapshot.Retain > 0 {
+		manager.retain = config.Snapshot.Retain
+	}
+
+	if err := os.MkdirAll(manager.directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create snapshot directory error: %w", err)
+	}
+
+	return manager, nil
+}
+
+// SetWAL makes snapshots cut the write-ahead log.
+func (m *Manager) SetWAL(wal WAL) {
+	m.wal = wal
+}
+
+// SetBlocker makes snapshots copy the keyspace while writes are blocked, so
+// that databases are copied at the same point and no write lands between
+// the write-ahead log cut and the copy.
+func (m *Manager) SetBlocker(blocker WriteBlocker) {
+	m.blocker = blocker
+}
+
+// Load restores the keyspace from the newest valid snapshot and returns
+// the last write-ahead log segment it covers. Corrupted snapshots are
+// refused and the previous one is tried.
+func (m *Manager) Load() (int, error) {
+	paths, err := m.snapshots()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		walSegment, records, err := readSnapshot(paths[i])
+		if err != nil {
+			m.logger.Error(
+				"Refusing to load corrupted snapshot",
+				zap.String("path", paths[i]),
+				zap.Error(err),
+			)
+			fmt.Printf("Refusing to load corrupted snapshot %s: %s\n", paths[i], err.Error())
+
+			continue
+		}
+
+		m.storage.Load(records)
+		m.logger.Info(
+			"Snapshot loaded",
+			zap.String("path", paths[i]),
+			zap.Int("keys", len(records)),
+		)
+
+		return walSegment, nil
+	}
+
+	return 0, nil
+}
+
+// Save writes a snapshot to a temporary file and renames it once it is
+// fully written and synced, so a crash never leaves a partial snapshot.
+func (m *Manager) Save() error {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	var (
+		records    []storage.Record
+		walSegment int
+	)
+	cut := func() error {
+		if m.wal != nil {
+			segment, err := m.wal.Rotate()
+			if err != nil {
+				return fmt.Errorf("rotate wal error: %w", err)
+			}
+			walSegment = segment
+		}
+		records = m.storage.Dump()
+
+		return nil
+	}
+
+	var err error
+	if m.blocker != nil {
+		err = m.blocker.BlockWrites(cut)
+	} else {
+		err = cut()
+	}
+	if err != nil {
+		return err
+	}
+
+	path, err := m.write(walSegment, records)
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Snapshot saved", zap.String("path", path), zap.Int("keys", len(records)))
+
+	m.removeOld()
+	if m.wal != nil {
+		m.removeCoveredSegments()
+	}
+
+	return nil
+}
+
+// BackgroundSave starts Save in a separate goroutine.
+func (m *Manager) BackgroundSave() error {
+	if !m.bgSaving.CompareAndSwap(false, true) {
+		return ErrSaveInProgress
+	}
+
+	m.bgSaveWait.Add(1)
+	go func() {
+		defer m.bgSaveWait.Done()
+		defer m.bgSaving.Store(false)
+
+		if err := m.Save(); err != nil {
+			m.logger.Error("Background save error", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// Run saves snapshots on the configured interval until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	defer m.bgSaveWait.Wait()
+
+	if m.interval <= 0 {
+		<-ctx.Done()
+
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Save(); err != nil {
+				m.logger.Error("Scheduled save error", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (m *Manager) write(walSegment int, records []storage.Record) (string, error) {
+	file, err := os.CreateTemp(m.directory, tempFilePattern)
+	if err != nil {
+		return "", fmt.Errorf("create snapshot file error: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	checksum := crc32.NewIEEE()
+	writer := bufio.NewWriter(io.MultiWriter(file, checksum))
+
+	createdAt := time.Now().UnixNano()
+	header := make([]byte, 0, len(magic)+3*binary.MaxVarintLen64)
+	header = append(header, magic...)
+	header = binary.AppendUvarint(header, uint64(walSegment))
+	header = binary.AppendVarint(header, createdAt)
+	header = binary.AppendUvarint(header, uint64(len(records)))
+	if _, err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("write snapshot error: %w", err)
+	}
+
+	for _, record := range records {
+		if err := storage.WriteRecord(writer, record); err != nil {
+			return "", fmt.Errorf("write snapshot error: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return "", fmt.Errorf("write snapshot error: %w", err)
+	}
+
+	footer := binary.LittleEndian.AppendUint32(nil, checksum.Sum32())
+	if _, err := file.Write(footer); err != nil {
+		return "", fmt.Errorf("write snapshot error: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return "", fmt.Errorf("sync snapshot error: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("close snapshot error: %w", err)
+	}
+
+	path := filepath.Join(m.directory, fmt.Sprintf("%s%020d%s", filePrefix, createdAt, fileSuffix))
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("rename snapshot error: %w", err)
+	}
+	if err := syncDirectory(m.directory); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (m *Manager) removeOld() {
+	paths, err := m.snapshots()
+	if err != nil {
+		m.logger.Error("List snapshots error", zap.Error(err))
+
+		return
+	}
+
+	for len(paths) > m.retain {
+		if err := os.Remove(paths[0]); err != nil {
+			m.logger.Error("Remove old snapshot error", zap.String("path", paths[0]), zap.Error(err))
+		}
+		paths = paths[1:]
+	}
+}
+
+// removeCoveredSegments deletes write-ahead log segments covered by every
+// retained snapshot. Load falls back to an older snapshot when the newest
+// one is corrupted, so the segments written after the oldest one are kept
+// to replay from it. Nothing is removed while a snapshot header is
+// unreadable.
+func (m *Manager) removeCoveredSegments() {
+	paths, err := m.snapshots()
+	if err != nil {
+		m.logger.Error("List snapshots error", zap.Error(err))
+
+		return
+	}
+
+	covered := -1
+	for _, path := range paths {
+		walSegment, err := readSnapshotSegment(path)
+		if err != nil {
+			m.logger.Error("Read snapshot header error, keeping wal segments", zap.String("path", path), zap.Error(err))
+
+			return
+		}
+		if covered < 0 || walSegment < covered {
+			covered = walSegment
+		}
+	}
+	if covered <= 0 {
+		return
+	}
+
+	if err := m.wal.RemoveSegments(covered); err != nil {
+		m.logger.Error("Remove covered wal segments error", zap.Error(err))
+	}
+}
+
+// snapshots returns snapshot paths from the oldest to the newest.
+func (m *Manager) snapshots() ([]string, error) {
+	entries, err := os.ReadDir(m.directory)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot directory error: %w", err)
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		paths = append(paths, filepath.Join(m.directory, name))
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+func readSnapshot(path string) (int, []storage.Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < len(magic)+footerSize || string(data[:len(magic)]) != magic {
+		return 0, nil, fmt.Errorf("%w: unknown format", errCorrupted)
+	}
+
+	body := data[:len(data)-footerSize]
+	expected := binary.LittleEndian.Uint32(data[len(data)-footerSize:])
+	if crc32.ChecksumIEEE(body) != expected {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch", errCorrupted)
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(body[len(magic):]))
+	walSegment, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s", errCorrupted, err.Error())
+	}
+	if _, err := binary.ReadVarint(reader); err != nil {
+		return 0, nil, fmt.Errorf("%w: %s", errCorrupted, err.Error())
+	}
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s", errCorrupted, err.Error())
+	}
+
+	records := make([]storage.Record, 0, min(count, 1<<20))
+	for i := uint64(0); i < count; i++ {
+		record, err := storage.ReadRecord(reader)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: %s", errCorrupted, err.Error())
+		}
+		records = append(records, record)
+	}
+	if reader.Buffered() > 0 {
+		return 0, nil, fmt.Errorf("%w: unexpected trailing data", errCorrupted)
+	}
+
+	return int(walSegment), records, nil
+}
+
+// readSnapshotSegment reads the last write-ahead log segment covered by the
+// snapshot from its header.
+func readSnapshotSegment(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(reader, header); err != nil || string(header) != magic {
+		return 0, fmt.Errorf("%w: unknown format", errCorrupted)
+	}
+	walSegment, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCorrupted, err.Error())
+	}
+
+	return int(walSegment), nil
+}
+
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return fmt.Errorf("open snapshot directory error: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("sync snapshot directory error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+const (
+	// bloomBitsPerKey gives about one percent of false positives with
+	// bloomHashes hash functions.
+	bloomBitsPerKey = 10
+	bloomHashes     = 7
+)
+
+var errInvalidBloomFilter = errors.New("invalid bloom filter")
+
+// bloomFilter tells that a key is surely missing from a table, so lookups
+// of missing keys do not read its blocks. The hash functions are derived
+// from two halves of a 64-bit hash.
+type bloomFilter struct {
+	bits   []byte
+	hashes int
+}
+
+func newBloomFilter(keys int) *bloomFilter {
+	bits := max(keys*bloomBitsPerKey, 64)
+
+	return &bloomFilter{bits: make([]byte, (bits+7)/8), hashes: bloomHashes}
+}
+
+func bloomHash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	return h.Sum64()
+}
+
+func (f *bloomFilter) add(hash uint64) {
+	bits := uint32(len(f.bits) * 8)
+	h1, h2 := uint32(hash), uint32(hash>>32)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % bits
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (f *bloomFilter) mayContain(key string) bool {
+	hash := bloomHash(key)
+	bits := uint32(len(f.bits) * 8)
+	h1, h2 := uint32(hash), uint32(hash>>32)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % bits
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encode returns the number of hash functions followed by the bits.
+func (f *bloomFilter) encode() []byte {
+	return append([]byte{byte(f.hashes)}, f.bits...)
+}
+
+func decodeBloomFilter(data []byte) (*bloomFilter, error) {
+	if len(data) < 2 || data[0] == 0 {
+		return nil, errInvalidBloomFilter
+	}
+
+	return &bloomFilter{bits: data[1:], hashes: int(data[0])}, nil
+}
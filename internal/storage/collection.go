@@ -0,0 +1,74 @@
+package storage
+
+import "time"
+
+// collection is a value holding elements, like a list, a hash, a set or
+// a sorted set.
+type collection interface {
+	*list | *hash | *set | *zset
+
+	len() int
+	// size approximates the memory held by the elements.
+	size() int64
+}
+
+// updateCollection runs fn with the collection stored by key under the
+// shard lock. A missing key is created with newValue, or fn is not called
+// when newValue is nil. Collections left empty are removed.
+func updateCollection[T collection](s *InMemoryStorage, key string, newValue func() T, fn func(value T) error) error {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	e, found := sh.alive(key, now)
+	if !found {
+		if newValue == nil {
+			return nil
+		}
+		e = &entry{value: newValue()}
+		sh.set(key, e)
+	}
+
+	value, ok := e.value.(T)
+	if !ok {
+		return ErrWrongType
+	}
+
+	size := value.size()
+	err := fn(value)
+	sh.used.Add(value.size() - size)
+	e.touch(now)
+
+	if value.len() == 0 {
+		sh.delete(key)
+	} else if err == nil {
+		sh.modified(e)
+	}
+
+	return err
+}
+
+// readCollection runs fn with the collection stored by key under the shard
+// read lock, fn is not called when the key is missing.
+func readCollection[T collection](s *InMemoryStorage, key string, fn func(value T)) error {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	e, found := sh.data[key]
+	if !found || e.expired(now) {
+		return nil
+	}
+
+	value, ok := e.value.(T)
+	if !ok {
+		return ErrWrongType
+	}
+
+	e.touch(now)
+	fn(value)
+
+	return nil
+}
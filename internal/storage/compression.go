@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"umemory/internal"
+)
+
+const (
+	FlateCodec = "flate"
+	GzipCodec  = "gzip"
+
+	defaultCodec = FlateCodec
+)
+
+// Codec compresses string values above the compression threshold. A codec
+// is used by all shards at once, so it must be safe for concurrent use.
+type Codec interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		FlateCodec: &flateCodec{},
+		GzipCodec:  gzipCodec{},
+	}
+)
+
+// RegisterCodec makes a codec available by name for
+// engine.compression.codec, it panics if the name is already taken.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if _, found := codecs[name]; found {
+		panic(fmt.Sprintf("compression codec %q is already registered", name))
+	}
+	codecs[name] = codec
+}
+
+func lookupCodec(name string) (Codec, error) {
+	if name == "" {
+		name = defaultCodec
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, found := codecs[name]
+	if !found {
+		names := make([]string, 0, len(codecs))
+		for name := range codecs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown compression codec %q, available codecs: %s", name, strings.Join(names, ", "))
+	}
+
+	return codec, nil
+}
+
+func checkCompression(config internal.CompressionConfig) error {
+	_, err := lookupCodec(config.Codec)
+
+	return err
+}
+
+// CompressionStats describes the string values kept compressed.
+type CompressionStats struct {
+	// Threshold is the size from which values are compressed, zero means
+	// compression is disabled.
+	Threshold int64
+	Codec     string
+	// Values is the number of compressed values, RawBytes and
+	// CompressedBytes are their sizes before and after compression.
+	Values          int64
+	RawBytes        int64
+	CompressedBytes int64
+}
+
+// CompressionStats returns the stats of all databases sharing the memory
+// limit with the storage.
+func (s *InMemoryStorage) CompressionStats() CompressionStats {
+	stats := CompressionStats{Threshold: int64(s.compressionThreshold), Codec: s.codecName}
+
+	engines := []*InMemoryStorage{s}
+	if s.pool != nil {
+		engines = s.pool.engines
+	}
+	for _, storage := range engines {
+		for _, sh := range storage.shards {
+			stats.Values += sh.compressedValues.Load()
+			stats.RawBytes += sh.compressedRaw.Load()
+			stats.CompressedBytes += sh.compressedSize.Load()
+		}
+	}
+
+	return stats
+}
+
+// compressed is a string value kept compressed by the codec.
+type compressed struct {
+	data  string
+	size  int
+	codec Codec
+}
+
+func (c *compressed) string() (string, bool) {
+	data, err := c.codec.Decompress([]byte(c.data))
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// encode returns the value to store for the string, values from the
+// compression threshold are compressed unless it does not save memory.
+// It is called before the shard is locked, compression takes a while.
+func (s *InMemoryStorage) encode(value string) any {
+	if s.compressionThreshold == 0 || len(value) < s.compressionThreshold {
+		return value
+	}
+
+	data, err := s.codec.Compress([]byte(value))
+	if err != nil || len(data) >= len(value) {
+		return value
+	}
+
+	return &compressed{data: string(data), size: len(value), codec: s.codec}
+}
+
+// stringValue returns the string held by the entry value. A compressed
+// value which cannot be decompressed is reported as missing.
+func stringValue(value any) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case *compressed:
+		return value.string()
+	default:
+		return "", false
+	}
+}
+
+// account adds the entry to the compression stats of the shard or removes
+// it with a negative sign.
+func (sh *shard) account(e *entry, sign int64) {
+	value, ok := e.value.(*compressed)
+	if !ok {
+		return
+	}
+
+	sh.compressedValues.Add(sign)
+	sh.compressedRaw.Add(sign * int64(value.size))
+	sh.compressedSize.Add(sign * int64(len(value.data)))
+}
+
+// flateCodec pools the writers, allocating one takes hundreds of
+// kilobytes.
+type flateCodec struct {
+	writers sync.Pool
+}
+
+func (c *flateCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, ok := c.writers.Get().(*flate.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			return nil, err
+		}
+	}
+	defer c.writers.Put(w)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *flateCodec) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotInteger = errors.New("value is not an integer or out of range")
+	ErrNotFloat   = errors.New("value is not a valid float")
+	ErrNaNOrInf   = errors.New("increment would produce NaN or Infinity")
+)
+
+// IncrBy adds increment to the integer stored by key, a missing key is set
+// to increment. The key keeps its time to live.
+func (s *InMemoryStorage) IncrBy(key string, increment int64) (int64, error) {
+	var result int64
+	err := s.updateString(key, func(value string, found bool) (string, error) {
+		var current int64
+		if found {
+			var err error
+			if current, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return "", ErrNotInteger
+			}
+		}
+
+		if (increment > 0 && current > math.MaxInt64-increment) ||
+			(increment < 0 && current < math.MinInt64-increment) {
+			return "", ErrOverflow
+		}
+
+		result = current + increment
+
+		return strconv.FormatInt(result, 10), nil
+	})
+
+	return result, err
+}
+
+// IncrByFloat adds increment to the number stored by key, a missing key is
+// set to increment. The key keeps its time to live.
+func (s *InMemoryStorage) IncrByFloat(key string, increment float64) (float64, error) {
+	var result float64
+	err := s.updateString(key, func(value string, found bool) (string, error) {
+		var current float64
+		if found {
+			var err error
+			current, err = strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+				return "", ErrNotFloat
+			}
+		}
+
+		result = current + increment
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return "", ErrNaNOrInf
+		}
+
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
+	})
+
+	return result, err
+}
+
+// updateString replaces the string stored by key with the one returned by
+// fn under the shard write lock, so concurrent updates are not lost.
+func (s *InMemoryStorage) updateString(key string, fn func(value string, found bool) (string, error)) error {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	var (
+		value    string
+		expireAt int64
+	)
+	e, found := sh.alive(key, time.Now().UnixNano())
+	if found {
+		var ok bool
+		if value, ok = stringValue(e.value); !ok {
+			return ErrWrongType
+		}
+		expireAt = e.expireAt
+	}
+
+	value, err := fn(value, found)
+	if err != nil {
+		return err
+	}
+	sh.set(key, &entry{value: s.encode(value), expireAt: expireAt})
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"umemory/internal"
+
+	"go.uber.org/zap"
+)
+
+const defaultDatabasesCount = 16
+
+var ErrMoveNotSupported = errors.New("storage engine does not support move")
+
+// Databases holds the numbered databases of a server, each of them is a
+// separate engine of the configured type. The in-memory engines share the
+// memory limit, so it applies to all databases together.
+type Databases struct {
+	// mu guards the order of engines changed by Swap.
+	mu      sync.RWMutex
+	engines []Engine
+	// indexes holds the current index of every engine. It is updated by
+	// Swap and read without mu, expiration callbacks run under shard locks
+	// and must not wait for Swap.
+	indexes map[Engine]*atomic.Int32
+
+	logger *zap.Logger
+}
+
+// NewDatabases creates engine.databases engines of engine.engine_type.
+func NewDatabases(config internal.Config, logger *zap.Logger) (*Databases, error) {
+	count := defaultDatabasesCount
+	if config.Engine.Databases > 0 {
+		count = config.Engine.Databases
+	}
+
+	databases := &Databases{
+		engines: make([]Engine, 0, count),
+		indexes: make(map[Engine]*atomic.Int32, count),
+		logger:  logger,
+	}
+	pool := &memoryPool{}
+	for i := 0; i < count; i++ {
+		// databases of the disk engine keep their tables apart
+		engineConfig := config
+		if config.Engine.Disk.Directory != "" {
+			engineConfig.Engine.Disk.Directory = filepath.Join(config.Engine.Disk.Directory, strconv.Itoa(i))
+		}
+
+		engine, err := NewEngine(engineConfig, logger)
+		if err != nil {
+			databases.Close()
+
+			return nil, err
+		}
+		if storage, ok := inMemory(engine); ok {
+			storage.pool = pool
+			pool.engines = append(pool.engines, storage)
+		}
+		databases.engines = append(databases.engines, engine)
+		databases.indexes[engine] = &atomic.Int32{}
+		databases.indexes[engine].Store(int32(i))
+	}
+
+	return databases, nil
+}
+
+// Count returns the number of databases.
+func (d *Databases) Count() int {
+	return len(d.engines)
+}
+
+// DB returns the engine of the database by index.
+func (d *Databases) DB(index int) Engine {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.engines[index]
+}
+
+// Swap exchanges the contents of two databases. Callers must make sure no
+// command works with the databases meanwhile.
+func (d *Databases) Swap(a, b int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.engines[a], d.engines[b] = d.engines[b], d.engines[a]
+	d.indexes[d.engines[a]].Store(int32(a))
+	d.indexes[d.engines[b]].Store(int32(b))
+}
+
+// Move moves the key with its expiration to another database. It returns
+// false when the key does not exist or the target database already holds
+// it.
+func (d *Databases) Move(key string, from, to int) (bool, error) {
+	if from == to {
+		return false, nil
+	}
+
+	d.mu.RLock()
+	source, sourceOk := inMemory(d.engines[from])
+	target, targetOk := inMemory(d.engines[to])
+	d.mu.RUnlock()
+
+	if !sourceOk || !targetOk {
+		return false, ErrMoveNotSupported
+	}
+
+	// shards of different databases are locked in the order of the
+	// databases, so concurrent moves cannot deadlock
+	sourceShard, targetShard := source.shard(key), target.shard(key)
+	if from < to {
+		sourceShard.mu.Lock()
+		targetShard.mu.Lock()
+	} else {
+		targetShard.mu.Lock()
+		sourceShard.mu.Lock()
+	}
+	defer sourceShard.mu.Unlock()
+	defer targetShard.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	e, found := sourceShard.alive(key, now)
+	if !found {
+		return false, nil
+	}
+	if _, found := targetShard.alive(key, now); found {
+		return false, nil
+	}
+
+	sourceShard.delete(key)
+	targetShard.set(key, &entry{value: e.value, expireAt: e.expireAt})
+
+	return true, nil
+}
+
+// Run runs the background work of every database until ctx is done.
+func (d *Databases) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, engine := range d.engines {
+		runner, ok := engine.(Runner)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(runner Runner) {
+			defer wg.Done()
+
+			runner.Run(ctx)
+		}(runner)
+	}
+	wg.Wait()
+}
+
+// Close closes the engines holding files, it must be called once their
+// background work is done.
+func (d *Databases) Close() error {
+	var errs []error
+	for _, engine := range d.engines {
+		if closer, ok := engine.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// OnExpired registers fn to be called with the keys removed on expiration
+// in any of the databases, see InMemoryStorage.OnExpired.
+func (d *Databases) OnExpired(fn func(db int, key string)) {
+	for _, engine := range d.engines {
+		storage, ok := inMemory(engine)
+		if !ok {
+			continue
+		}
+
+		index := d.indexes[engine]
+		storage.OnExpired(func(key string) {
+			fn(int(index.Load()), key)
+		})
+	}
+}
+
+// Dump returns a point-in-time copy of every database, records are tagged
+// with the index of their database. Databases are copied one by one, so
+// the copy is consistent only while writes are blocked.
+func (d *Databases) Dump() []Record {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var records []Record
+	for i, engine := range d.engines {
+		dumper, ok := engine.(interface{ Dump() []Record })
+		if !ok {
+			continue
+		}
+
+		dumped := dumper.Dump()
+		for j := range dumped {
+			dumped[j].DB = i
+		}
+		records = append(records, dumped...)
+	}
+
+	return records
+}
+
+// Load replaces every database with the dumped records. Records of
+// databases out of range are skipped.
+func (d *Databases) Load(records []Record) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	byDB := make([][]Record, len(d.engines))
+	skipped := 0
+	for _, record := range records {
+		if record.DB < 0 || record.DB >= len(d.engines) {
+			skipped++
+			continue
+		}
+		byDB[record.DB] = append(byDB[record.DB], record)
+	}
+	if skipped > 0 {
+		d.logger.Warn("Records of databases out of range are skipped", zap.Int("records", skipped), zap.Int("databases", len(d.engines)))
+	}
+
+	for i, engine := range d.engines {
+		if loader, ok := engine.(interface{ Load(records []Record) }); ok {
+			loader.Load(byDB[i])
+		}
+	}
+}
+
+// inMemory returns the in-memory storage behind the engine.
+func inMemory(engine Engine) (*InMemoryStorage, bool) {
+	switch storage := engine.(type) {
+	case *InMemoryStorage:
+		return storage, true
+	case *OrderedStorage:
+		return storage.InMemoryStorage, true
+	default:
+		return nil, false
+	}
+}
+
+// memoryPool shares the memory limit between in-memory engines.
+type memoryPool struct {
+	engines []*InMemoryStorage
+}
+
+func (p *memoryPool) used() int64 {
+	var used int64
+	for _, storage := range p.engines {
+		used += storage.shardsMemory()
+	}
+
+	return used
+}
+
+func (p *memoryPool) evictedKeys() uint64 {
+	var evicted uint64
+	for _, storage := range p.engines {
+		evicted += storage.evictedKeys.Load()
+	}
+
+	return evicted
+}
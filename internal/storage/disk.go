@@ -0,0 +1,688 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"umemory/internal"
+	"umemory/internal/wal"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DiskEngine = "disk"
+
+	defaultMemtableSize        = 4 << 20
+	defaultBlockSize           = 4 << 10
+	defaultCompactionThreshold = 4
+	// memtableEntryOverhead approximates the memory held by a memtable key
+	// besides the key and the value.
+	memtableEntryOverhead = 48
+
+	// commands of the memtable log
+	diskSetRecord    = "set"
+	diskDeleteRecord = "del"
+
+	walDirectory = "wal"
+)
+
+func init() {
+	Register(DiskEngine, func(config internal.Config, logger *zap.Logger) (Engine, error) {
+		return NewDiskStorage(config, logger)
+	})
+}
+
+// DiskStorage is a log-structured merge tree keeping string keys on disk,
+// so the dataset may outgrow the memory. Writes go to the log and to the
+// memtable, a full memtable is frozen and written by the background work
+// to a level 0 table. Once there are enough level 0 tables they are merged
+// with the overlapping level 1 tables into new level 1 tables, dropping
+// overwritten values and tombstones. Lookups go from the memtables to the
+// newest tables, every table has a bloom filter and a block index.
+type DiskStorage struct {
+	// mu guards the memtables and the table list, table files are read
+	// without it.
+	mu        sync.RWMutex
+	memtable  *memtable
+	immutable *memtable
+	// tables holds level 0 tables from the newest one and then level 1
+	// tables in key order.
+	tables   []*table
+	manifest manifest
+
+	// flushMu serializes writing of memtables, compactMu serializes
+	// compactions.
+	flushMu   sync.Mutex
+	compactMu sync.Mutex
+	// work wakes the background work up when a memtable is frozen.
+	work chan struct{}
+
+	directory           string
+	memtableSize        int
+	blockSize           int
+	compactionThreshold int
+
+	log    *wal.WAL
+	logger *zap.Logger
+}
+
+// memtable holds the recent writes in memory, deleted keys are kept as
+// tombstones until they reach the tables.
+type memtable struct {
+	entries map[string]diskValue
+	size    int
+	// walSegment is the last log segment of a frozen memtable.
+	walSegment int
+}
+
+func newMemtable() *memtable {
+	return &memtable{entries: make(map[string]diskValue)}
+}
+
+func (m *memtable) put(key string, value diskValue) {
+	if old, found := m.entries[key]; found {
+		m.size -= len(key) + len(old.value) + memtableEntryOverhead
+	}
+	m.entries[key] = value
+	m.size += len(key) + len(value.value) + memtableEntryOverhead
+}
+
+func (m *memtable) sortedKeys() []string {
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// NewDiskStorage opens the tables listed by the manifest of
+// engine.disk.directory and replays the log not written to them yet.
+func NewDiskStorage(config internal.Config, logger *zap.Logger) (*DiskStorage, error) {
+	if config.Engine.Disk.Directory == "" {
+		return nil, errors.New("disk engine directory is not set")
+	}
+
+	s := &DiskStorage{
+		memtable:            newMemtable(),
+		work:                make(chan struct{}, 1),
+		directory:           config.Engine.Disk.Directory,
+		memtableSize:        defaultMemtableSize,
+		blockSize:           defaultBlockSize,
+		compactionThreshold: defaultCompactionThreshold,
+		logger:              logger,
+	}
+	if config.Engine.Disk.MemtableSize > 0 {
+		s.memtableSize = int(config.Engine.Disk.MemtableSize)
+	}
+	if config.Engine.Disk.BlockSize > 0 {
+		s.blockSize = int(config.Engine.Disk.BlockSize)
+	}
+	if config.Engine.Disk.CompactionThreshold > 0 {
+		s.compactionThreshold = config.Engine.Disk.CompactionThreshold
+	}
+
+	if err := os.MkdirAll(s.directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create disk engine directory error: %w", err)
+	}
+	if err := s.openTables(); err != nil {
+		s.Close()
+
+		return nil, err
+	}
+
+	// the log gets the fsync policy of the write-ahead log
+	logConfig := internal.Config{WAL: config.WAL}
+	logConfig.WAL.Directory = filepath.Join(s.directory, walDirectory)
+	log, err := wal.NewWAL(logConfig, logger)
+	if err != nil {
+		s.Close()
+
+		return nil, err
+	}
+	s.log = log
+
+	err = log.Replay(s.manifest.walSegment, func(command string, args []string) error {
+		switch {
+		case command == diskSetRecord && len(args) == 2:
+			s.memtable.put(args[0], diskValue{value: args[1]})
+		case command == diskDeleteRecord && len(args) == 1:
+			s.memtable.put(args[0], diskValue{deleted: true})
+		default:
+			return fmt.Errorf("unknown disk engine log record %q", command)
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.Close()
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// openTables opens the tables of the manifest and removes table files it
+// does not list.
+func (s *DiskStorage) openTables() error {
+	m, err := readManifest(s.directory)
+	if err != nil {
+		return err
+	}
+	s.manifest = m
+
+	live := make(map[string]struct{}, len(m.tables))
+	for _, meta := range m.tables {
+		t, err := openTable(s.directory, meta)
+		if err != nil {
+			return err
+		}
+		s.tables = append(s.tables, t)
+		live[filepath.Base(tablePath(s.directory, meta.id))] = struct{}{}
+	}
+	s.sortTables()
+
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return fmt.Errorf("read disk engine directory error: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, found := live[name]; found || entry.IsDir() || !strings.HasSuffix(name, tableSuffix) {
+			continue
+		}
+		s.logger.Warn("Removing table missing from the manifest", zap.String("file", name))
+		if err := os.Remove(filepath.Join(s.directory, name)); err != nil {
+			return fmt.Errorf("remove table error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sortTables orders level 0 tables from the newest one and level 1 tables
+// by key. The lock must be held.
+func (s *DiskStorage) sortTables() {
+	sort.Slice(s.tables, func(i, j int) bool {
+		a, b := s.tables[i].meta, s.tables[j].meta
+		if a.level != b.level {
+			return a.level < b.level
+		}
+		if a.level == 0 {
+			return a.id > b.id
+		}
+
+		return a.smallest < b.smallest
+	})
+}
+
+func (s *DiskStorage) Capabilities() Capabilities {
+	return Capabilities{Persistent: true}
+}
+
+func (s *DiskStorage) Get(key string) (string, bool) {
+	s.mu.RLock()
+	for _, m := range []*memtable{s.memtable, s.immutable} {
+		if m == nil {
+			continue
+		}
+		if value, found := m.entries[key]; found {
+			s.mu.RUnlock()
+
+			return value.value, !value.deleted
+		}
+	}
+
+	tables := append([]*table(nil), s.tables...)
+	for _, t := range tables {
+		t.acquire()
+	}
+	s.mu.RUnlock()
+	defer func() {
+		for _, t := range tables {
+			t.release()
+		}
+	}()
+
+	for _, t := range tables {
+		value, found, err := t.get(key)
+		if err != nil {
+			s.logger.Error("Disk engine read error", zap.String("key", key), zap.Error(err))
+
+			return "", false
+		}
+		if found {
+			return value.value, !value.deleted
+		}
+	}
+
+	return "", false
+}
+
+func (s *DiskStorage) Set(key string, value string) {
+	s.write(key, diskValue{value: value}, diskSetRecord, key, value)
+}
+
+func (s *DiskStorage) Delete(key string) {
+	s.write(key, diskValue{deleted: true}, diskDeleteRecord, key)
+}
+
+// write logs the change and applies it to the memtable. Errors are logged,
+// a write failed to reach the log is not applied.
+func (s *DiskStorage) write(key string, value diskValue, command string, args ...string) {
+	s.mu.Lock()
+	if err := s.log.Append(command, args); err != nil {
+		s.mu.Unlock()
+		s.logger.Error("Disk engine log write error", zap.String("key", key), zap.Error(err))
+
+		return
+	}
+	s.memtable.put(key, value)
+	full := s.memtable.size >= s.memtableSize
+	s.mu.Unlock()
+
+	if full {
+		s.freezeMemtable()
+	}
+}
+
+// freezeMemtable replaces the full memtable with an empty one and wakes
+// the background work up to write it. When the memtable frozen before is
+// not written yet, the writer writes it itself, so memtables do not pile up
+// in memory.
+func (s *DiskStorage) freezeMemtable() {
+	for {
+		s.mu.Lock()
+		if s.memtable.size < s.memtableSize {
+			s.mu.Unlock()
+
+			return
+		}
+		if s.immutable == nil {
+			break
+		}
+		s.mu.Unlock()
+
+		if err := s.flushMemtable(); err != nil {
+			s.logger.Error("Disk engine memtable write error", zap.Error(err))
+
+			return
+		}
+	}
+	defer s.mu.Unlock()
+
+	// the log is cut, so the memtable is covered by the segments up to the
+	// closed one
+	segment, err := s.log.Rotate()
+	if err != nil {
+		s.logger.Error("Disk engine log rotate error", zap.Error(err))
+
+		return
+	}
+	s.memtable.walSegment = segment
+	s.immutable = s.memtable
+	s.memtable = newMemtable()
+
+	select {
+	case s.work <- struct{}{}:
+	default:
+	}
+}
+
+// FlushMemtable freezes the memtable, even when it is not full, and writes
+// it to a level 0 table.
+func (s *DiskStorage) FlushMemtable() error {
+	if err := s.flushMemtable(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if len(s.memtable.entries) == 0 {
+		s.mu.Unlock()
+
+		return nil
+	}
+	segment, err := s.log.Rotate()
+	if err != nil {
+		s.mu.Unlock()
+
+		return err
+	}
+	s.memtable.walSegment = segment
+	s.immutable = s.memtable
+	s.memtable = newMemtable()
+	s.mu.Unlock()
+
+	return s.flushMemtable()
+}
+
+// flushMemtable writes the frozen memtable to a level 0 table, the log
+// segments it covers are removed afterwards.
+func (s *DiskStorage) flushMemtable() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	immutable := s.immutable
+	if immutable == nil {
+		s.mu.Unlock()
+
+		return nil
+	}
+	id := s.allocateFile()
+	s.mu.Unlock()
+
+	writer, err := createTable(s.directory, tableMeta{id: id}, s.blockSize)
+	if err != nil {
+		return err
+	}
+	for _, key := range immutable.sortedKeys() {
+		if err := writer.add(key, immutable.entries[key]); err != nil {
+			writer.abort()
+
+			return err
+		}
+	}
+	meta, err := writer.finish()
+	if err != nil {
+		writer.abort()
+
+		return err
+	}
+	t, err := openTable(s.directory, meta)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	tables, walSegment := s.tables, s.manifest.walSegment
+	s.tables = append([]*table{t}, tables...)
+	s.manifest.walSegment = immutable.walSegment
+	if err := s.saveManifest(); err != nil {
+		// the memtable stays frozen to be written again
+		s.tables, s.manifest.walSegment = tables, walSegment
+		s.mu.Unlock()
+		t.obsolete.Store(true)
+		t.release()
+
+		return err
+	}
+	s.immutable = nil
+	s.mu.Unlock()
+
+	return s.log.RemoveSegments(immutable.walSegment)
+}
+
+// Compact merges all level 0 tables with the level 1 tables overlapping
+// them into new level 1 tables. Level 1 is the last level, so overwritten
+// values and tombstones are dropped.
+func (s *DiskStorage) Compact() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	s.mu.Lock()
+	var inputs []*table
+	smallest, largest := "", ""
+	for _, t := range s.tables {
+		if t.meta.level != 0 {
+			continue
+		}
+		if len(inputs) == 0 || t.meta.smallest < smallest {
+			smallest = t.meta.smallest
+		}
+		if len(inputs) == 0 || t.meta.largest > largest {
+			largest = t.meta.largest
+		}
+		inputs = append(inputs, t)
+	}
+	if len(inputs) == 0 {
+		s.mu.Unlock()
+
+		return nil
+	}
+	for _, t := range s.tables {
+		if t.meta.level == 1 && t.meta.largest >= smallest && t.meta.smallest <= largest {
+			inputs = append(inputs, t)
+		}
+	}
+	for _, t := range inputs {
+		t.acquire()
+	}
+	s.mu.Unlock()
+	defer func() {
+		for _, t := range inputs {
+			t.release()
+		}
+	}()
+
+	outputs, err := s.mergeTables(inputs)
+	if err != nil {
+		for _, t := range outputs {
+			t.obsolete.Store(true)
+			t.release()
+		}
+
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := make(map[*table]struct{}, len(inputs))
+	for _, t := range inputs {
+		merged[t] = struct{}{}
+	}
+	tables := s.tables
+	s.tables = make([]*table, 0, len(tables)-len(inputs)+len(outputs))
+	for _, t := range tables {
+		if _, found := merged[t]; !found {
+			s.tables = append(s.tables, t)
+		}
+	}
+	s.tables = append(s.tables, outputs...)
+	s.sortTables()
+	if err := s.saveManifest(); err != nil {
+		s.tables = tables
+		for _, t := range outputs {
+			t.obsolete.Store(true)
+			t.release()
+		}
+
+		return err
+	}
+
+	// the table list references of the merged tables
+	for _, t := range inputs {
+		t.obsolete.Store(true)
+		t.release()
+	}
+
+	return nil
+}
+
+// mergeTables writes the live entries of the tables, which go from the
+// newest one, to level 1 tables of about the memtable size.
+func (s *DiskStorage) mergeTables(inputs []*table) ([]*table, error) {
+	iterators := make([]*tableIterator, 0, len(inputs))
+	for _, t := range inputs {
+		iterators = append(iterators, t.iterator())
+	}
+
+	var (
+		outputs []*table
+		writer  *tableWriter
+	)
+	finish := func() error {
+		meta, err := writer.finish()
+		if err != nil {
+			writer.abort()
+
+			return err
+		}
+		writer = nil
+
+		t, err := openTable(s.directory, meta)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, t)
+
+		return nil
+	}
+
+	err := mergeTables(iterators, func(key string, value diskValue) error {
+		if value.deleted {
+			return nil
+		}
+		if writer == nil {
+			s.mu.Lock()
+			id := s.allocateFile()
+			s.mu.Unlock()
+
+			var err error
+			if writer, err = createTable(s.directory, tableMeta{id: id, level: 1}, s.blockSize); err != nil {
+				return err
+			}
+		}
+		if err := writer.add(key, value); err != nil {
+			return err
+		}
+		if writer.size() >= uint64(s.memtableSize) {
+			return finish()
+		}
+
+		return nil
+	})
+	if err == nil && writer != nil {
+		err = finish()
+	}
+	if err != nil && writer != nil {
+		writer.abort()
+	}
+
+	return outputs, err
+}
+
+// Run writes frozen memtables and compacts level 0 tables in the
+// background until ctx is done, it also syncs the log.
+func (s *DiskStorage) Run(ctx context.Context) {
+	go s.log.Run(ctx)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.work:
+		case <-ticker.C:
+		}
+
+		if err := s.flushMemtable(); err != nil {
+			s.logger.Error("Disk engine memtable write error", zap.Error(err))
+		}
+		if s.level0Tables() >= s.compactionThreshold {
+			if err := s.Compact(); err != nil {
+				s.logger.Error("Disk engine compaction error", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *DiskStorage) level0Tables() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, t := range s.tables {
+		if t.meta.level == 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Flush removes all keys, the tables are dropped and the log starts over.
+func (s *DiskStorage) Flush() {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	segment, err := s.log.Rotate()
+	if err != nil {
+		s.mu.Unlock()
+		s.logger.Error("Disk engine log rotate error", zap.Error(err))
+
+		return
+	}
+	tables := s.tables
+	s.tables = nil
+	s.memtable = newMemtable()
+	s.immutable = nil
+	s.manifest.walSegment = segment
+	err = s.saveManifest()
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Error("Disk engine manifest write error", zap.Error(err))
+
+		return
+	}
+
+	for _, t := range tables {
+		t.obsolete.Store(true)
+		t.release()
+	}
+	if err := s.log.RemoveSegments(segment); err != nil {
+		s.logger.Error("Disk engine log remove error", zap.Error(err))
+	}
+}
+
+// Close closes the log and the table files, the memtable is restored from
+// the log on the next start. It must be called once the background work is
+// done.
+func (s *DiskStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tables {
+		t.release()
+	}
+	s.tables = nil
+
+	if s.log == nil {
+		return nil
+	}
+
+	return s.log.Close()
+}
+
+// allocateFile returns the id of a new table file. The lock must be held.
+func (s *DiskStorage) allocateFile() uint64 {
+	id := s.manifest.nextFile
+	s.manifest.nextFile++
+
+	return id
+}
+
+// saveManifest writes the current table list. The lock must be held.
+func (s *DiskStorage) saveManifest() error {
+	s.manifest.tables = s.manifest.tables[:0]
+	for _, t := range s.tables {
+		s.manifest.tables = append(s.manifest.tables, t.meta)
+	}
+
+	return s.manifest.write(s.directory)
+}
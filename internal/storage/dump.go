@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	maxStringLength = 1 << 30
+	maxDatabases    = 1 << 16
+)
+
+// Record is a single key of a keyspace dump.
+type Record struct {
+	// DB is the index of the database holding the key.
+	DB   int
+	Key  string
+	Type ValueType
+	// Value holds a string value.
+	Value string
+	// Items holds elements of a list, field and value pairs of a hash,
+	// members of a set or member and score pairs of a sorted set.
+	Items []string
+	// ExpireAt is a unix time in nanoseconds, zero means the key never expires.
+	ExpireAt int64
+}
+
+// Dump returns a point-in-time copy of the keyspace. All shards are locked
+// for reading while the copy is made. Index definitions are dumped as
+// records of IndexType before the keys.
+func (s *InMemoryStorage) Dump() []Record {
+	defer s.lockShards(false)()
+
+	count := 0
+	for _, sh := range s.shards {
+		count += len(sh.data)
+	}
+
+	definitions := s.shards[0].indexDefinitions()
+	records := make([]Record, 0, len(definitions)+count)
+	for _, definition := range definitions {
+		records = append(records, indexRecord(definition))
+	}
+
+	now := time.Now().UnixNano()
+	for _, sh := range s.shards {
+		for key, e := range sh.data {
+			if e.expired(now) {
+				continue
+			}
+			records = append(records, e.record(key))
+		}
+	}
+
+	return records
+}
+
+// Load replaces the keyspace and the indexes with the dumped records,
+// already expired records are skipped.
+func (s *InMemoryStorage) Load(records []Record) {
+	s.load(records, false)
+}
+
+// load replaces the keyspace with the records, the indexes are replaced by
+// the dumped ones unless keepIndexes is true.
+func (s *InMemoryStorage) load(records []Record, keepIndexes bool) {
+	defer s.lockShards(true)()
+
+	var definitions []IndexDefinition
+	if keepIndexes {
+		definitions = s.shards[0].indexDefinitions()
+	}
+	for _, record := range records {
+		if record.Type == IndexType {
+			definitions = append(definitions, record.indexDefinition())
+		}
+	}
+
+	for _, sh := range s.shards {
+		sh.data = make(map[string]*entry)
+		sh.volatile = make(map[string]struct{})
+		if sh.sorted != nil {
+			sh.sorted = newSkiplist()
+		}
+		sh.used.Store(0)
+		sh.compressedValues.Store(0)
+		sh.compressedRaw.Store(0)
+		sh.compressedSize.Store(0)
+		if sh.history != nil {
+			sh.history.keys = make(map[string]*keyHistory)
+		}
+		sh.indexes = nil
+		for _, definition := range definitions {
+			// definitions with invalid paths are skipped
+			_ = sh.addIndex(definition)
+		}
+		sh.version++
+		sh.deleted = sh.version
+	}
+
+	now := time.Now().UnixNano()
+	for _, record := range records {
+		if record.Type == IndexType {
+			continue
+		}
+
+		e := &entry{value: record.value(), expireAt: record.ExpireAt}
+		if e.expired(now) {
+			continue
+		}
+		if value, ok := e.value.(string); ok {
+			e.value = s.encode(value)
+		}
+		s.shard(record.Key).set(record.Key, e)
+	}
+}
+
+// indexRecord stores the index definition in a record keyed by the index
+// name with the prefix and the path as items.
+func indexRecord(definition IndexDefinition) Record {
+	return Record{Key: definition.Name, Type: IndexType, Items: []string{definition.Prefix, definition.Path}}
+}
+
+func (r Record) indexDefinition() IndexDefinition {
+	definition := IndexDefinition{Name: r.Key}
+	if len(r.Items) == 2 {
+		definition.Prefix, definition.Path = r.Items[0], r.Items[1]
+	}
+
+	return definition
+}
+
+func (e *entry) record(key string) Record {
+	record := Record{Key: key, Type: valueType(e.value), ExpireAt: e.expireAt}
+	switch value := e.value.(type) {
+	case string:
+		record.Value = value
+	case *compressed:
+		record.Value, _ = value.string()
+	case *list:
+		record.Items = append([]string(nil), value.elements()...)
+	case *hash:
+		record.Items = make([]string, 0, 2*value.len())
+		for field, fieldValue := range value.fields {
+			record.Items = append(record.Items, field, fieldValue)
+		}
+	case *set:
+		record.Items = make([]string, 0, value.len())
+		for member := range value.members {
+			record.Items = append(record.Items, member)
+		}
+	case *zset:
+		record.Items = value.members()
+	}
+
+	return record
+}
+
+func (r Record) value() any {
+	switch r.Type {
+	case ListType:
+		l := &list{}
+		l.replace(r.Items)
+
+		return l
+	case HashType:
+		h := newHash()
+		for i := 0; i+1 < len(r.Items); i += 2 {
+			h.set(r.Items[i], r.Items[i+1])
+		}
+
+		return h
+	case SetType:
+		st := newSet()
+		for _, member := range r.Items {
+			st.add(member)
+		}
+
+		return st
+	case ZSetType:
+		z := newZSet()
+		for i := 0; i+1 < len(r.Items); i += 2 {
+			score, _ := strconv.ParseFloat(r.Items[i+1], 64)
+			z.set(r.Items[i], score)
+		}
+
+		return z
+	default:
+		return r.Value
+	}
+}
+
+// WriteRecord encodes the record as uvarint database index, length prefixed
+// key, the value type, the value and the expiration time. A string value is written as a
+// length prefixed string, other values as a uvarint count of length
+// prefixed items.
+func WriteRecord(w io.Writer, record Record) error {
+	buf := make([]byte, 0, len(record.Key)+len(record.Value)+4*binary.MaxVarintLen64)
+	buf = binary.AppendUvarint(buf, uint64(record.DB))
+	buf = appendString(buf, record.Key)
+	buf = append(buf, byte(record.Type))
+	if record.Type == StringType {
+		buf = appendString(buf, record.Value)
+	} else {
+		buf = binary.AppendUvarint(buf, uint64(len(record.Items)))
+		for _, item := range record.Items {
+			buf = appendString(buf, item)
+		}
+	}
+	buf = binary.AppendVarint(buf, record.ExpireAt)
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+// ReadRecord decodes a record written by WriteRecord.
+func ReadRecord(r *bufio.Reader) (Record, error) {
+	db, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Record{}, err
+	}
+	if db > maxDatabases {
+		return Record{}, fmt.Errorf("database index %d is too big", db)
+	}
+
+	key, err := readString(r)
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+
+	record := Record{DB: int(db), Key: key}
+	valueType, err := r.ReadByte()
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	record.Type = ValueType(valueType)
+
+	switch record.Type {
+	case StringType:
+		if record.Value, err = readString(r); err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+	case ListType, HashType, SetType, ZSetType, IndexType:
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return Record{}, unexpectedEOF(err)
+		}
+		if count > maxStringLength {
+			return Record{}, fmt.Errorf("items count %d is too big", count)
+		}
+
+		record.Items = make([]string, 0, min(count, 1<<16))
+		for i := uint64(0); i < count; i++ {
+			item, err := readString(r)
+			if err != nil {
+				return Record{}, unexpectedEOF(err)
+			}
+			record.Items = append(record.Items, item)
+		}
+	default:
+		return Record{}, fmt.Errorf("unknown value type %d", valueType)
+	}
+
+	if record.ExpireAt, err = binary.ReadVarint(r); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+
+	return record, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+
+	return append(buf, s...)
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if length > maxStringLength {
+		return "", fmt.Errorf("string length %d is too big", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", unexpectedEOF(err)
+	}
+
+	return string(buf), nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
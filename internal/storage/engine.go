@@ -1,26 +1,534 @@
 package storage
 
-type InMemoryStorage struct{
-	data map[string]string
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"umemory/internal"
+
+	"go.uber.org/zap"
+)
+
+const (
+	InMemoryEngine = "in_memory"
+	ShardedEngine  = "sharded"
+	OrderedEngine  = "ordered"
+
+	defaultShardsCount        = 16
+	defaultExpirationInterval = time.Second
+)
+
+func init() {
+	Register(InMemoryEngine, func(config internal.Config, _ *zap.Logger) (Engine, error) {
+		if err := checkEvictionPolicy(config.Engine.EvictionPolicy); err != nil {
+			return nil, err
+		}
+		if err := checkCompression(config.Engine.Compression); err != nil {
+			return nil, err
+		}
+		config.Engine.ShardsCount = 1
+
+		return NewInMemoryStorage(config), nil
+	})
+	Register(ShardedEngine, func(config internal.Config, _ *zap.Logger) (Engine, error) {
+		if err := checkEvictionPolicy(config.Engine.EvictionPolicy); err != nil {
+			return nil, err
+		}
+		if err := checkCompression(config.Engine.Compression); err != nil {
+			return nil, err
+		}
+
+		return NewInMemoryStorage(config), nil
+	})
+}
+
+// InMemoryStorage splits the keyspace into shards by key hash, each shard
+// guarded by its own lock, so connections working with different keys
+// do not block each other. The in_memory engine is the same storage with
+// a single shard.
+type InMemoryStorage struct {
+	shards []*shard
+
+	expirationInterval time.Duration
+
+	// maxMemory is the memory limit in bytes, zero means no limit.
+	maxMemory      int64
+	evictionPolicy string
+	evictedKeys    atomic.Uint64
+	// pool is set when the memory limit is shared with other databases.
+	pool *memoryPool
+
+	// string values from compressionThreshold bytes are compressed by
+	// codec, zero threshold disables compression.
+	compressionThreshold int
+	codec                Codec
+	codecName            string
+}
+
+type shard struct {
+	mu   sync.RWMutex
+	data map[string]*entry
+	// volatile holds keys with expiration, so the sweeper does not have to
+	// walk the whole shard.
+	volatile map[string]struct{}
+	// sorted holds the keys in lexical order, it is nil unless the engine
+	// is ordered.
+	sorted *skiplist
+	// used is the approximate memory held by the shard entries in bytes.
+	used atomic.Int64
+	// version is bumped on every modification of the shard keys. Entries
+	// keep the version of their last modification, missing keys have the
+	// version of the last removal, so a key removed and created again does
+	// not get its old version back.
+	version uint64
+	deleted uint64
+	// onExpired is called with the keys removed on expiration.
+	onExpired func(key string)
+	// history is nil unless the history of string keys is enabled.
+	history *history
+	// indexes holds the parts of the secondary indexes by index name, every
+	// shard has a part of each index.
+	indexes map[string]*fieldIndex
+	// compressedValues is the number of compressed values, compressedRaw
+	// and compressedSize are their sizes before and after compression.
+	compressedValues atomic.Int64
+	compressedRaw    atomic.Int64
+	compressedSize   atomic.Int64
+}
+
+type entry struct {
+	// value is a string, a *compressed string, a *list, a *hash, a *set or
+	// a *zset.
+	value any
+	// expireAt is a unix time in nanoseconds, zero means the key never expires.
+	expireAt int64
+	// version is the shard version of the last modification.
+	version uint64
+
+	// accessedAt and hits are used by the eviction policies, they are
+	// updated by readers holding only the shard read lock.
+	accessedAt atomic.Int64
+	hits       atomic.Uint64
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expireAt != 0 && e.expireAt <= now
+}
+
+func (e *entry) touch(now int64) {
+	e.accessedAt.Store(now)
+	e.hits.Add(1)
 }
 
-func NewInMemoryStorage() *InMemoryStorage {
-	return &InMemoryStorage{
-		data: make(map[string]string),
+// size approximates the memory held by the entry stored by key.
+func (e *entry) size(key string) int64 {
+	size := int64(len(key)) + entryOverhead
+	switch value := e.value.(type) {
+	case string:
+		size += int64(len(value))
+	case *compressed:
+		size += int64(len(value.data))
+	case *list:
+		size += value.size()
+	case *hash:
+		size += value.size()
+	case *set:
+		size += value.size()
+	case *zset:
+		size += value.size()
+	}
+
+	return size
+}
+
+func NewInMemoryStorage(config internal.Config) *InMemoryStorage {
+	shardsCount := defaultShardsCount
+	if config.Engine.ShardsCount > 0 {
+		shardsCount = config.Engine.ShardsCount
+	}
+
+	storage := &InMemoryStorage{
+		shards:             make([]*shard, shardsCount),
+		expirationInterval: defaultExpirationInterval,
+		maxMemory:          int64(config.Engine.MaxMemory),
+		evictionPolicy:     NoEviction,
 	}
+	if config.Engine.EvictionPolicy != "" {
+		storage.evictionPolicy = config.Engine.EvictionPolicy
+	}
+	if config.Engine.ExpirationInterval > 0 {
+		storage.expirationInterval = config.Engine.ExpirationInterval
+	}
+	// an unknown codec is rejected by the engine factories
+	if codec, err := lookupCodec(config.Engine.Compression.Codec); err == nil && config.Engine.Compression.Threshold > 0 {
+		storage.compressionThreshold = int(config.Engine.Compression.Threshold)
+		storage.codec = codec
+		storage.codecName = config.Engine.Compression.Codec
+		if storage.codecName == "" {
+			storage.codecName = defaultCodec
+		}
+	}
+
+	for i := range storage.shards {
+		storage.shards[i] = &shard{
+			data:     make(map[string]*entry),
+			volatile: make(map[string]struct{}),
+			history:  newHistory(config.Engine.History),
+		}
+	}
+
+	return storage
+}
+
+func (s *InMemoryStorage) Capabilities() Capabilities {
+	return Capabilities{TTL: true}
 }
 
+// Get returns a string value by key. Keys holding values of other types are
+// reported as missing, Type tells them apart.
 func (s *InMemoryStorage) Get(key string) (string, bool) {
-	value, found := s.data[key]
+	sh := s.shard(key)
+	now := time.Now().UnixNano()
 
-	return value, found
+	sh.mu.RLock()
+	e, found := sh.data[key]
+	if !found {
+		sh.mu.RUnlock()
+
+		return "", false
+	}
+	if e.expired(now) {
+		sh.mu.RUnlock()
+
+		sh.mu.Lock()
+		sh.deleteExpired(key, now)
+		sh.mu.Unlock()
+
+		return "", false
+	}
+	value, ok := stringValue(e.value)
+	if ok {
+		e.touch(now)
+	}
+	sh.mu.RUnlock()
+
+	return value, ok
 }
 
 func (s *InMemoryStorage) Set(key string, value string) {
-	s.data[key] = value
+	encoded := s.encode(value)
+
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.set(key, &entry{value: encoded})
+}
+
+func (s *InMemoryStorage) SetWithTTL(key string, value string, ttl time.Duration) {
+	encoded := s.encode(value)
+
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.set(key, &entry{value: encoded, expireAt: time.Now().Add(ttl).UnixNano()})
 }
 
 func (s *InMemoryStorage) Delete(key string) {
-	delete(s.data, key)
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.delete(key)
+}
+
+// Expire sets a time to live for an existing key. A non-positive ttl
+// deletes the key right away.
+func (s *InMemoryStorage) Expire(key string, ttl time.Duration) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now()
+	e, found := sh.alive(key, now.UnixNano())
+	if !found {
+		return false
+	}
+	if ttl <= 0 {
+		sh.delete(key)
+
+		return true
+	}
+
+	e.expireAt = now.Add(ttl).UnixNano()
+	sh.set(key, e)
+
+	return true
+}
+
+// TTL returns the remaining time to live of a key, or -1 if the key
+// exists but has no expiration.
+func (s *InMemoryStorage) TTL(key string) (time.Duration, bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	e, found := sh.data[key]
+	if !found || e.expired(now) {
+		return 0, false
+	}
+	if e.expireAt == 0 {
+		return -1, true
+	}
+
+	return time.Duration(e.expireAt - now), true
+}
+
+// Persist removes the expiration from a key. It returns false when the
+// key does not exist or has no expiration.
+func (s *InMemoryStorage) Persist(key string) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, found := sh.alive(key, time.Now().UnixNano())
+	if !found || e.expireAt == 0 {
+		return false
+	}
+
+	e.expireAt = 0
+	sh.set(key, e)
+
+	return true
 }
 
+// Version returns a number changed by every modification of the key,
+// including its removal and expiration.
+func (s *InMemoryStorage) Version(key string) uint64 {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, found := sh.alive(key, time.Now().UnixNano())
+	if !found {
+		return sh.deleted
+	}
+
+	return e.version
+}
+
+// CompareAndSet replaces the string stored by key with value when it equals
+// expected. The key keeps its time to live.
+func (s *InMemoryStorage) CompareAndSet(key, expected, value string) (bool, error) {
+	encoded := s.encode(value)
+
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, found := sh.alive(key, time.Now().UnixNano())
+	if !found {
+		return false, nil
+	}
+
+	current, ok := stringValue(e.value)
+	if !ok {
+		return false, ErrWrongType
+	}
+	if current != expected {
+		return false, nil
+	}
+	sh.set(key, &entry{value: encoded, expireAt: e.expireAt})
+
+	return true, nil
+}
+
+// Run periodically removes expired keys until ctx is done. Expired keys
+// are also dropped lazily on access, so the sweeper only bounds the memory
+// held by keys nobody reads anymore.
+func (s *InMemoryStorage) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.expirationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deleteExpired()
+		}
+	}
+}
+
+// Flush removes all keys, the indexes are kept.
+func (s *InMemoryStorage) Flush() {
+	s.load(nil, true)
+}
+
+// OnExpired registers fn to be called with every key removed on expiration,
+// either lazily on access or by the sweeper. fn is called with the shard of
+// the key locked, so it must not block or access the storage. OnExpired must
+// be called before the storage is used.
+func (s *InMemoryStorage) OnExpired(fn func(key string)) {
+	for _, sh := range s.shards {
+		sh.onExpired = fn
+	}
+}
+
+func (s *InMemoryStorage) deleteExpired() {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		now := time.Now().UnixNano()
+		for key := range sh.volatile {
+			sh.deleteExpired(key, now)
+		}
+		sh.mu.Unlock()
+	}
+}
+
+func (s *InMemoryStorage) shard(key string) *shard {
+	return s.shards[shardIndex(key, len(s.shards))]
+}
+
+// lockKeys locks the shards of all keys and returns the function unlocking
+// them. Shards are locked once each in the order of their indexes, so
+// concurrent multi-key operations cannot deadlock.
+func (s *InMemoryStorage) lockKeys(keys []string, write bool) func() {
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		indexes = append(indexes, shardIndex(key, len(s.shards)))
+	}
+	sort.Ints(indexes)
+
+	locked := make([]*shard, 0, len(indexes))
+	for i, index := range indexes {
+		if i > 0 && index == indexes[i-1] {
+			continue
+		}
+
+		sh := s.shards[index]
+		if write {
+			sh.mu.Lock()
+		} else {
+			sh.mu.RLock()
+		}
+		locked = append(locked, sh)
+	}
+
+	return func() {
+		for _, sh := range locked {
+			if write {
+				sh.mu.Unlock()
+			} else {
+				sh.mu.RUnlock()
+			}
+		}
+	}
+}
+
+// alive returns the entry by key treating expired entries as missing and
+// removing them. The shard write lock must be held.
+func (sh *shard) alive(key string, now int64) (*entry, bool) {
+	e, found := sh.data[key]
+	if !found {
+		return nil, false
+	}
+	if e.expired(now) {
+		sh.expire(key)
+
+		return nil, false
+	}
+
+	return e, true
+}
+
+// set stores the entry by key, the entry replacing an existing one keeps
+// its access frequency. A new entry is recorded in the history, storing
+// the same entry again only updates its expiration.
+func (sh *shard) set(key string, e *entry) {
+	now := time.Now().UnixNano()
+	old, found := sh.data[key]
+	if found {
+		sh.used.Add(-old.size(key))
+		sh.account(old, -1)
+		if old != e {
+			e.hits.Store(old.hits.Load())
+		}
+	} else if sh.sorted != nil {
+		sh.sorted.insert(0, key)
+	}
+	e.touch(now)
+	sh.used.Add(e.size(key))
+	sh.account(e, 1)
+	sh.modified(e)
+	if old != e {
+		sh.record(key, historyValue(e.value), now)
+		sh.index(key, e.value)
+	}
+
+	sh.data[key] = e
+	if e.expireAt != 0 {
+		sh.volatile[key] = struct{}{}
+	} else {
+		delete(sh.volatile, key)
+	}
+}
+
+func (sh *shard) delete(key string) {
+	e, found := sh.data[key]
+	if !found {
+		return
+	}
+
+	sh.used.Add(-e.size(key))
+	sh.account(e, -1)
+	delete(sh.data, key)
+	delete(sh.volatile, key)
+	if sh.sorted != nil {
+		sh.sorted.delete(0, key)
+	}
+
+	sh.unindex(key)
+
+	sh.version++
+	sh.deleted = sh.version
+	sh.record(key, nil, time.Now().UnixNano())
+}
+
+// modified bumps the version of the entry. The shard write lock must be
+// held.
+func (sh *shard) modified(e *entry) {
+	sh.version++
+	e.version = sh.version
+}
+
+func (sh *shard) deleteExpired(key string, now int64) {
+	if e, found := sh.data[key]; found && e.expired(now) {
+		sh.expire(key)
+	}
+}
+
+// expire removes the expired key and reports it to the expiration hook.
+// The shard write lock must be held.
+func (sh *shard) expire(key string) {
+	sh.delete(key)
+	if sh.onExpired != nil {
+		sh.onExpired(key)
+	}
+}
+
+func shardIndex(key string, shardsCount int) int {
+	return int(keyHash(key) % uint32(shardsCount))
+}
+
+func keyHash(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return h.Sum32()
+}
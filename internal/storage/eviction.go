@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Eviction policies applied when the memory limit is reached.
+const (
+	NoEviction    = "noeviction"
+	AllKeysLRU    = "allkeys-lru"
+	AllKeysLFU    = "allkeys-lfu"
+	VolatileLRU   = "volatile-lru"
+	AllKeysRandom = "random"
+
+	// evictionSamples is the number of keys compared to choose the one to
+	// evict. Eviction is approximated by sampling to avoid keeping all keys
+	// in access order.
+	evictionSamples = 5
+	// entryOverhead approximates the memory held by a key besides the bytes
+	// of the key and the value.
+	entryOverhead = 64
+)
+
+// MemoryStats describes the memory usage of the storage.
+type MemoryStats struct {
+	Used        int64
+	Max         int64
+	Policy      string
+	EvictedKeys uint64
+}
+
+func checkEvictionPolicy(policy string) error {
+	switch policy {
+	case "", NoEviction, AllKeysLRU, AllKeysLFU, VolatileLRU, AllKeysRandom:
+		return nil
+	default:
+		return fmt.Errorf("unknown eviction policy %q", policy)
+	}
+}
+
+func (s *InMemoryStorage) MemoryStats() MemoryStats {
+	return MemoryStats{
+		Used:        s.usedMemory(),
+		Max:         s.maxMemory,
+		Policy:      s.evictionPolicy,
+		EvictedKeys: s.evictedCount(),
+	}
+}
+
+// FreeMemory evicts keys according to the eviction policy until the used
+// memory is within the limit. It returns the evicted keys and false when
+// the limit is still exceeded, i.e. the policy is noeviction or there are
+// no keys the policy may evict.
+func (s *InMemoryStorage) FreeMemory() ([]string, bool) {
+	if s.maxMemory == 0 {
+		return nil, true
+	}
+
+	var evicted []string
+	for s.usedMemory() > s.maxMemory {
+		if s.evictionPolicy == NoEviction {
+			return evicted, false
+		}
+
+		key, ok := s.evict()
+		if !ok {
+			return evicted, false
+		}
+		if key != "" {
+			evicted = append(evicted, key)
+		}
+	}
+
+	return evicted, true
+}
+
+// usedMemory returns the memory held by the storage or by all storages
+// sharing its memory limit.
+func (s *InMemoryStorage) usedMemory() int64 {
+	if s.pool != nil {
+		return s.pool.used()
+	}
+
+	return s.shardsMemory()
+}
+
+func (s *InMemoryStorage) evictedCount() uint64 {
+	if s.pool != nil {
+		return s.pool.evictedKeys()
+	}
+
+	return s.evictedKeys.Load()
+}
+
+func (s *InMemoryStorage) shardsMemory() int64 {
+	var used int64
+	for _, sh := range s.shards {
+		used += sh.used.Load()
+	}
+
+	return used
+}
+
+type evictionCandidate struct {
+	shard      *shard
+	key        string
+	accessedAt int64
+	hits       uint64
+}
+
+// evict removes the best of the sampled keys. It returns false when there
+// is nothing to evict and an empty key when the chosen key was removed by
+// someone else in the meantime.
+func (s *InMemoryStorage) evict() (string, bool) {
+	perShard := max(1, evictionSamples/len(s.shards))
+	start := rand.Intn(len(s.shards))
+	now := time.Now().UnixNano()
+
+	var (
+		best    evictionCandidate
+		sampled int
+	)
+	for i := 0; i < len(s.shards) && sampled < evictionSamples; i++ {
+		sh := s.shards[(start+i)%len(s.shards)]
+
+		sh.mu.RLock()
+		for _, candidate := range sh.sample(perShard, s.evictionPolicy == VolatileLRU, now) {
+			if sampled == 0 || s.evictBefore(candidate, best) {
+				best = candidate
+			}
+			sampled++
+		}
+		sh.mu.RUnlock()
+	}
+	if sampled == 0 {
+		return "", false
+	}
+
+	best.shard.mu.Lock()
+	defer best.shard.mu.Unlock()
+
+	if _, found := best.shard.data[best.key]; !found {
+		return "", true
+	}
+	best.shard.delete(best.key)
+	best.shard.forget(best.key)
+	s.evictedKeys.Add(1)
+
+	return best.key, true
+}
+
+// evictBefore reports whether candidate a should be evicted rather than b.
+func (s *InMemoryStorage) evictBefore(a, b evictionCandidate) bool {
+	switch s.evictionPolicy {
+	case AllKeysRandom:
+		return false
+	case AllKeysLFU:
+		if a.hits != b.hits {
+			return a.hits < b.hits
+		}
+	}
+
+	return a.accessedAt < b.accessedAt
+}
+
+// sample returns up to n keys of the shard in random order, expired keys
+// are always evicted first. The shard read lock must be held.
+func (sh *shard) sample(n int, volatileOnly bool, now int64) []evictionCandidate {
+	candidates := make([]evictionCandidate, 0, n)
+	add := func(key string, e *entry) bool {
+		candidate := evictionCandidate{shard: sh, key: key}
+		if !e.expired(now) {
+			candidate.accessedAt = e.accessedAt.Load()
+			candidate.hits = e.hits.Load()
+		}
+		candidates = append(candidates, candidate)
+
+		return len(candidates) < n
+	}
+
+	if volatileOnly {
+		for key := range sh.volatile {
+			if !add(key, sh.data[key]) {
+				break
+			}
+		}
+	} else {
+		for key, e := range sh.data {
+			if !add(key, e) {
+				break
+			}
+		}
+	}
+
+	return candidates
+}
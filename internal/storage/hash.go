@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// hashFieldOverhead approximates the memory held by a hash field besides
+// the bytes of the field and its value.
+const hashFieldOverhead = 32
+
+var ErrHashValueNotInteger = errors.New("hash value is not an integer")
+
+type hash struct {
+	fields map[string]string
+	// bytes is the approximate memory held by the fields.
+	bytes int64
+}
+
+func newHash() *hash {
+	return &hash{fields: make(map[string]string)}
+}
+
+func (h *hash) len() int {
+	return len(h.fields)
+}
+
+func (h *hash) size() int64 {
+	return h.bytes
+}
+
+// set stores the field value and reports whether the field is new.
+func (h *hash) set(field, value string) bool {
+	old, found := h.fields[field]
+	if found {
+		h.bytes -= fieldSize(field, old)
+	}
+
+	h.fields[field] = value
+	h.bytes += fieldSize(field, value)
+
+	return !found
+}
+
+func (h *hash) delete(field string) bool {
+	value, found := h.fields[field]
+	if !found {
+		return false
+	}
+
+	delete(h.fields, field)
+	h.bytes -= fieldSize(field, value)
+
+	return true
+}
+
+// sortedFields returns the fields in lexical order, so replies do not
+// depend on the map iteration order.
+func (h *hash) sortedFields() []string {
+	fields := make([]string, 0, len(h.fields))
+	for field := range h.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func fieldSize(field, value string) int64 {
+	return int64(len(field)+len(value)) + hashFieldOverhead
+}
+
+// HSet stores the field values and returns the number of added fields.
+func (s *InMemoryStorage) HSet(key string, fields map[string]string) (int, error) {
+	var added int
+	err := updateCollection(s, key, newHash, func(h *hash) error {
+		for field, value := range fields {
+			if h.set(field, value) {
+				added++
+			}
+		}
+
+		return nil
+	})
+
+	return added, err
+}
+
+func (s *InMemoryStorage) HGet(key, field string) (string, bool, error) {
+	var (
+		value string
+		found bool
+	)
+	err := readCollection(s, key, func(h *hash) {
+		value, found = h.fields[field]
+	})
+
+	return value, found, err
+}
+
+// HDel removes the fields and returns the number of removed ones.
+func (s *InMemoryStorage) HDel(key string, fields []string) (int, error) {
+	var removed int
+	err := updateCollection(s, key, nil, func(h *hash) error {
+		for _, field := range fields {
+			if h.delete(field) {
+				removed++
+			}
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// HGetAll returns the fields with their values, ordered by field.
+func (s *InMemoryStorage) HGetAll(key string) ([]string, error) {
+	var fieldValues []string
+	err := readCollection(s, key, func(h *hash) {
+		fieldValues = make([]string, 0, 2*h.len())
+		for _, field := range h.sortedFields() {
+			fieldValues = append(fieldValues, field, h.fields[field])
+		}
+	})
+
+	return fieldValues, err
+}
+
+// HKeys returns the fields in lexical order.
+func (s *InMemoryStorage) HKeys(key string) ([]string, error) {
+	var fields []string
+	err := readCollection(s, key, func(h *hash) {
+		fields = h.sortedFields()
+	})
+
+	return fields, err
+}
+
+// HVals returns the values ordered by their fields.
+func (s *InMemoryStorage) HVals(key string) ([]string, error) {
+	var values []string
+	err := readCollection(s, key, func(h *hash) {
+		values = make([]string, 0, h.len())
+		for _, field := range h.sortedFields() {
+			values = append(values, h.fields[field])
+		}
+	})
+
+	return values, err
+}
+
+func (s *InMemoryStorage) HLen(key string) (int, error) {
+	var length int
+	err := readCollection(s, key, func(h *hash) {
+		length = h.len()
+	})
+
+	return length, err
+}
+
+func (s *InMemoryStorage) HExists(key, field string) (bool, error) {
+	var found bool
+	err := readCollection(s, key, func(h *hash) {
+		_, found = h.fields[field]
+	})
+
+	return found, err
+}
+
+// HIncrBy adds increment to the integer value of the field, a missing field
+// is set to increment.
+func (s *InMemoryStorage) HIncrBy(key, field string, increment int64) (int64, error) {
+	var result int64
+	err := updateCollection(s, key, newHash, func(h *hash) error {
+		var current int64
+		if value, found := h.fields[field]; found {
+			var err error
+			if current, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return ErrHashValueNotInteger
+			}
+		}
+
+		if (increment > 0 && current > math.MaxInt64-increment) ||
+			(increment < 0 && current < math.MinInt64-increment) {
+			return ErrOverflow
+		}
+
+		result = current + increment
+		h.set(field, strconv.FormatInt(result, 10))
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"time"
+	"umemory/internal"
+)
+
+// versionOverhead approximates the memory held by a version besides its
+// value.
+const versionOverhead = 32
+
+var (
+	ErrHistoryDisabled = errors.New("history is disabled")
+	ErrVersionNotFound = errors.New("version not found")
+)
+
+// Version is a past or the current value of a string key. A version of a
+// deleted key or of a key replaced by another type holds no value.
+type Version struct {
+	Version uint64
+	Value   string
+	Deleted bool
+	Time    time.Time
+}
+
+// history keeps the versions of string keys of a shard. Versions are kept
+// while their number is within versions and until retention passes since
+// they were replaced, zero disables the limit. History is not part of
+// snapshots, so a restart starts it over.
+type history struct {
+	versions  int
+	retention time.Duration
+	keys      map[string]*keyHistory
+}
+
+type keyHistory struct {
+	// last is the number of the last version, it keeps growing when old
+	// versions are dropped.
+	last     uint64
+	versions []version
+}
+
+type version struct {
+	number uint64
+	// value is a string or a *compressed string, nil for a deletion.
+	value any
+	at    int64
+}
+
+func newHistory(config internal.HistoryConfig) *history {
+	if config.Versions <= 0 && config.Retention <= 0 {
+		return nil
+	}
+
+	return &history{
+		versions:  config.Versions,
+		retention: config.Retention,
+		keys:      make(map[string]*keyHistory),
+	}
+}
+
+// historyValue returns the value to record in the history for an entry
+// value, values of other types than string are recorded as deletions.
+func historyValue(value any) any {
+	switch value.(type) {
+	case string, *compressed:
+		return value
+	default:
+		return nil
+	}
+}
+
+func (v version) size() int64 {
+	size := int64(versionOverhead)
+	switch value := v.value.(type) {
+	case string:
+		size += int64(len(value))
+	case *compressed:
+		size += int64(len(value.data))
+	}
+
+	return size
+}
+
+func (v version) public() Version {
+	result := Version{Version: v.number, Deleted: v.value == nil, Time: time.Unix(0, v.at).UTC()}
+	if v.value != nil {
+		result.Value, _ = stringValue(v.value)
+	}
+
+	return result
+}
+
+// record adds a version of the key, a nil value records its deletion. The
+// shard write lock must be held.
+func (sh *shard) record(key string, value any, now int64) {
+	if sh.history == nil {
+		return
+	}
+
+	kh, found := sh.history.keys[key]
+	if !found {
+		if value == nil {
+			return
+		}
+		kh = &keyHistory{}
+		sh.history.keys[key] = kh
+		sh.used.Add(int64(len(key)) + entryOverhead)
+	}
+	if value == nil && kh.versions[len(kh.versions)-1].value == nil {
+		return
+	}
+
+	kh.last++
+	v := version{number: kh.last, value: value, at: now}
+	kh.versions = append(kh.versions, v)
+	sh.used.Add(v.size())
+
+	sh.trimHistory(key, kh, now)
+}
+
+// trimHistory drops the versions out of the limits. The history of a key
+// left with its deletion only is dropped entirely.
+func (sh *shard) trimHistory(key string, kh *keyHistory, now int64) {
+	drop := 0
+	if sh.history.versions > 0 && len(kh.versions) > sh.history.versions {
+		drop = len(kh.versions) - sh.history.versions
+	}
+	if sh.history.retention > 0 {
+		// a version is kept while it was current within the window
+		windowStart := now - int64(sh.history.retention)
+		for drop < len(kh.versions)-1 && kh.versions[drop+1].at < windowStart {
+			drop++
+		}
+	}
+
+	for _, v := range kh.versions[:drop] {
+		sh.used.Add(-v.size())
+	}
+	kh.versions = append(kh.versions[:0], kh.versions[drop:]...)
+
+	if len(kh.versions) == 1 && kh.versions[0].value == nil {
+		sh.forget(key)
+	}
+}
+
+// forget drops the history of the key. The shard write lock must be held.
+func (sh *shard) forget(key string) {
+	if sh.history == nil {
+		return
+	}
+
+	kh, found := sh.history.keys[key]
+	if !found {
+		return
+	}
+
+	for _, v := range kh.versions {
+		sh.used.Add(-v.size())
+	}
+	sh.used.Add(-int64(len(key)) - entryOverhead)
+	delete(sh.history.keys, key)
+}
+
+// keyHistory returns the history of the key trimmed to the limits.
+func (sh *shard) keyHistory(key string) (*keyHistory, bool) {
+	kh, found := sh.history.keys[key]
+	if !found {
+		return nil, false
+	}
+
+	sh.trimHistory(key, kh, time.Now().UnixNano())
+	kh, found = sh.history.keys[key]
+
+	return kh, found
+}
+
+// lockHistory locks the shard of the key for writing, reading history
+// trims it.
+func (s *InMemoryStorage) lockHistory(key string) (*shard, error) {
+	sh := s.shard(key)
+	if sh.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	sh.mu.Lock()
+
+	return sh, nil
+}
+
+// History returns the kept versions of the key from the oldest one.
+func (s *InMemoryStorage) History(key string) ([]Version, error) {
+	sh, err := s.lockHistory(key)
+	if err != nil {
+		return nil, err
+	}
+	defer sh.mu.Unlock()
+
+	kh, found := sh.keyHistory(key)
+	if !found {
+		return nil, nil
+	}
+
+	versions := make([]Version, 0, len(kh.versions))
+	for _, v := range kh.versions {
+		versions = append(versions, v.public())
+	}
+
+	return versions, nil
+}
+
+// VersionAt returns the version of the key by number.
+func (s *InMemoryStorage) VersionAt(key string, number uint64) (Version, bool, error) {
+	sh, err := s.lockHistory(key)
+	if err != nil {
+		return Version{}, false, err
+	}
+	defer sh.mu.Unlock()
+
+	v, found := sh.findVersion(key, number)
+	if !found {
+		return Version{}, false, nil
+	}
+
+	return v.public(), true, nil
+}
+
+// VersionAtTime returns the version of the key which was current at the
+// time. It is not found when the time precedes the kept versions.
+func (s *InMemoryStorage) VersionAtTime(key string, at time.Time) (Version, bool, error) {
+	sh, err := s.lockHistory(key)
+	if err != nil {
+		return Version{}, false, err
+	}
+	defer sh.mu.Unlock()
+
+	kh, found := sh.keyHistory(key)
+	if !found {
+		return Version{}, false, nil
+	}
+
+	// the first version set after the time follows the one looked for
+	next := sort.Search(len(kh.versions), func(i int) bool {
+		return kh.versions[i].at > at.UnixNano()
+	})
+	if next == 0 {
+		return Version{}, false, nil
+	}
+
+	return kh.versions[next-1].public(), true, nil
+}
+
+// Revert restores the value of the key from the version, the key loses its
+// time to live like on Set. Reverting to a deletion deletes the key. It
+// returns the restored value and whether the key exists now.
+func (s *InMemoryStorage) Revert(key string, number uint64) (string, bool, error) {
+	sh, err := s.lockHistory(key)
+	if err != nil {
+		return "", false, err
+	}
+	defer sh.mu.Unlock()
+
+	v, found := sh.findVersion(key, number)
+	if !found {
+		return "", false, ErrVersionNotFound
+	}
+	if v.value == nil {
+		sh.delete(key)
+
+		return "", false, nil
+	}
+
+	value, ok := stringValue(v.value)
+	if !ok {
+		return "", false, ErrVersionNotFound
+	}
+	sh.set(key, &entry{value: v.value})
+
+	return value, true, nil
+}
+
+func (sh *shard) findVersion(key string, number uint64) (version, bool) {
+	kh, found := sh.keyHistory(key)
+	if !found {
+		return version{}, false
+	}
+
+	i := sort.Search(len(kh.versions), func(i int) bool {
+		return kh.versions[i].number >= number
+	})
+	if i == len(kh.versions) || kh.versions[i].number != number {
+		return version{}, false
+	}
+
+	return kh.versions[i], true
+}
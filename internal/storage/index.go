@@ -0,0 +1,468 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// indexEntryOverhead approximates the memory held by an indexed key besides
+// the key and the indexed value.
+const indexEntryOverhead = 48
+
+var (
+	ErrIndexExists   = errors.New("index already exists")
+	ErrIndexNotFound = errors.New("index not found")
+	ErrInvalidPath   = errors.New("invalid field path")
+)
+
+// IndexDefinition describes a secondary index over JSON documents stored
+// as strings by keys starting with Prefix. The value indexed is found by
+// Path, a dot separated list of object fields and array indexes with an
+// optional "$." prefix, like $.user.emails.0.
+type IndexDefinition struct {
+	Name   string
+	Prefix string
+	Path   string
+}
+
+// IndexRange is a bound of an index range query. Unbounded ranges have no
+// bound at their end, numeric bounds match numbers and other bounds match
+// strings.
+type IndexRange struct {
+	Value     string
+	Unbounded bool
+}
+
+// fieldIndex is the part of an index holding the keys of a shard. Numbers
+// are kept in their own skiplist ordered by the number and then by the key,
+// strings are kept as distinct values with the keys holding them, so every
+// key is indexed by a single value.
+type fieldIndex struct {
+	definition IndexDefinition
+	path       []string
+
+	numbers  *skiplist
+	texts    *skiplist
+	textKeys map[string]map[string]struct{}
+	// terms holds the indexed value by key.
+	terms map[string]indexTerm
+}
+
+// indexTerm is an indexed value, a number or a string. Booleans are indexed
+// as the strings true and false.
+type indexTerm struct {
+	text    string
+	number  float64
+	numeric bool
+}
+
+func (t indexTerm) size(key string) int64 {
+	return int64(len(key)+len(t.text)) + indexEntryOverhead
+}
+
+// parseIndexPath splits the field path into its segments.
+func parseIndexPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(path, "$.")
+	if path == "" {
+		return nil, ErrInvalidPath
+	}
+
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, ErrInvalidPath
+		}
+	}
+
+	return segments, nil
+}
+
+func newFieldIndex(definition IndexDefinition) (*fieldIndex, error) {
+	path, err := parseIndexPath(definition.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fieldIndex{
+		definition: definition,
+		path:       path,
+		numbers:    newSkiplist(),
+		texts:      newSkiplist(),
+		textKeys:   make(map[string]map[string]struct{}),
+		terms:      make(map[string]indexTerm),
+	}, nil
+}
+
+// extract returns the value found by the path in the JSON document. Values
+// other than strings, numbers and booleans are not indexed.
+func (fi *fieldIndex) extract(document string) (indexTerm, bool) {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return indexTerm{}, false
+	}
+
+	for _, segment := range fi.path {
+		switch container := value.(type) {
+		case map[string]any:
+			field, found := container[segment]
+			if !found {
+				return indexTerm{}, false
+			}
+			value = field
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(container) {
+				return indexTerm{}, false
+			}
+			value = container[i]
+		default:
+			return indexTerm{}, false
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		return indexTerm{text: value}, true
+	case json.Number:
+		number, err := value.Float64()
+		if err != nil {
+			return indexTerm{}, false
+		}
+
+		return indexTerm{number: number, numeric: true}, true
+	case bool:
+		return indexTerm{text: strconv.FormatBool(value)}, true
+	default:
+		return indexTerm{}, false
+	}
+}
+
+func (fi *fieldIndex) add(key string, term indexTerm) {
+	fi.terms[key] = term
+	if term.numeric {
+		fi.numbers.insert(term.number, key)
+
+		return
+	}
+
+	keys, found := fi.textKeys[term.text]
+	if !found {
+		keys = make(map[string]struct{})
+		fi.textKeys[term.text] = keys
+		fi.texts.insert(0, term.text)
+	}
+	keys[key] = struct{}{}
+}
+
+// remove drops the key from the index, it returns the dropped value.
+func (fi *fieldIndex) remove(key string) (indexTerm, bool) {
+	term, found := fi.terms[key]
+	if !found {
+		return indexTerm{}, false
+	}
+
+	delete(fi.terms, key)
+	if term.numeric {
+		fi.numbers.delete(term.number, key)
+
+		return term, true
+	}
+
+	keys := fi.textKeys[term.text]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(fi.textKeys, term.text)
+		fi.texts.delete(0, term.text)
+	}
+
+	return term, true
+}
+
+// find returns the keys holding the value, matching both the string and,
+// when the value is a number, the numeric values.
+func (fi *fieldIndex) find(value string) []string {
+	var keys []string
+	for key := range fi.textKeys[value] {
+		keys = append(keys, key)
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		bound := ScoreBound{Value: number}
+		for x := fi.numbers.firstInRange(bound); x != nil && x.score == number; x = x.levels[0].forward {
+			keys = append(keys, x.member)
+		}
+	}
+
+	return keys
+}
+
+// indexMatch is a key found by a range query with its indexed value.
+type indexMatch struct {
+	key  string
+	term indexTerm
+}
+
+// findRange returns the keys with values between inclusive min and max.
+// Numbers are matched when both bounds are numbers or unbounded, strings
+// are matched when neither bound is a number.
+func (fi *fieldIndex) findRange(min, max IndexRange) []indexMatch {
+	minNumber, minNumeric := min.number()
+	maxNumber, maxNumeric := max.number()
+
+	var matches []indexMatch
+	if (min.Unbounded || minNumeric) && (max.Unbounded || maxNumeric) {
+		if min.Unbounded {
+			minNumber = math.Inf(-1)
+		}
+		if max.Unbounded {
+			maxNumber = math.Inf(1)
+		}
+
+		bound, maxBound := ScoreBound{Value: minNumber}, ScoreBound{Value: maxNumber}
+		for x := fi.numbers.firstInRange(bound); x != nil && maxBound.above(x.score); x = x.levels[0].forward {
+			matches = append(matches, indexMatch{key: x.member, term: indexTerm{number: x.score, numeric: true}})
+		}
+	}
+
+	if (min.Unbounded || !minNumeric) && (max.Unbounded || !maxNumeric) {
+		x := fi.texts.header.levels[0].forward
+		if !min.Unbounded {
+			x = fi.texts.firstFrom(0, min.Value)
+		}
+		for ; x != nil && (max.Unbounded || x.member <= max.Value); x = x.levels[0].forward {
+			for key := range fi.textKeys[x.member] {
+				matches = append(matches, indexMatch{key: key, term: indexTerm{text: x.member}})
+			}
+		}
+	}
+
+	return matches
+}
+
+func (r IndexRange) number() (float64, bool) {
+	if r.Unbounded {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(r.Value, 64)
+
+	return number, err == nil
+}
+
+// index adds the key to the indexes covering it, the previous value of the
+// key is dropped from them. The shard write lock must be held.
+func (sh *shard) index(key string, value any) {
+	if len(sh.indexes) == 0 {
+		return
+	}
+
+	sh.unindex(key)
+	document, ok := "", false
+	for _, fi := range sh.indexes {
+		if !strings.HasPrefix(key, fi.definition.Prefix) {
+			continue
+		}
+		// decompressed once for all the indexes
+		if !ok {
+			if document, ok = stringValue(value); !ok {
+				return
+			}
+		}
+
+		if term, found := fi.extract(document); found {
+			fi.add(key, term)
+			sh.used.Add(term.size(key))
+		}
+	}
+}
+
+// unindex drops the key from all indexes. The shard write lock must be
+// held.
+func (sh *shard) unindex(key string) {
+	for _, fi := range sh.indexes {
+		if term, found := fi.remove(key); found {
+			sh.used.Add(-term.size(key))
+		}
+	}
+}
+
+// addIndex creates the part of the index for the shard keys. The shard
+// write lock must be held.
+func (sh *shard) addIndex(definition IndexDefinition) error {
+	fi, err := newFieldIndex(definition)
+	if err != nil {
+		return err
+	}
+	if sh.indexes == nil {
+		sh.indexes = make(map[string]*fieldIndex)
+	}
+	sh.indexes[definition.Name] = fi
+
+	for key, e := range sh.data {
+		if !strings.HasPrefix(key, definition.Prefix) {
+			continue
+		}
+		document, ok := stringValue(e.value)
+		if !ok {
+			continue
+		}
+		if term, found := fi.extract(document); found {
+			fi.add(key, term)
+			sh.used.Add(term.size(key))
+		}
+	}
+
+	return nil
+}
+
+// lockShards locks all shards for writing, or for reading when write is
+// false, and returns the function unlocking them.
+func (s *InMemoryStorage) lockShards(write bool) func() {
+	for _, sh := range s.shards {
+		if write {
+			sh.mu.Lock()
+		} else {
+			sh.mu.RLock()
+		}
+	}
+
+	return func() {
+		for _, sh := range s.shards {
+			if write {
+				sh.mu.Unlock()
+			} else {
+				sh.mu.RUnlock()
+			}
+		}
+	}
+}
+
+// CreateIndex creates the index and adds the existing keys to it. All
+// shards are locked while the keys are indexed.
+func (s *InMemoryStorage) CreateIndex(definition IndexDefinition) error {
+	if _, err := parseIndexPath(definition.Path); err != nil {
+		return err
+	}
+
+	defer s.lockShards(true)()
+
+	if _, found := s.shards[0].indexes[definition.Name]; found {
+		return ErrIndexExists
+	}
+	for _, sh := range s.shards {
+		if err := sh.addIndex(definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropIndex removes the index.
+func (s *InMemoryStorage) DropIndex(name string) error {
+	defer s.lockShards(true)()
+
+	if _, found := s.shards[0].indexes[name]; !found {
+		return ErrIndexNotFound
+	}
+	for _, sh := range s.shards {
+		for key, term := range sh.indexes[name].terms {
+			sh.used.Add(-term.size(key))
+		}
+		delete(sh.indexes, name)
+	}
+
+	return nil
+}
+
+// Indexes returns the definitions of the indexes sorted by name.
+func (s *InMemoryStorage) Indexes() []IndexDefinition {
+	sh := s.shards[0]
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.indexDefinitions()
+}
+
+func (sh *shard) indexDefinitions() []IndexDefinition {
+	definitions := make([]IndexDefinition, 0, len(sh.indexes))
+	for _, fi := range sh.indexes {
+		definitions = append(definitions, fi.definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+
+	return definitions
+}
+
+// Find returns the sorted keys whose indexed value equals value, a number
+// matches both the string and the numeric value.
+func (s *InMemoryStorage) Find(name, value string) ([]string, error) {
+	defer s.lockShards(false)()
+
+	if _, found := s.shards[0].indexes[name]; !found {
+		return nil, ErrIndexNotFound
+	}
+
+	var keys []string
+	now := time.Now().UnixNano()
+	for _, sh := range s.shards {
+		for _, key := range sh.indexes[name].find(value) {
+			if e := sh.data[key]; !e.expired(now) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// FindRange returns the keys whose indexed values are between inclusive
+// min and max, ordered by the value and then by the key. Numbers go before
+// strings when both are matched.
+func (s *InMemoryStorage) FindRange(name string, min, max IndexRange) ([]string, error) {
+	defer s.lockShards(false)()
+
+	if _, found := s.shards[0].indexes[name]; !found {
+		return nil, ErrIndexNotFound
+	}
+
+	var matches []indexMatch
+	now := time.Now().UnixNano()
+	for _, sh := range s.shards {
+		for _, match := range sh.indexes[name].findRange(min, max) {
+			if e := sh.data[match.key]; !e.expired(now) {
+				matches = append(matches, match)
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i].term, matches[j].term
+		switch {
+		case a.numeric != b.numeric:
+			return a.numeric
+		case a.number != b.number:
+			return a.number < b.number
+		case a.text != b.text:
+			return a.text < b.text
+		default:
+			return matches[i].key < matches[j].key
+		}
+	})
+
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		keys = append(keys, match.key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,254 @@
+package storage
+
+// listItemOverhead approximates the memory held by a list element besides
+// its bytes.
+const listItemOverhead = 16
+
+// list is a double-ended queue, the elements are items[head:] so pushing
+// and popping at both ends is amortized O(1).
+type list struct {
+	items []string
+	head  int
+	// bytes is the approximate memory held by the elements.
+	bytes int64
+}
+
+func (l *list) len() int {
+	return len(l.items) - l.head
+}
+
+func (l *list) size() int64 {
+	return l.bytes
+}
+
+func (l *list) elements() []string {
+	return l.items[l.head:]
+}
+
+func (l *list) pushFront(value string) {
+	if l.head == 0 {
+		n := l.len()
+		room := max(n, 4)
+		items := make([]string, room+n, room+2*n)
+		copy(items[room:], l.items)
+		l.items = items
+		l.head = room
+	}
+
+	l.head--
+	l.items[l.head] = value
+	l.bytes += itemSize(value)
+}
+
+func (l *list) pushBack(value string) {
+	l.items = append(l.items, value)
+	l.bytes += itemSize(value)
+}
+
+func (l *list) popFront() string {
+	value := l.items[l.head]
+	l.items[l.head] = ""
+	l.head++
+	l.bytes -= itemSize(value)
+
+	// release the room left by popped elements
+	if l.head > 32 && l.head > l.len() {
+		l.replace(append([]string(nil), l.elements()...))
+	}
+
+	return value
+}
+
+func (l *list) popBack() string {
+	last := len(l.items) - 1
+	value := l.items[last]
+	l.items[last] = ""
+	l.items = l.items[:last]
+	l.bytes -= itemSize(value)
+
+	return value
+}
+
+func (l *list) replace(items []string) {
+	l.items = items
+	l.head = 0
+	l.bytes = 0
+	for _, item := range items {
+		l.bytes += itemSize(item)
+	}
+}
+
+func itemSize(value string) int64 {
+	return int64(len(value)) + listItemOverhead
+}
+
+func newList() *list {
+	return &list{}
+}
+
+// normalizeRange converts inclusive start and stop indexes, which may be
+// negative to count from the end, to a valid range of a sequence of
+// the given length. It returns false when the range is empty.
+func normalizeRange(start, stop, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start, stop, true
+}
+
+func (s *InMemoryStorage) LPush(key string, values []string) (int, error) {
+	return s.push(key, values, (*list).pushFront)
+}
+
+func (s *InMemoryStorage) RPush(key string, values []string) (int, error) {
+	return s.push(key, values, (*list).pushBack)
+}
+
+func (s *InMemoryStorage) push(key string, values []string, push func(l *list, value string)) (int, error) {
+	var length int
+	err := updateCollection(s, key, newList, func(l *list) error {
+		for _, value := range values {
+			push(l, value)
+		}
+		length = l.len()
+
+		return nil
+	})
+
+	return length, err
+}
+
+func (s *InMemoryStorage) LPop(key string) (string, bool, error) {
+	return s.pop(key, (*list).popFront)
+}
+
+func (s *InMemoryStorage) RPop(key string) (string, bool, error) {
+	return s.pop(key, (*list).popBack)
+}
+
+func (s *InMemoryStorage) pop(key string, pop func(l *list) string) (string, bool, error) {
+	var (
+		value  string
+		popped bool
+	)
+	err := updateCollection(s, key, nil, func(l *list) error {
+		value, popped = pop(l), true
+
+		return nil
+	})
+
+	return value, popped, err
+}
+
+func (s *InMemoryStorage) LLen(key string) (int, error) {
+	var length int
+	err := readCollection(s, key, func(l *list) {
+		length = l.len()
+	})
+
+	return length, err
+}
+
+// LRange returns the elements between inclusive start and stop indexes,
+// negative indexes count from the end of the list.
+func (s *InMemoryStorage) LRange(key string, start, stop int) ([]string, error) {
+	var values []string
+	err := readCollection(s, key, func(l *list) {
+		if start, stop, ok := normalizeRange(start, stop, l.len()); ok {
+			values = append(values, l.elements()[start:stop+1]...)
+		}
+	})
+
+	return values, err
+}
+
+func (s *InMemoryStorage) LIndex(key string, index int) (string, bool, error) {
+	var (
+		value string
+		found bool
+	)
+	err := readCollection(s, key, func(l *list) {
+		if index < 0 {
+			index += l.len()
+		}
+		if index >= 0 && index < l.len() {
+			value, found = l.elements()[index], true
+		}
+	})
+
+	return value, found, err
+}
+
+// LRem removes count occurrences of value starting from the head of the
+// list, or from the tail when count is negative, and all of them when count
+// is zero. It returns the number of removed elements.
+func (s *InMemoryStorage) LRem(key string, count int, value string) (int, error) {
+	var removed int
+	err := updateCollection(s, key, nil, func(l *list) error {
+		elements := l.elements()
+		keep := make([]bool, len(elements))
+		limit := count
+		if limit < 0 {
+			limit = -limit
+		}
+
+		for i := range elements {
+			index := i
+			if count < 0 {
+				index = len(elements) - 1 - i
+			}
+			if elements[index] == value && (limit == 0 || removed < limit) {
+				removed++
+
+				continue
+			}
+			keep[index] = true
+		}
+		if removed == 0 {
+			return nil
+		}
+
+		items := make([]string, 0, len(elements)-removed)
+		for i, element := range elements {
+			if keep[i] {
+				items = append(items, element)
+			}
+		}
+		l.replace(items)
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// LTrim leaves only the elements between inclusive start and stop indexes.
+func (s *InMemoryStorage) LTrim(key string, start, stop int) error {
+	return updateCollection(s, key, nil, func(l *list) error {
+		start, stop, ok := normalizeRange(start, stop, l.len())
+		if !ok {
+			l.replace(nil)
+
+			return nil
+		}
+		if start == 0 && stop == l.len()-1 {
+			return nil
+		}
+		l.replace(append([]string(nil), l.elements()[start:stop+1]...))
+
+		return nil
+	})
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// manifestMagic identifies the manifest format.
+	manifestMagic    = "UMLSM001"
+	manifestFile     = "MANIFEST"
+	manifestTempFile = "MANIFEST.tmp"
+	tableSuffix      = ".sst"
+)
+
+var errCorruptedManifest = errors.New("manifest is corrupted")
+
+// manifest lists the live tables of the disk engine. It is rewritten as a
+// whole and renamed into place on every change, so a crash leaves either
+// the old or the new list. Table files missing from it are leftovers of an
+// interrupted flush or compaction.
+type manifest struct {
+	// nextFile is the id of the next table file.
+	nextFile uint64
+	// walSegment is the last log segment already written to the tables.
+	walSegment int
+	tables     []tableMeta
+}
+
+// tableMeta describes a table file of a level, level 0 tables are written
+// from memtables and may overlap, level 1 tables are written by compaction
+// and hold disjoint key ranges.
+type tableMeta struct {
+	id       uint64
+	level    int
+	smallest string
+	largest  string
+	entries  uint64
+}
+
+// readManifest reads the manifest of the directory, a missing one is empty.
+func readManifest(directory string) (manifest, error) {
+	data, err := os.ReadFile(filepath.Join(directory, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest{nextFile: 1}, nil
+	}
+	if err != nil {
+		return manifest{}, fmt.Errorf("read manifest error: %w", err)
+	}
+	if len(data) < len(manifestMagic)+4 || string(data[:len(manifestMagic)]) != manifestMagic {
+		return manifest{}, fmt.Errorf("%w: unknown format", errCorruptedManifest)
+	}
+
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return manifest{}, fmt.Errorf("%w: checksum mismatch", errCorruptedManifest)
+	}
+
+	m, err := decodeManifest(bufio.NewReader(bytes.NewReader(body[len(manifestMagic):])))
+	if err != nil {
+		return manifest{}, fmt.Errorf("%w: %s", errCorruptedManifest, err.Error())
+	}
+
+	return m, nil
+}
+
+func decodeManifest(r *bufio.Reader) (manifest, error) {
+	var (
+		m   manifest
+		err error
+	)
+	if m.nextFile, err = binary.ReadUvarint(r); err != nil {
+		return manifest{}, err
+	}
+	walSegment, err := binary.ReadUvarint(r)
+	if err != nil {
+		return manifest{}, err
+	}
+	m.walSegment = int(walSegment)
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return manifest{}, err
+	}
+	for i := uint64(0); i < count; i++ {
+		var meta tableMeta
+		if meta.id, err = binary.ReadUvarint(r); err != nil {
+			return manifest{}, unexpectedEOF(err)
+		}
+		level, err := binary.ReadUvarint(r)
+		if err != nil {
+			return manifest{}, unexpectedEOF(err)
+		}
+		meta.level = int(level)
+		if meta.smallest, err = readString(r); err != nil {
+			return manifest{}, unexpectedEOF(err)
+		}
+		if meta.largest, err = readString(r); err != nil {
+			return manifest{}, unexpectedEOF(err)
+		}
+		if meta.entries, err = binary.ReadUvarint(r); err != nil {
+			return manifest{}, unexpectedEOF(err)
+		}
+		m.tables = append(m.tables, meta)
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return manifest{}, errors.New("unexpected trailing data")
+	}
+
+	return m, nil
+}
+
+// write replaces the manifest of the directory.
+func (m manifest) write(directory string) error {
+	data := []byte(manifestMagic)
+	data = binary.AppendUvarint(data, m.nextFile)
+	data = binary.AppendUvarint(data, uint64(m.walSegment))
+	data = binary.AppendUvarint(data, uint64(len(m.tables)))
+	for _, meta := range m.tables {
+		data = binary.AppendUvarint(data, meta.id)
+		data = binary.AppendUvarint(data, uint64(meta.level))
+		data = appendString(data, meta.smallest)
+		data = appendString(data, meta.largest)
+		data = binary.AppendUvarint(data, meta.entries)
+	}
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+
+	path := filepath.Join(directory, manifestTempFile)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create manifest error: %w", err)
+	}
+	defer os.Remove(path)
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("write manifest error: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync manifest error: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close manifest error: %w", err)
+	}
+	if err := os.Rename(path, filepath.Join(directory, manifestFile)); err != nil {
+		return fmt.Errorf("rename manifest error: %w", err)
+	}
+
+	return syncDirectory(directory)
+}
+
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return fmt.Errorf("open directory error: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("sync directory error: %w", err)
+	}
+
+	return nil
+}
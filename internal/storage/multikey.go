@@ -0,0 +1,103 @@
+package storage
+
+import "time"
+
+// MGet returns the string values of the keys. Missing keys and keys holding
+// values of other types are reported as not found. The values are read
+// while the shards of all keys are locked, so they are consistent with
+// concurrent multi-key writes.
+func (s *InMemoryStorage) MGet(keys []string) ([]string, []bool) {
+	unlock := s.lockKeys(keys, true)
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	values := make([]string, len(keys))
+	found := make([]bool, len(keys))
+	for i, key := range keys {
+		e, ok := s.shard(key).alive(key, now)
+		if !ok {
+			continue
+		}
+
+		if values[i], found[i] = stringValue(e.value); found[i] {
+			e.touch(now)
+		}
+	}
+
+	return values, found
+}
+
+// MSet stores the values of key value pairs at once, the keys lose their
+// time to live like on Set. A key repeated in pairs gets its last value.
+func (s *InMemoryStorage) MSet(pairs []string) {
+	values := s.encodePairs(pairs)
+	unlock := s.lockKeys(pairKeys(pairs), true)
+	defer unlock()
+
+	s.setPairs(pairs, values)
+}
+
+// MSetNX stores the values of key value pairs only when none of the keys
+// exists, either all of the keys are set or none of them.
+func (s *InMemoryStorage) MSetNX(pairs []string) bool {
+	keys := pairKeys(pairs)
+	values := s.encodePairs(pairs)
+	unlock := s.lockKeys(keys, true)
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	for _, key := range keys {
+		if _, found := s.shard(key).alive(key, now); found {
+			return false
+		}
+	}
+	s.setPairs(pairs, values)
+
+	return true
+}
+
+// MDelete removes the keys at once and reports which of them existed. A key
+// repeated in keys is reported as removed only once.
+func (s *InMemoryStorage) MDelete(keys []string) []bool {
+	unlock := s.lockKeys(keys, true)
+	defer unlock()
+
+	now := time.Now().UnixNano()
+	deleted := make([]bool, len(keys))
+	for i, key := range keys {
+		sh := s.shard(key)
+		if _, found := sh.alive(key, now); found {
+			sh.delete(key)
+			deleted[i] = true
+		}
+	}
+
+	return deleted
+}
+
+// setPairs stores the keys of pairs with the encoded values, the shards of
+// all keys must be locked.
+func (s *InMemoryStorage) setPairs(pairs []string, values []any) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		s.shard(pairs[i]).set(pairs[i], &entry{value: values[i/2]})
+	}
+}
+
+// encodePairs encodes the values of pairs before the shards are locked.
+func (s *InMemoryStorage) encodePairs(pairs []string) []any {
+	values := make([]any, 0, len(pairs)/2)
+	for i := 1; i < len(pairs); i += 2 {
+		values = append(values, s.encode(pairs[i]))
+	}
+
+	return values
+}
+
+func pairKeys(pairs []string) []string {
+	keys := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i])
+	}
+
+	return keys
+}
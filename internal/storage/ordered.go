@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"strings"
+	"time"
+	"umemory/internal"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	Register(OrderedEngine, func(config internal.Config, _ *zap.Logger) (Engine, error) {
+		if err := checkEvictionPolicy(config.Engine.EvictionPolicy); err != nil {
+			return nil, err
+		}
+		if err := checkCompression(config.Engine.Compression); err != nil {
+			return nil, err
+		}
+
+		return NewOrderedStorage(config), nil
+	})
+}
+
+// KeyValue is a key found by a range query. Value is set only for keys
+// holding strings.
+type KeyValue struct {
+	Key   string
+	Type  ValueType
+	Value string
+}
+
+// OrderedStorage is the in-memory storage keeping its keys in lexical
+// order in a skiplist next to the hash map, so it serves range and prefix
+// queries. Keys are sorted across the whole keyspace, so the storage has a
+// single shard.
+type OrderedStorage struct {
+	*InMemoryStorage
+}
+
+func NewOrderedStorage(config internal.Config) *OrderedStorage {
+	config.Engine.ShardsCount = 1
+	storage := NewInMemoryStorage(config)
+	storage.shards[0].sorted = newSkiplist()
+
+	return &OrderedStorage{InMemoryStorage: storage}
+}
+
+func (s *OrderedStorage) Capabilities() Capabilities {
+	return Capabilities{Ordered: true, TTL: true}
+}
+
+// Range returns up to limit keys between inclusive start and end in lexical
+// order, or in reverse order when reverse is true. An empty end means no
+// upper bound and zero limit means no limit.
+func (s *OrderedStorage) Range(start, end string, limit int, reverse bool) []KeyValue {
+	sh := s.shards[0]
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	inRange := func(key string) bool {
+		return key >= start && (end == "" || key <= end)
+	}
+
+	var x *skiplistNode
+	switch {
+	case !reverse:
+		x = sh.sorted.firstFrom(0, start)
+	case end == "":
+		x = sh.sorted.tail
+	default:
+		x = sh.sorted.lastUpTo(0, end)
+	}
+
+	var result []KeyValue
+	now := time.Now().UnixNano()
+	for ; x != nil && inRange(x.member) && (limit == 0 || len(result) < limit); x = x.next(reverse) {
+		result = appendKeyValue(result, x.member, sh.data[x.member], now)
+	}
+
+	return result
+}
+
+// Prefix returns up to limit keys starting with prefix in lexical order,
+// zero limit means no limit.
+func (s *OrderedStorage) Prefix(prefix string, limit int) []KeyValue {
+	sh := s.shards[0]
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	var result []KeyValue
+	now := time.Now().UnixNano()
+	x := sh.sorted.firstFrom(0, prefix)
+	for ; x != nil && strings.HasPrefix(x.member, prefix) && (limit == 0 || len(result) < limit); x = x.next(false) {
+		result = appendKeyValue(result, x.member, sh.data[x.member], now)
+	}
+
+	return result
+}
+
+// appendKeyValue appends the key unless it is expired.
+func appendKeyValue(result []KeyValue, key string, e *entry, now int64) []KeyValue {
+	if e.expired(now) {
+		return result
+	}
+
+	keyValue := KeyValue{Key: key, Type: valueType(e.value)}
+	if value, ok := stringValue(e.value); ok {
+		keyValue.Value = value
+	}
+
+	return append(result, keyValue)
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"umemory/internal"
+
+	"go.uber.org/zap"
+)
+
+// Capabilities describes optional features of a storage engine.
+type Capabilities struct {
+	// Persistent engines keep data on disk by themselves and do not need
+	// the write-ahead log or snapshots to survive a restart.
+	Persistent bool
+	// Ordered engines can iterate keys in lexical order.
+	Ordered bool
+	// TTL engines support keys with expiration.
+	TTL bool
+}
+
+// Engine is a storage engine selected by engine.engine_type.
+type Engine interface {
+	Get(key string) (string, bool)
+	Set(key string, value string)
+	Delete(key string)
+	Capabilities() Capabilities
+}
+
+// Runner is implemented by engines doing background work, like removing
+// expired keys. Run blocks until ctx is done.
+type Runner interface {
+	Run(ctx context.Context)
+}
+
+type Factory func(config internal.Config, logger *zap.Logger) (Engine, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an engine available by name, it panics if the name is
+// already taken.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("storage engine %q is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Engines returns sorted names of the registered engines.
+func Engines() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewEngine creates the engine configured by engine.engine_type.
+func NewEngine(config internal.Config, logger *zap.Logger) (Engine, error) {
+	registryMu.RLock()
+	factory, found := registry[config.Engine.EngineType]
+	registryMu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf(
+			"unknown storage engine %q, available engines: %s",
+			config.Engine.EngineType,
+			strings.Join(Engines(), ", "),
+		)
+	}
+
+	return factory(config, logger)
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"time"
+	"umemory/internal/glob"
+)
+
+// Scan returns a page of keys matching the glob-style pattern, an empty
+// pattern matches all keys, and the cursor of the next page, which is zero
+// when the scan is complete. Count is the number of keys to visit, keys
+// not matching the pattern are visited too, so a page may be empty before
+// the scan is complete.
+//
+// Keys are visited shard by shard in the order of their hashes and only
+// one shard is locked at a time. The order does not depend on the other
+// keys, so a key present during the whole scan is returned regardless of
+// the keys added or removed between the pages.
+func (s *InMemoryStorage) Scan(cursor uint64, pattern string, count int) ([]string, uint64) {
+	var (
+		keys    []string
+		visited int
+	)
+
+	// the cursor holds the shard index and the lowest hash to visit
+	index, from := int(cursor>>32), uint32(cursor)
+	now := time.Now().UnixNano()
+	for index < len(s.shards) && visited < count {
+		page, next, done := s.shards[index].scan(from, count-visited, now)
+		visited += len(page)
+		for _, key := range page {
+			if pattern == "" || glob.Match(pattern, key) {
+				keys = append(keys, key)
+			}
+		}
+		if !done {
+			return keys, uint64(index)<<32 | uint64(next)
+		}
+
+		index, from = index+1, 0
+	}
+	if index >= len(s.shards) {
+		return keys, 0
+	}
+
+	return keys, uint64(index) << 32
+}
+
+// scan returns at least count keys of the shard with hashes from the given
+// one in the order of hashes, or all of them when there are fewer. Keys of
+// the same hash are returned together, so the next page can start from
+// the next hash. It returns false when some keys are left.
+//
+// Only the page is sorted: the first pass selects the count smallest
+// hashes with a bounded heap, the second one collects the keys up to the
+// largest of them.
+func (sh *shard) scan(from uint32, count int, now int64) ([]string, uint32, bool) {
+	type hashedKey struct {
+		key  string
+		hash uint32
+	}
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	var (
+		smallest   hashHeap
+		candidates int
+	)
+	for key, e := range sh.data {
+		hash := keyHash(key)
+		if hash < from || e.expired(now) {
+			continue
+		}
+
+		candidates++
+		if len(smallest) < count {
+			heap.Push(&smallest, hash)
+		} else if hash < smallest[0] {
+			smallest[0] = hash
+			heap.Fix(&smallest, 0)
+		}
+	}
+	if candidates == 0 {
+		return nil, 0, true
+	}
+
+	last := smallest[0]
+	page := make([]hashedKey, 0, len(smallest))
+	for key, e := range sh.data {
+		if hash := keyHash(key); hash >= from && hash <= last && !e.expired(now) {
+			page = append(page, hashedKey{key: key, hash: hash})
+		}
+	}
+
+	sort.Slice(page, func(i, j int) bool {
+		if page[i].hash != page[j].hash {
+			return page[i].hash < page[j].hash
+		}
+
+		return page[i].key < page[j].key
+	})
+
+	keys := make([]string, 0, len(page))
+	for _, candidate := range page {
+		keys = append(keys, candidate.key)
+	}
+	if len(keys) == candidates || last == math.MaxUint32 {
+		return keys, 0, true
+	}
+
+	return keys, last + 1, false
+}
+
+// hashHeap is a max-heap of key hashes.
+type hashHeap []uint32
+
+func (h hashHeap) Len() int           { return len(h) }
+func (h hashHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h hashHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *hashHeap) Push(x any) {
+	*h = append(*h, x.(uint32))
+}
+
+func (h *hashHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return x
+}
+
+// Keys returns the sorted keys matching the glob-style pattern. Shards are
+// locked one at a time, so the result is not a point-in-time view of the
+// keyspace.
+func (s *InMemoryStorage) Keys(pattern string) []string {
+	var keys []string
+	now := time.Now().UnixNano()
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key, e := range sh.data {
+			if !e.expired(now) && glob.Match(pattern, key) {
+				keys = append(keys, key)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// DBSize returns the number of keys, including expired keys which are not
+// removed yet.
+func (s *InMemoryStorage) DBSize() int {
+	var size int
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		size += len(sh.data)
+		sh.mu.RUnlock()
+	}
+
+	return size
+}
@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// setMemberOverhead approximates the memory held by a set member besides
+// its bytes.
+const setMemberOverhead = 16
+
+type set struct {
+	members map[string]struct{}
+	// bytes is the approximate memory held by the members.
+	bytes int64
+}
+
+func newSet() *set {
+	return &set{members: make(map[string]struct{})}
+}
+
+func (st *set) len() int {
+	return len(st.members)
+}
+
+func (st *set) size() int64 {
+	return st.bytes
+}
+
+func (st *set) add(member string) bool {
+	if _, found := st.members[member]; found {
+		return false
+	}
+
+	st.members[member] = struct{}{}
+	st.bytes += int64(len(member)) + setMemberOverhead
+
+	return true
+}
+
+func (st *set) remove(member string) bool {
+	if _, found := st.members[member]; !found {
+		return false
+	}
+
+	delete(st.members, member)
+	st.bytes -= int64(len(member)) + setMemberOverhead
+
+	return true
+}
+
+func (st *set) has(member string) bool {
+	_, found := st.members[member]
+
+	return found
+}
+
+// sortedMembers returns the members in lexical order, so replies do not
+// depend on the map iteration order.
+func (st *set) sortedMembers() []string {
+	members := make([]string, 0, len(st.members))
+	for member := range st.members {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	return members
+}
+
+// random returns count distinct random members, or when count is negative
+// -count members which may repeat.
+func (st *set) random(count int) []string {
+	if count >= 0 {
+		// map iteration starts at a random position
+		members := make([]string, 0, min(count, st.len()))
+		for member := range st.members {
+			if len(members) == count {
+				break
+			}
+			members = append(members, member)
+		}
+
+		return members
+	}
+
+	all := make([]string, 0, st.len())
+	for member := range st.members {
+		all = append(all, member)
+	}
+
+	members := make([]string, 0, -count)
+	for len(all) > 0 && len(members) < -count {
+		members = append(members, all[rand.Intn(len(all))])
+	}
+
+	return members
+}
+
+// SAdd adds the members and returns the number of added ones.
+func (s *InMemoryStorage) SAdd(key string, members []string) (int, error) {
+	var added int
+	err := updateCollection(s, key, newSet, func(st *set) error {
+		for _, member := range members {
+			if st.add(member) {
+				added++
+			}
+		}
+
+		return nil
+	})
+
+	return added, err
+}
+
+// SRem removes the members and returns the number of removed ones.
+func (s *InMemoryStorage) SRem(key string, members []string) (int, error) {
+	var removed int
+	err := updateCollection(s, key, nil, func(st *set) error {
+		for _, member := range members {
+			if st.remove(member) {
+				removed++
+			}
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// SMembers returns the members in lexical order.
+func (s *InMemoryStorage) SMembers(key string) ([]string, error) {
+	var members []string
+	err := readCollection(s, key, func(st *set) {
+		members = st.sortedMembers()
+	})
+
+	return members, err
+}
+
+func (s *InMemoryStorage) SIsMember(key, member string) (bool, error) {
+	var found bool
+	err := readCollection(s, key, func(st *set) {
+		found = st.has(member)
+	})
+
+	return found, err
+}
+
+func (s *InMemoryStorage) SCard(key string) (int, error) {
+	var length int
+	err := readCollection(s, key, func(st *set) {
+		length = st.len()
+	})
+
+	return length, err
+}
+
+// SPop removes and returns up to count random members.
+func (s *InMemoryStorage) SPop(key string, count int) ([]string, error) {
+	var members []string
+	err := updateCollection(s, key, nil, func(st *set) error {
+		members = st.random(max(count, 0))
+		for _, member := range members {
+			st.remove(member)
+		}
+
+		return nil
+	})
+
+	return members, err
+}
+
+// SRandMember returns up to count distinct random members, or -count
+// members which may repeat when count is negative.
+func (s *InMemoryStorage) SRandMember(key string, count int) ([]string, error) {
+	var members []string
+	err := readCollection(s, key, func(st *set) {
+		members = st.random(count)
+	})
+
+	return members, err
+}
+
+// SInter returns the members present in all sets.
+func (s *InMemoryStorage) SInter(keys []string) ([]string, error) {
+	return s.combineSets(keys, intersect)
+}
+
+// SUnion returns the members present in any of the sets.
+func (s *InMemoryStorage) SUnion(keys []string) ([]string, error) {
+	return s.combineSets(keys, union)
+}
+
+// SDiff returns the members of the first set not present in the others.
+func (s *InMemoryStorage) SDiff(keys []string) ([]string, error) {
+	return s.combineSets(keys, difference)
+}
+
+// SInterStore stores the intersection of the sets by destination and
+// returns its size.
+func (s *InMemoryStorage) SInterStore(destination string, keys []string) (int, error) {
+	return s.storeSets(destination, keys, intersect)
+}
+
+func (s *InMemoryStorage) SUnionStore(destination string, keys []string) (int, error) {
+	return s.storeSets(destination, keys, union)
+}
+
+func (s *InMemoryStorage) SDiffStore(destination string, keys []string) (int, error) {
+	return s.storeSets(destination, keys, difference)
+}
+
+type setOperation func(sets []*set) *set
+
+// combineSets applies the operation to the sets while the shards of all
+// keys are locked, so the result is consistent with concurrent writes.
+func (s *InMemoryStorage) combineSets(keys []string, operation setOperation) ([]string, error) {
+	unlock := s.lockKeys(keys, false)
+	defer unlock()
+
+	sets, err := s.lockedSets(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return operation(sets).sortedMembers(), nil
+}
+
+// storeSets replaces destination with the result of the operation, an
+// empty result removes destination.
+func (s *InMemoryStorage) storeSets(destination string, keys []string, operation setOperation) (int, error) {
+	unlock := s.lockKeys(append([]string{destination}, keys...), true)
+	defer unlock()
+
+	sets, err := s.lockedSets(keys)
+	if err != nil {
+		return 0, err
+	}
+
+	result := operation(sets)
+	sh := s.shard(destination)
+	if result.len() == 0 {
+		sh.delete(destination)
+	} else {
+		sh.set(destination, &entry{value: result})
+	}
+
+	return result.len(), nil
+}
+
+// lockedSets returns the sets stored by keys, nil for missing keys. The
+// shards of the keys must be locked.
+func (s *InMemoryStorage) lockedSets(keys []string) ([]*set, error) {
+	now := time.Now().UnixNano()
+	sets := make([]*set, len(keys))
+	for i, key := range keys {
+		e, found := s.shard(key).data[key]
+		if !found || e.expired(now) {
+			continue
+		}
+
+		st, ok := e.value.(*set)
+		if !ok {
+			return nil, ErrWrongType
+		}
+		e.touch(now)
+		sets[i] = st
+	}
+
+	return sets, nil
+}
+
+func intersect(sets []*set) *set {
+	result := newSet()
+	for _, st := range sets {
+		if st == nil {
+			return result
+		}
+	}
+
+	for member := range sets[0].members {
+		inAll := true
+		for _, st := range sets[1:] {
+			if !st.has(member) {
+				inAll = false
+
+				break
+			}
+		}
+		if inAll {
+			result.add(member)
+		}
+	}
+
+	return result
+}
+
+func union(sets []*set) *set {
+	result := newSet()
+	for _, st := range sets {
+		if st == nil {
+			continue
+		}
+		for member := range st.members {
+			result.add(member)
+		}
+	}
+
+	return result
+}
+
+func difference(sets []*set) *set {
+	result := newSet()
+	if sets[0] == nil {
+		return result
+	}
+
+	for member := range sets[0].members {
+		inOther := false
+		for _, st := range sets[1:] {
+			if st != nil && st.has(member) {
+				inOther = true
+
+				break
+			}
+		}
+		if !inOther {
+			result.add(member)
+		}
+	}
+
+	return result
+}
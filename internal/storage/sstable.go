@@ -0,0 +1,469 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	// tableMagic identifies the table format.
+	tableMagic      = "UMSST001"
+	tableFooterSize = 4*8 + len(tableMagic)
+	blockTrailer    = 4
+
+	valueKind     byte = 0
+	tombstoneKind byte = 1
+)
+
+var errCorruptedTable = errors.New("table is corrupted")
+
+// diskValue is a value of the disk engine, a deleted key is kept as a
+// tombstone until compaction reaches the last level.
+type diskValue struct {
+	value   string
+	deleted bool
+}
+
+// A table is an immutable file of keys in sorted order:
+//
+//	data block...  entries, each block followed by its crc32
+//	index block    first key, offset and length of every data block
+//	bloom block    the bloom filter of the table keys
+//	footer         offsets and lengths of the index and bloom blocks, magic
+//
+// An entry is the kind byte, the length prefixed key and, unless it is a
+// tombstone, the length prefixed value. The index and the bloom filter are
+// kept in memory, a lookup reads a single data block.
+type table struct {
+	meta   tableMeta
+	file   *os.File
+	index  []blockHandle
+	filter *bloomFilter
+
+	// refs counts the table list holding the table and the readers using
+	// it, the file is removed once an obsolete table is released by all.
+	refs     atomic.Int32
+	obsolete atomic.Bool
+}
+
+type blockHandle struct {
+	firstKey string
+	offset   uint64
+	length   uint64
+}
+
+// tableWriter writes a table file, the keys must be added in order.
+type tableWriter struct {
+	meta      tableMeta
+	path      string
+	file      *os.File
+	writer    *bufio.Writer
+	offset    uint64
+	blockSize int
+
+	block  []byte
+	index  []blockHandle
+	hashes []uint64
+}
+
+func tablePath(directory string, id uint64) string {
+	return filepath.Join(directory, fmt.Sprintf("%06d%s", id, tableSuffix))
+}
+
+func createTable(directory string, meta tableMeta, blockSize int) (*tableWriter, error) {
+	path := tablePath(directory, meta.id)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("create table error: %w", err)
+	}
+
+	return &tableWriter{
+		meta:      meta,
+		path:      path,
+		file:      file,
+		writer:    bufio.NewWriter(file),
+		blockSize: blockSize,
+	}, nil
+}
+
+func (w *tableWriter) add(key string, value diskValue) error {
+	if len(w.block) == 0 {
+		w.index = append(w.index, blockHandle{firstKey: key, offset: w.offset})
+	}
+	if w.meta.entries == 0 {
+		w.meta.smallest = key
+	}
+	w.meta.largest = key
+	w.meta.entries++
+	w.hashes = append(w.hashes, bloomHash(key))
+
+	if value.deleted {
+		w.block = append(w.block, tombstoneKind)
+		w.block = appendString(w.block, key)
+	} else {
+		w.block = append(w.block, valueKind)
+		w.block = appendString(w.block, key)
+		w.block = appendString(w.block, value.value)
+	}
+
+	if len(w.block) >= w.blockSize {
+		return w.finishBlock()
+	}
+
+	return nil
+}
+
+// size returns the number of bytes written so far.
+func (w *tableWriter) size() uint64 {
+	return w.offset + uint64(len(w.block))
+}
+
+func (w *tableWriter) finishBlock() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+
+	w.index[len(w.index)-1].length = uint64(len(w.block))
+	if err := w.writeBlock(w.block); err != nil {
+		return err
+	}
+	w.block = w.block[:0]
+
+	return nil
+}
+
+func (w *tableWriter) writeBlock(block []byte) error {
+	block = binary.LittleEndian.AppendUint32(block, crc32.ChecksumIEEE(block))
+	if _, err := w.writer.Write(block); err != nil {
+		return fmt.Errorf("write table error: %w", err)
+	}
+	w.offset += uint64(len(block))
+
+	return nil
+}
+
+// finish writes the index, the bloom filter and the footer and syncs the
+// file.
+func (w *tableWriter) finish() (tableMeta, error) {
+	if err := w.finishBlock(); err != nil {
+		return tableMeta{}, err
+	}
+
+	var index []byte
+	for _, handle := range w.index {
+		index = appendString(index, handle.firstKey)
+		index = binary.AppendUvarint(index, handle.offset)
+		index = binary.AppendUvarint(index, handle.length)
+	}
+	indexOffset := w.offset
+	if err := w.writeBlock(index); err != nil {
+		return tableMeta{}, err
+	}
+
+	filter := newBloomFilter(len(w.hashes))
+	for _, hash := range w.hashes {
+		filter.add(hash)
+	}
+	bloom := filter.encode()
+	bloomOffset := w.offset
+	if err := w.writeBlock(bloom); err != nil {
+		return tableMeta{}, err
+	}
+
+	footer := make([]byte, 0, tableFooterSize)
+	footer = binary.LittleEndian.AppendUint64(footer, indexOffset)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(len(index)))
+	footer = binary.LittleEndian.AppendUint64(footer, bloomOffset)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(len(bloom)))
+	footer = append(footer, tableMagic...)
+	if _, err := w.writer.Write(footer); err != nil {
+		return tableMeta{}, fmt.Errorf("write table error: %w", err)
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return tableMeta{}, fmt.Errorf("write table error: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return tableMeta{}, fmt.Errorf("sync table error: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return tableMeta{}, fmt.Errorf("close table error: %w", err)
+	}
+
+	return w.meta, nil
+}
+
+// abort closes and removes the unfinished table.
+func (w *tableWriter) abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.path)
+}
+
+func openTable(directory string, meta tableMeta) (*table, error) {
+	file, err := os.Open(tablePath(directory, meta.id))
+	if err != nil {
+		return nil, fmt.Errorf("open table error: %w", err)
+	}
+
+	t := &table{meta: meta, file: file}
+	if err := t.load(); err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("table %d: %w", meta.id, err)
+	}
+	t.refs.Store(1)
+
+	return t, nil
+}
+
+// load reads the footer, the index and the bloom filter.
+func (t *table) load() error {
+	info, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < int64(tableFooterSize) {
+		return fmt.Errorf("%w: file is too short", errCorruptedTable)
+	}
+
+	footer := make([]byte, tableFooterSize)
+	if _, err := t.file.ReadAt(footer, info.Size()-int64(tableFooterSize)); err != nil {
+		return err
+	}
+	if string(footer[4*8:]) != tableMagic {
+		return fmt.Errorf("%w: unknown format", errCorruptedTable)
+	}
+
+	index, err := t.readBlock(blockHandle{
+		offset: binary.LittleEndian.Uint64(footer[0:]),
+		length: binary.LittleEndian.Uint64(footer[8:]),
+	})
+	if err != nil {
+		return err
+	}
+	for len(index) > 0 {
+		var handle blockHandle
+		if handle.firstKey, index, err = decodeString(index); err != nil {
+			return err
+		}
+		if handle.offset, index, err = decodeUvarint(index); err != nil {
+			return err
+		}
+		if handle.length, index, err = decodeUvarint(index); err != nil {
+			return err
+		}
+		t.index = append(t.index, handle)
+	}
+
+	bloom, err := t.readBlock(blockHandle{
+		offset: binary.LittleEndian.Uint64(footer[16:]),
+		length: binary.LittleEndian.Uint64(footer[24:]),
+	})
+	if err != nil {
+		return err
+	}
+	if t.filter, err = decodeBloomFilter(bloom); err != nil {
+		return fmt.Errorf("%w: %s", errCorruptedTable, err.Error())
+	}
+
+	return nil
+}
+
+// readBlock reads the block and checks its crc32.
+func (t *table) readBlock(handle blockHandle) ([]byte, error) {
+	if handle.length > maxStringLength {
+		return nil, fmt.Errorf("%w: block length %d is too big", errCorruptedTable, handle.length)
+	}
+
+	data := make([]byte, handle.length+blockTrailer)
+	if _, err := t.file.ReadAt(data, int64(handle.offset)); err != nil {
+		return nil, fmt.Errorf("read table block error: %w", err)
+	}
+
+	block := data[:handle.length]
+	if crc32.ChecksumIEEE(block) != binary.LittleEndian.Uint32(data[handle.length:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch at offset %d", errCorruptedTable, handle.offset)
+	}
+
+	return block, nil
+}
+
+// get looks the key up, found is true for tombstones too.
+func (t *table) get(key string) (diskValue, bool, error) {
+	if key < t.meta.smallest || key > t.meta.largest || !t.filter.mayContain(key) {
+		return diskValue{}, false, nil
+	}
+
+	// the last block starting at or before the key
+	i := sort.Search(len(t.index), func(i int) bool {
+		return t.index[i].firstKey > key
+	}) - 1
+	if i < 0 {
+		return diskValue{}, false, nil
+	}
+
+	block, err := t.readBlock(t.index[i])
+	if err != nil {
+		return diskValue{}, false, err
+	}
+	for len(block) > 0 {
+		var (
+			entryKey string
+			value    diskValue
+		)
+		if entryKey, value, block, err = decodeEntry(block); err != nil {
+			return diskValue{}, false, err
+		}
+		if entryKey == key {
+			return value, true, nil
+		}
+		if entryKey > key {
+			break
+		}
+	}
+
+	return diskValue{}, false, nil
+}
+
+func (t *table) acquire() {
+	t.refs.Add(1)
+}
+
+// release drops a reference, the file of an obsolete table is removed with
+// the last one.
+func (t *table) release() {
+	if t.refs.Add(-1) > 0 {
+		return
+	}
+
+	_ = t.file.Close()
+	if t.obsolete.Load() {
+		_ = os.Remove(t.file.Name())
+	}
+}
+
+// tableIterator reads the table entries in order.
+type tableIterator struct {
+	table *table
+	next  int
+	block []byte
+
+	key   string
+	value diskValue
+	err   error
+}
+
+func (t *table) iterator() *tableIterator {
+	return &tableIterator{table: t}
+}
+
+// advance moves to the following entry, it returns false at the end of the
+// table or on error.
+func (it *tableIterator) advance() bool {
+	for len(it.block) == 0 {
+		if it.err != nil || it.next == len(it.table.index) {
+			return false
+		}
+		if it.block, it.err = it.table.readBlock(it.table.index[it.next]); it.err != nil {
+			return false
+		}
+		it.next++
+	}
+
+	it.key, it.value, it.block, it.err = decodeEntry(it.block)
+
+	return it.err == nil
+}
+
+// mergeTables calls fn with the entries of the iterators in key order. A
+// key found by several iterators is passed once with the entry of the
+// first of them, so iterators go from the newest table.
+func mergeTables(iterators []*tableIterator, fn func(key string, value diskValue) error) error {
+	active := make([]*tableIterator, 0, len(iterators))
+	for _, it := range iterators {
+		if it.advance() {
+			active = append(active, it)
+		} else if it.err != nil {
+			return it.err
+		}
+	}
+
+	for len(active) > 0 {
+		current := active[0]
+		for _, it := range active[1:] {
+			if it.key < current.key {
+				current = it
+			}
+		}
+		key, value := current.key, current.value
+		if err := fn(key, value); err != nil {
+			return err
+		}
+
+		remaining := active[:0]
+		for _, it := range active {
+			if it.key != key || it.advance() {
+				remaining = append(remaining, it)
+			} else if it.err != nil {
+				return it.err
+			}
+		}
+		active = remaining
+	}
+
+	return nil
+}
+
+func decodeEntry(data []byte) (string, diskValue, []byte, error) {
+	if len(data) == 0 {
+		return "", diskValue{}, nil, errCorruptedTable
+	}
+
+	kind := data[0]
+	key, data, err := decodeString(data[1:])
+	if err != nil {
+		return "", diskValue{}, nil, err
+	}
+
+	switch kind {
+	case tombstoneKind:
+		return key, diskValue{deleted: true}, data, nil
+	case valueKind:
+		value, data, err := decodeString(data)
+		if err != nil {
+			return "", diskValue{}, nil, err
+		}
+
+		return key, diskValue{value: value}, data, nil
+	default:
+		return "", diskValue{}, nil, fmt.Errorf("%w: unknown entry kind %d", errCorruptedTable, kind)
+	}
+}
+
+func decodeUvarint(data []byte) (uint64, []byte, error) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: invalid varint", errCorruptedTable)
+	}
+
+	return value, data[n:], nil
+}
+
+func decodeString(data []byte) (string, []byte, error) {
+	length, data, err := decodeUvarint(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if length > uint64(len(data)) {
+		return "", nil, fmt.Errorf("%w: string length %d is out of block", errCorruptedTable, length)
+	}
+
+	return string(data[:length]), data[length:], nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ValueType is the type of a value stored by a key.
+type ValueType byte
+
+const (
+	StringType ValueType = iota
+	ListType
+	HashType
+	SetType
+	ZSetType
+	// IndexType tags dump records holding an index definition rather than
+	// a key, see InMemoryStorage.Dump.
+	IndexType
+)
+
+var (
+	ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	ErrOverflow  = errors.New("increment or decrement would overflow")
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case StringType:
+		return "string"
+	case ListType:
+		return "list"
+	case HashType:
+		return "hash"
+	case SetType:
+		return "set"
+	case ZSetType:
+		return "zset"
+	case IndexType:
+		return "index"
+	default:
+		return "unknown"
+	}
+}
+
+func valueType(value any) ValueType {
+	switch value.(type) {
+	case *list:
+		return ListType
+	case *hash:
+		return HashType
+	case *set:
+		return SetType
+	case *zset:
+		return ZSetType
+	default:
+		return StringType
+	}
+}
+
+// Type returns the type of the value stored by key.
+func (s *InMemoryStorage) Type(key string) (ValueType, bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e, found := sh.data[key]
+	if !found || e.expired(time.Now().UnixNano()) {
+		return 0, false
+	}
+
+	return valueType(e.value), true
+}
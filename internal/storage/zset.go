@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// zsetMemberOverhead approximates the memory held by a sorted set member
+// besides its bytes, i.e. the skiplist node and the map entry.
+const zsetMemberOverhead = 80
+
+var ErrScoreNaN = errors.New("resulting score is not a number (NaN)")
+
+// ScoredMember is a sorted set member with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// ScoreBound is a minimum or a maximum of a score range.
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+// below reports whether a score is above the bound used as a minimum.
+func (b ScoreBound) below(score float64) bool {
+	return score > b.Value || (!b.Exclusive && score == b.Value)
+}
+
+// above reports whether a score is below the bound used as a maximum.
+func (b ScoreBound) above(score float64) bool {
+	return score < b.Value || (!b.Exclusive && score == b.Value)
+}
+
+// zset is a sorted set, scores are looked up by member in the map and
+// members are ordered by the skiplist.
+type zset struct {
+	scores map[string]float64
+	list   *skiplist
+	// bytes is the approximate memory held by the members.
+	bytes int64
+}
+
+func newZSet() *zset {
+	return &zset{scores: make(map[string]float64), list: newSkiplist()}
+}
+
+func (z *zset) len() int {
+	return len(z.scores)
+}
+
+func (z *zset) size() int64 {
+	return z.bytes
+}
+
+// set stores the member score and reports whether the member is new.
+func (z *zset) set(member string, score float64) bool {
+	old, found := z.scores[member]
+	if found {
+		if old == score {
+			return false
+		}
+		z.list.delete(old, member)
+	} else {
+		z.bytes += int64(len(member)) + zsetMemberOverhead
+	}
+
+	z.scores[member] = score
+	z.list.insert(score, member)
+
+	return !found
+}
+
+func (z *zset) remove(member string) bool {
+	score, found := z.scores[member]
+	if !found {
+		return false
+	}
+
+	delete(z.scores, member)
+	z.list.delete(score, member)
+	z.bytes -= int64(len(member)) + zsetMemberOverhead
+
+	return true
+}
+
+// members returns the members in order, the scores are formatted to be
+// parsed back without losing precision.
+func (z *zset) members() []string {
+	members := make([]string, 0, 2*z.len())
+	for x := z.list.header.levels[0].forward; x != nil; x = x.levels[0].forward {
+		members = append(members, x.member, strconv.FormatFloat(x.score, 'g', -1, 64))
+	}
+
+	return members
+}
+
+// ZAdd stores the member scores and returns the number of added members.
+func (s *InMemoryStorage) ZAdd(key string, members map[string]float64) (int, error) {
+	var added int
+	err := updateCollection(s, key, newZSet, func(z *zset) error {
+		for member, score := range members {
+			if z.set(member, score) {
+				added++
+			}
+		}
+
+		return nil
+	})
+
+	return added, err
+}
+
+// ZRem removes the members and returns the number of removed ones.
+func (s *InMemoryStorage) ZRem(key string, members []string) (int, error) {
+	var removed int
+	err := updateCollection(s, key, nil, func(z *zset) error {
+		for _, member := range members {
+			if z.remove(member) {
+				removed++
+			}
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+func (s *InMemoryStorage) ZScore(key, member string) (float64, bool, error) {
+	var (
+		score float64
+		found bool
+	)
+	err := readCollection(s, key, func(z *zset) {
+		score, found = z.scores[member]
+	})
+
+	return score, found, err
+}
+
+// ZIncrBy adds increment to the member score, a missing member is added
+// with increment as its score.
+func (s *InMemoryStorage) ZIncrBy(key, member string, increment float64) (float64, error) {
+	var score float64
+	err := updateCollection(s, key, newZSet, func(z *zset) error {
+		score = z.scores[member] + increment
+		if math.IsNaN(score) {
+			return ErrScoreNaN
+		}
+		z.set(member, score)
+
+		return nil
+	})
+
+	return score, err
+}
+
+// ZRank returns the 0-based rank of the member ordered by score, from the
+// highest score when reverse is true.
+func (s *InMemoryStorage) ZRank(key, member string, reverse bool) (int, bool, error) {
+	var (
+		rank  int
+		found bool
+	)
+	err := readCollection(s, key, func(z *zset) {
+		score, ok := z.scores[member]
+		if !ok {
+			return
+		}
+
+		rank, found = z.list.rank(score, member)-1, true
+		if reverse {
+			rank = z.len() - 1 - rank
+		}
+	})
+
+	return rank, found, err
+}
+
+// ZRange returns the members between inclusive start and stop ranks,
+// negative ranks count from the end. Ranks are counted from the highest
+// score when reverse is true.
+func (s *InMemoryStorage) ZRange(key string, start, stop int, reverse bool) ([]ScoredMember, error) {
+	var members []ScoredMember
+	err := readCollection(s, key, func(z *zset) {
+		start, stop, ok := normalizeRange(start, stop, z.len())
+		if !ok {
+			return
+		}
+
+		members = make([]ScoredMember, 0, stop-start+1)
+		if reverse {
+			x := z.list.byRank(z.len() - start)
+			for ; x != nil && len(members) < stop-start+1; x = x.backward {
+				members = append(members, ScoredMember{Member: x.member, Score: x.score})
+			}
+
+			return
+		}
+
+		x := z.list.byRank(start + 1)
+		for ; x != nil && len(members) < stop-start+1; x = x.levels[0].forward {
+			members = append(members, ScoredMember{Member: x.member, Score: x.score})
+		}
+	})
+
+	return members, err
+}
+
+// ZRangeByScore returns the members with scores between min and max
+// ordered by score.
+func (s *InMemoryStorage) ZRangeByScore(key string, min, max ScoreBound) ([]ScoredMember, error) {
+	var members []ScoredMember
+	err := readCollection(s, key, func(z *zset) {
+		for x := z.list.firstInRange(min); x != nil && max.above(x.score); x = x.levels[0].forward {
+			members = append(members, ScoredMember{Member: x.member, Score: x.score})
+		}
+	})
+
+	return members, err
+}
+
+// ZCount returns the number of members with scores between min and max.
+func (s *InMemoryStorage) ZCount(key string, min, max ScoreBound) (int, error) {
+	var count int
+	err := readCollection(s, key, func(z *zset) {
+		first := z.list.firstInRange(min)
+		if first == nil || !max.above(first.score) {
+			return
+		}
+
+		last := z.list.lastInRange(max)
+		count = z.list.rank(last.score, last.member) - z.list.rank(first.score, first.member) + 1
+	})
+
+	return count, err
+}
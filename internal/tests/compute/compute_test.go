@@ -2,11 +2,18 @@ package compute
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"umemory/internal"
 	"umemory/internal/compute"
+	"umemory/internal/network"
+	"umemory/internal/storage"
 	mock_compute "umemory/internal/compute/mock"
 
 	"github.com/golang/mock/gomock"
@@ -84,7 +91,7 @@ func TestComputeHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.exec()
 			getOutputText := captureFromStdout()
-			handler.Handle(tt.requestStr)
+			handler.Handle(nil, tt.requestStr)
 			actualOutput, err := getOutputText()
 			if err != nil {
 				t.Errorf("getOutputText error: %v \nactual output: %v", err, actualOutput)
@@ -96,6 +103,347 @@ func TestComputeHandler(t *testing.T) {
 	}
 }
 
+type expirableStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockExpirableStorage
+}
+
+func TestComputeHandlerExpiration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := expirableStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockExpirableStorage: mock_compute.NewMockExpirableStorage(ctrl),
+	}
+
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	var testCases = []struct {
+		name string
+		requestStr string
+		exec func()
+		expected string
+	}{
+		{
+			name: "Handle: set value with EX",
+			requestStr: "set key value EX 30",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().SetWithTTL("key", "value", 30*time.Second)
+			},
+			expected: "saved",
+		},
+		{
+			name: "Handle: set value with PX",
+			requestStr: "set key value px 1500",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().SetWithTTL("key", "value", 1500*time.Millisecond)
+			},
+			expected: "saved",
+		},
+		{
+			name: "Handle: set value with PXAT",
+			requestStr: "set key value PXAT 32503680000000",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().SetWithTTL("key", "value", gomock.Any())
+			},
+			expected: "saved",
+		},
+		{
+			name: "Handle: expire existing key",
+			requestStr: "expire key 10",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().Expire("key", 10*time.Second).Return(true)
+			},
+			expected: "1",
+		},
+		{
+			name: "Handle: expire missing key",
+			requestStr: "expire key 10",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().Expire("key", 10*time.Second).Return(false)
+			},
+			expected: "0",
+		},
+		{
+			name: "Handle: ttl of volatile key",
+			requestStr: "ttl key",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().TTL("key").Return(9800*time.Millisecond, true)
+			},
+			expected: "10",
+		},
+		{
+			name: "Handle: pttl of volatile key",
+			requestStr: "pttl key",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().TTL("key").Return(9800*time.Millisecond, true)
+			},
+			expected: "9800",
+		},
+		{
+			name: "Handle: ttl of persistent key",
+			requestStr: "ttl key",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().TTL("key").Return(time.Duration(-1), true)
+			},
+			expected: "-1",
+		},
+		{
+			name: "Handle: ttl of missing key",
+			requestStr: "ttl key",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().TTL("key").Return(time.Duration(0), false)
+			},
+			expected: "-2",
+		},
+		{
+			name: "Handle: persist volatile key",
+			requestStr: "persist key",
+			exec: func() {
+				mockStorage.MockExpirableStorage.EXPECT().Persist("key").Return(true)
+			},
+			expected: "1",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.exec()
+			actual, err := handler.Handle(nil, tt.requestStr)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected: %v \nactual: %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestComputeHandlerExpirationNotSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := compute.NewComputeHandler(
+		mock_compute.NewMockStorage(ctrl),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	_, err := handler.Handle(nil, "ttl key")
+	if err == nil || err.Error() != "Storage engine does not support key expiration" {
+		t.Errorf("expected expiration not supported error, got: %v", err)
+	}
+}
+
+func TestComputeHandlerWAL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := expirableStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockExpirableStorage: mock_compute.NewMockExpirableStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	gomock.InOrder(
+		mockStorage.MockStorage.EXPECT().Set("key", "value"),
+		mockWAL.EXPECT().Append("set", []string{"key", "value"}).Return(nil),
+	)
+	if _, err := handler.Handle(nil, "set key value"); err != nil {
+		t.Errorf("set unexpected error: %v", err)
+	}
+
+	mockStorage.MockStorage.EXPECT().Get("key").Return("value", true)
+	if _, err := handler.Handle(nil, "get key"); err != nil {
+		t.Errorf("get unexpected error: %v", err)
+	}
+
+	mockStorage.MockExpirableStorage.EXPECT().Expire("key", 10*time.Second).Return(true)
+	mockWAL.EXPECT().Append("pexpireat", gomock.Any()).Return(nil)
+	if _, err := handler.Handle(nil, "expire key 10"); err != nil {
+		t.Errorf("expire unexpected error: %v", err)
+	}
+
+	mockStorage.MockExpirableStorage.EXPECT().Expire("missing", 10*time.Second).Return(false)
+	if _, err := handler.Handle(nil, "expire missing 10"); err != nil {
+		t.Errorf("expire unexpected error: %v", err)
+	}
+
+	mockStorage.MockStorage.EXPECT().Delete("key")
+	mockWAL.EXPECT().Append("delete", []string{"key"}).Return(errors.New("disk is full"))
+	if _, err := handler.Handle(nil, "delete key"); err == nil || err.Error() != "Write-ahead log error" {
+		t.Errorf("expected write-ahead log error, got: %v", err)
+	}
+}
+
+func TestComputeHandlerApply(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_compute.NewMockStorage(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	mockStorage.EXPECT().Set("key", "value with spaces")
+	if err := handler.Apply("set", []string{"key", "value with spaces"}); err != nil {
+		t.Errorf("apply unexpected error: %v", err)
+	}
+
+	if err := handler.Apply("set", []string{"key"}); err == nil {
+		t.Errorf("apply expected validation error")
+	}
+}
+
+func TestComputeHandlerBinary(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	value := "{\"a\": [1, 2],\n\"b\": \"\x00\x01\"}"
+	if _, err := handler.Handle(nil, string(network.EncodeRequest("set", "json key", value))); err != nil {
+		t.Fatalf("set unexpected error: %v", err)
+	}
+	if res, err := handler.Handle(nil, string(network.EncodeRequest("get", "json key"))); err != nil || res != value {
+		t.Errorf("get: expected %q, got %q (err: %v)", value, res, err)
+	}
+
+	// commands replayed from the log are binary-safe too
+	if err := handler.Apply("rpush", []string{"list", "\x00\n", "\r\n"}); err != nil {
+		t.Fatalf("apply unexpected error: %v", err)
+	}
+	if res, err := handler.Handle(nil, string(network.EncodeRequest("lindex", "list", "0"))); err != nil || res != "\x00\n" {
+		t.Errorf("lindex: expected %q, got %q (err: %v)", "\x00\n", res, err)
+	}
+
+	// plain text arguments are still restricted to the available symbols
+	if _, err := handler.Handle(nil, "set key √"); err == nil {
+		t.Errorf("set: expected unknown symbols error")
+	}
+}
+
+func TestComputeHandlerSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := compute.NewComputeHandler(
+		mock_compute.NewMockStorage(ctrl),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	if _, err := handler.Handle(nil, "save"); err == nil || err.Error() != "Snapshots are disabled" {
+		t.Errorf("expected snapshots disabled error, got: %v", err)
+	}
+
+	mockSnapshotter := mock_compute.NewMockSnapshotter(ctrl)
+	handler.SetSnapshotter(mockSnapshotter)
+
+	mockSnapshotter.EXPECT().Save().Return(nil)
+	if res, err := handler.Handle(nil, "save"); err != nil || res != "snapshot saved" {
+		t.Errorf("save: unexpected result %v, error: %v", res, err)
+	}
+
+	mockSnapshotter.EXPECT().BackgroundSave().Return(nil)
+	if res, err := handler.Handle(nil, "bgsave"); err != nil || res != "background saving started" {
+		t.Errorf("bgsave: unexpected result %v, error: %v", res, err)
+	}
+
+	mockSnapshotter.EXPECT().BackgroundSave().Return(errors.New("Background save already in progress"))
+	if _, err := handler.Handle(nil, "bgsave"); err == nil {
+		t.Errorf("bgsave: expected error")
+	}
+}
+
+func TestComputeHandlerSnapshotBlocksWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := storage.NewInMemoryStorage(internal.Config{})
+	handler := compute.NewComputeHandler(s, compute.NewRequestParser(), zap.NewNop())
+	// snapshots without the write-ahead log still see writes blocked
+	handler.SetSnapshotter(mock_compute.NewMockSnapshotter(ctrl))
+
+	written := make(chan struct{})
+	err := handler.BlockWrites(func() error {
+		go func() {
+			defer close(written)
+
+			handler.Handle(nil, "set key value")
+		}()
+
+		select {
+		case <-written:
+			t.Errorf("expected the write to wait for the blocked section")
+		case <-time.After(50 * time.Millisecond):
+		}
+		if _, found := s.Get("key"); found {
+			t.Errorf("expected no write while writes are blocked")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("BlockWrites error: %v", err)
+	}
+
+	<-written
+	if value, _ := s.Get("key"); value != "value" {
+		t.Errorf("expected the write to be applied after the blocked section, got %q", value)
+	}
+}
+
+type capableStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockCapableStorage
+}
+
+func TestComputeHandlerInfoEngine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := capableStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockCapableStorage: mock_compute.NewMockCapableStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	mockStorage.MockCapableStorage.EXPECT().Capabilities().Return(storage.Capabilities{Ordered: true})
+	res, err := handler.Handle(nil, "info engine")
+	if err != nil {
+		t.Fatalf("info: unexpected error: %v", err)
+	}
+	expected := "# engine\npersistent:false\nordered:true\nttl:false"
+	if res != expected {
+		t.Errorf("expected: %v \nactual: %v", expected, res)
+	}
+
+	if _, err := handler.Handle(nil, "info nothing"); err == nil {
+		t.Errorf("expected unknown section error")
+	}
+}
+
 func captureFromStdout() func() (string, error) {
     r, w, err := os.Pipe()
     if err != nil {
@@ -122,3 +470,1734 @@ func captureFromStdout() func() (string, error) {
         return buf.String(), err
     }
 }
+
+func TestComputeHandlerReplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_compute.NewMockStorage(ctrl)
+	mockNode := mock_compute.NewMockReplicationNode(ctrl)
+
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetReplication(mockNode)
+
+	mockNode.EXPECT().ReadOnly().Return(false).AnyTimes()
+	gomock.InOrder(
+		mockStorage.EXPECT().Set("key", "value"),
+		mockNode.EXPECT().Propagate("set", []string{"key", "value"}),
+	)
+	if _, err := handler.Handle(nil, "set key value"); err != nil {
+		t.Errorf("set unexpected error: %v", err)
+	}
+
+	mockStorage.EXPECT().Get("key").Return("value", true)
+	if _, err := handler.Handle(nil, "get key"); err != nil {
+		t.Errorf("get unexpected error: %v", err)
+	}
+
+	mockNode.EXPECT().Info().Return([]compute.InfoField{
+		{Name: "role", Value: "leader"},
+		{Name: "offset", Value: "1"},
+	})
+	res, err := handler.Handle(nil, "info replication")
+	if err != nil {
+		t.Errorf("info unexpected error: %v", err)
+	}
+	if expected := "# replication\nrole:leader\noffset:1"; res != expected {
+		t.Errorf("expected info %q, got %q", expected, res)
+	}
+}
+
+func TestComputeHandlerReadOnlyReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_compute.NewMockStorage(ctrl)
+	mockNode := mock_compute.NewMockReplicationNode(ctrl)
+
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetReplication(mockNode)
+
+	mockNode.EXPECT().ReadOnly().Return(true).AnyTimes()
+	for _, request := range []string{"set key value", "delete key", "expire key 10", "persist key"} {
+		if _, err := handler.Handle(nil, request); err == nil || err.Error() != "Write commands are not allowed on a read-only replica" {
+			t.Errorf("%s: expected read-only replica error, got: %v", request, err)
+		}
+	}
+
+	mockStorage.EXPECT().Get("key").Return("value", true)
+	if _, err := handler.Handle(nil, "get key"); err != nil {
+		t.Errorf("get unexpected error: %v", err)
+	}
+
+	// writes received from the leader are applied
+	gomock.InOrder(
+		mockStorage.EXPECT().Set("key", "value"),
+		mockNode.EXPECT().Propagate("set", []string{"key", "value"}),
+	)
+	if err := handler.Apply("set", []string{"key", "value"}); err != nil {
+		t.Errorf("apply unexpected error: %v", err)
+	}
+}
+
+type memoryLimitedStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockMemoryLimitedStorage
+}
+
+func TestComputeHandlerOutOfMemory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := memoryLimitedStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockMemoryLimitedStorage: mock_compute.NewMockMemoryLimitedStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	mockStorage.MockMemoryLimitedStorage.EXPECT().FreeMemory().Return(nil, false)
+	mockStorage.MockMemoryLimitedStorage.EXPECT().MemoryStats().Return(storage.MemoryStats{Policy: storage.NoEviction})
+	_, err := handler.Handle(nil, "set key value")
+	if err == nil || err.Error() != "OOM command not allowed when used memory > 'max_memory'" {
+		t.Errorf("expected out of memory error, got: %v", err)
+	}
+
+	// commands not growing the keyspace are allowed
+	mockStorage.MockStorage.EXPECT().Delete("key")
+	if _, err := handler.Handle(nil, "delete key"); err != nil {
+		t.Errorf("delete unexpected error: %v", err)
+	}
+	mockStorage.MockStorage.EXPECT().Get("key").Return("", false)
+	handler.Handle(nil, "get key")
+
+	// writes from the write-ahead log or the leader are not limited
+	mockStorage.MockStorage.EXPECT().Set("key", "value")
+	if err := handler.Apply("set", []string{"key", "value"}); err != nil {
+		t.Errorf("apply unexpected error: %v", err)
+	}
+
+	mockStorage.MockMemoryLimitedStorage.EXPECT().MemoryStats().Return(storage.MemoryStats{
+		Used: 2048,
+		Max: 1024,
+		Policy: storage.NoEviction,
+	})
+	res, err := handler.Handle(nil, "info memory")
+	if err != nil {
+		t.Fatalf("info: unexpected error: %v", err)
+	}
+	expected := "# memory\nused_memory:2048\nmax_memory:1024\neviction_policy:noeviction\nevicted_keys:0"
+	if res != expected {
+		t.Errorf("expected: %v \nactual: %v", expected, res)
+	}
+}
+
+func TestComputeHandlerEviction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := memoryLimitedStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockMemoryLimitedStorage: mock_compute.NewMockMemoryLimitedStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	gomock.InOrder(
+		mockStorage.MockMemoryLimitedStorage.EXPECT().FreeMemory().Return([]string{"old1", "old2"}, true),
+		mockWAL.EXPECT().Append("delete", []string{"old1"}).Return(nil),
+		mockWAL.EXPECT().Append("delete", []string{"old2"}).Return(nil),
+		mockStorage.MockStorage.EXPECT().Set("key", "value"),
+		mockWAL.EXPECT().Append("set", []string{"key", "value"}).Return(nil),
+	)
+	if _, err := handler.Handle(nil, "set key value"); err != nil {
+		t.Errorf("set unexpected error: %v", err)
+	}
+}
+
+type listStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockTypedStorage
+	*mock_compute.MockListStorage
+}
+
+func TestComputeHandlerList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := listStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockTypedStorage: mock_compute.NewMockTypedStorage(ctrl),
+		MockListStorage: mock_compute.NewMockListStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "rpush",
+			requestStr: "rpush queue a b",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockListStorage.EXPECT().RPush("queue", []string{"a", "b"}).Return(2, nil),
+					mockWAL.EXPECT().Append("rpush", []string{"queue", "a", "b"}).Return(nil),
+				)
+			},
+			expected: "2",
+		},
+		{
+			name: "lrange",
+			requestStr: "lrange queue 0 -1",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().LRange("queue", 0, -1).Return([]string{"a", "b"}, nil)
+			},
+			expected: "1) a\n2) b",
+		},
+		{
+			name: "lrange missing list",
+			requestStr: "lrange missing 0 -1",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().LRange("missing", 0, -1).Return(nil, nil)
+			},
+			expected: "(empty array)",
+		},
+		{
+			name: "lpop",
+			requestStr: "lpop queue",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockListStorage.EXPECT().LPop("queue").Return("a", true, nil),
+					mockWAL.EXPECT().Append("lpop", []string{"queue"}).Return(nil),
+				)
+			},
+			expected: "a",
+		},
+		{
+			name: "rpop empty list is not journaled",
+			requestStr: "rpop missing",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().RPop("missing").Return("", false, nil)
+			},
+			expected: "(nil)",
+		},
+		{
+			name: "lindex",
+			requestStr: "lindex queue -1",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().LIndex("queue", -1).Return("b", true, nil)
+			},
+			expected: "b",
+		},
+		{
+			name: "lrem nothing removed is not journaled",
+			requestStr: "lrem queue 0 c",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().LRem("queue", 0, "c").Return(0, nil)
+			},
+			expected: "0",
+		},
+		{
+			name: "ltrim",
+			requestStr: "ltrim queue 0 1",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockListStorage.EXPECT().LTrim("queue", 0, 1).Return(nil),
+					mockWAL.EXPECT().Append("ltrim", []string{"queue", "0", "1"}).Return(nil),
+				)
+			},
+			expected: "trimmed",
+		},
+		{
+			name: "llen",
+			requestStr: "llen queue",
+			exec: func() {
+				mockStorage.MockListStorage.EXPECT().LLen("queue").Return(1, nil)
+			},
+			expected: "1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+func TestComputeHandlerWrongType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := listStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockTypedStorage: mock_compute.NewMockTypedStorage(ctrl),
+		MockListStorage: mock_compute.NewMockListStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	mockStorage.MockListStorage.EXPECT().LPush("key", []string{"value"}).Return(0, storage.ErrWrongType)
+	if _, err := handler.Handle(nil, "lpush key value"); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("lpush: expected wrong type error, got: %v", err)
+	}
+
+	mockStorage.MockStorage.EXPECT().Get("queue").Return("", false)
+	mockStorage.MockTypedStorage.EXPECT().Type("queue").Return(storage.ListType, true)
+	if _, err := handler.Handle(nil, "get queue"); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("get: expected wrong type error, got: %v", err)
+	}
+
+	mockStorage.MockStorage.EXPECT().Get("missing").Return("", false)
+	mockStorage.MockTypedStorage.EXPECT().Type("missing").Return(storage.StringType, false)
+	if res, _ := handler.Handle(nil, "get missing"); res != "value not found" {
+		t.Errorf("get: expected value not found, got: %v", res)
+	}
+}
+
+func TestComputeHandlerListNotSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := compute.NewComputeHandler(
+		mock_compute.NewMockStorage(ctrl),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	if _, err := handler.Handle(nil, "rpush queue a"); err == nil || err.Error() != "Storage engine does not support lists" {
+		t.Errorf("expected lists not supported error, got: %v", err)
+	}
+}
+
+type hashStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockHashStorage
+}
+
+func TestComputeHandlerHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := hashStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockHashStorage: mock_compute.NewMockHashStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "hset",
+			requestStr: "hset user:1 name Ann email ann@example.com",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockHashStorage.EXPECT().HSet("user:1", map[string]string{
+						"name": "Ann",
+						"email": "ann@example.com",
+					}).Return(2, nil),
+					mockWAL.EXPECT().Append("hset", []string{"user:1", "name", "Ann", "email", "ann@example.com"}).Return(nil),
+				)
+			},
+			expected: "2",
+		},
+		{
+			name: "hget",
+			requestStr: "hget user:1 name",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HGet("user:1", "name").Return("Ann", true, nil)
+			},
+			expected: "Ann",
+		},
+		{
+			name: "hget missing field",
+			requestStr: "hget user:1 phone",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HGet("user:1", "phone").Return("", false, nil)
+			},
+			expected: "(nil)",
+		},
+		{
+			name: "hgetall",
+			requestStr: "hgetall user:1",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HGetAll("user:1").Return([]string{"email", "ann@example.com", "name", "Ann"}, nil)
+			},
+			expected: "1) email\n2) ann@example.com\n3) name\n4) Ann",
+		},
+		{
+			name: "hkeys",
+			requestStr: "hkeys user:1",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HKeys("user:1").Return([]string{"email", "name"}, nil)
+			},
+			expected: "1) email\n2) name",
+		},
+		{
+			name: "hvals",
+			requestStr: "hvals user:1",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HVals("user:1").Return([]string{"ann@example.com", "Ann"}, nil)
+			},
+			expected: "1) ann@example.com\n2) Ann",
+		},
+		{
+			name: "hlen",
+			requestStr: "hlen user:1",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HLen("user:1").Return(2, nil)
+			},
+			expected: "2",
+		},
+		{
+			name: "hexists",
+			requestStr: "hexists user:1 name",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HExists("user:1", "name").Return(true, nil)
+			},
+			expected: "1",
+		},
+		{
+			name: "hincrby",
+			requestStr: "hincrby user:1 visits -3",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockHashStorage.EXPECT().HIncrBy("user:1", "visits", int64(-3)).Return(int64(7), nil),
+					mockWAL.EXPECT().Append("hincrby", []string{"user:1", "visits", "-3"}).Return(nil),
+				)
+			},
+			expected: "7",
+		},
+		{
+			name: "hdel nothing removed is not journaled",
+			requestStr: "hdel user:1 phone",
+			exec: func() {
+				mockStorage.MockHashStorage.EXPECT().HDel("user:1", []string{"phone"}).Return(0, nil)
+			},
+			expected: "0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+
+	mockStorage.MockHashStorage.EXPECT().HIncrBy("user:1", "name", int64(1)).Return(int64(0), storage.ErrHashValueNotInteger)
+	if _, err := handler.Handle(nil, "hincrby user:1 name 1"); !errors.Is(err, storage.ErrHashValueNotInteger) {
+		t.Errorf("hincrby: expected not an integer error, got: %v", err)
+	}
+}
+
+type setStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockSetStorage
+}
+
+func TestComputeHandlerSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := setStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockSetStorage: mock_compute.NewMockSetStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "sadd",
+			requestStr: "sadd tags go db",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockSetStorage.EXPECT().SAdd("tags", []string{"go", "db"}).Return(2, nil),
+					mockWAL.EXPECT().Append("sadd", []string{"tags", "go", "db"}).Return(nil),
+				)
+			},
+			expected: "2",
+		},
+		{
+			name: "srem nothing removed is not journaled",
+			requestStr: "srem tags rust",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SRem("tags", []string{"rust"}).Return(0, nil)
+			},
+			expected: "0",
+		},
+		{
+			name: "smembers",
+			requestStr: "smembers tags",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SMembers("tags").Return([]string{"db", "go"}, nil)
+			},
+			expected: "1) db\n2) go",
+		},
+		{
+			name: "sismember",
+			requestStr: "sismember tags go",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SIsMember("tags", "go").Return(true, nil)
+			},
+			expected: "1",
+		},
+		{
+			name: "scard",
+			requestStr: "scard tags",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SCard("tags").Return(2, nil)
+			},
+			expected: "2",
+		},
+		{
+			name: "spop is journaled as srem",
+			requestStr: "spop tags",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockSetStorage.EXPECT().SPop("tags", 1).Return([]string{"db"}, nil),
+					mockWAL.EXPECT().Append("srem", []string{"tags", "db"}).Return(nil),
+				)
+			},
+			expected: "db",
+		},
+		{
+			name: "spop empty set",
+			requestStr: "spop missing 2",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SPop("missing", 2).Return(nil, nil)
+			},
+			expected: "(empty array)",
+		},
+		{
+			name: "srandmember",
+			requestStr: "srandmember tags -2",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SRandMember("tags", -2).Return([]string{"go", "go"}, nil)
+			},
+			expected: "1) go\n2) go",
+		},
+		{
+			name: "sinter",
+			requestStr: "sinter tags other",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SInter([]string{"tags", "other"}).Return([]string{"go"}, nil)
+			},
+			expected: "1) go",
+		},
+		{
+			name: "sunion",
+			requestStr: "sunion tags other",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SUnion([]string{"tags", "other"}).Return([]string{"db", "go"}, nil)
+			},
+			expected: "1) db\n2) go",
+		},
+		{
+			name: "sdiff",
+			requestStr: "sdiff tags other",
+			exec: func() {
+				mockStorage.MockSetStorage.EXPECT().SDiff([]string{"tags", "other"}).Return(nil, nil)
+			},
+			expected: "(empty array)",
+		},
+		{
+			name: "sunionstore",
+			requestStr: "sunionstore all tags other",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockSetStorage.EXPECT().SUnionStore("all", []string{"tags", "other"}).Return(3, nil),
+					mockWAL.EXPECT().Append("sunionstore", []string{"all", "tags", "other"}).Return(nil),
+				)
+			},
+			expected: "3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+type sortedSetStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockSortedSetStorage
+}
+
+func TestComputeHandlerSortedSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := sortedSetStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockSortedSetStorage: mock_compute.NewMockSortedSetStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "zadd",
+			requestStr: "zadd board 10 alice 7.5 bob",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockSortedSetStorage.EXPECT().
+						ZAdd("board", map[string]float64{"alice": 10, "bob": 7.5}).Return(2, nil),
+					mockWAL.EXPECT().Append("zadd", []string{"board", "10", "alice", "7.5", "bob"}).Return(nil),
+				)
+			},
+			expected: "2",
+		},
+		{
+			name: "zrem nothing removed is not journaled",
+			requestStr: "zrem board carol",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZRem("board", []string{"carol"}).Return(0, nil)
+			},
+			expected: "0",
+		},
+		{
+			name: "zscore",
+			requestStr: "zscore board bob",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZScore("board", "bob").Return(7.5, true, nil)
+			},
+			expected: "7.5",
+		},
+		{
+			name: "zscore missing member",
+			requestStr: "zscore board carol",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZScore("board", "carol").Return(0.0, false, nil)
+			},
+			expected: "(nil)",
+		},
+		{
+			name: "zincrby",
+			requestStr: "zincrby board -2.5 alice",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockSortedSetStorage.EXPECT().ZIncrBy("board", "alice", -2.5).Return(7.5, nil),
+					mockWAL.EXPECT().Append("zincrby", []string{"board", "-2.5", "alice"}).Return(nil),
+				)
+			},
+			expected: "7.5",
+		},
+		{
+			name: "zrevrank",
+			requestStr: "zrevrank board bob",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZRank("board", "bob", true).Return(1, true, nil)
+			},
+			expected: "1",
+		},
+		{
+			name: "zrange withscores",
+			requestStr: "zrange board 0 -1 withscores",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZRange("board", 0, -1, false).Return([]storage.ScoredMember{
+					{Member: "alice", Score: 7.5},
+					{Member: "bob", Score: 7.5},
+				}, nil)
+			},
+			expected: "1) alice\n2) 7.5\n3) bob\n4) 7.5",
+		},
+		{
+			name: "zrangebyscore",
+			requestStr: "zrangebyscore board (5 +inf",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZRangeByScore(
+					"board",
+					storage.ScoreBound{Value: 5, Exclusive: true},
+					storage.ScoreBound{Value: math.Inf(1)},
+				).Return([]storage.ScoredMember{{Member: "alice", Score: 7.5}}, nil)
+			},
+			expected: "1) alice",
+		},
+		{
+			name: "zcount",
+			requestStr: "zcount board -inf 7",
+			exec: func() {
+				mockStorage.MockSortedSetStorage.EXPECT().ZCount(
+					"board",
+					storage.ScoreBound{Value: math.Inf(-1)},
+					storage.ScoreBound{Value: 7},
+				).Return(0, nil)
+			},
+			expected: "0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+type counterStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockCounterStorage
+}
+
+func TestComputeHandlerCounter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := counterStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockCounterStorage: mock_compute.NewMockCounterStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "incr",
+			requestStr: "incr visits",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockCounterStorage.EXPECT().IncrBy("visits", int64(1)).Return(int64(1), nil),
+					mockWAL.EXPECT().Append("incr", []string{"visits"}).Return(nil),
+				)
+			},
+			expected: "1",
+		},
+		{
+			name: "decr",
+			requestStr: "decr visits",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockCounterStorage.EXPECT().IncrBy("visits", int64(-1)).Return(int64(0), nil),
+					mockWAL.EXPECT().Append("decr", []string{"visits"}).Return(nil),
+				)
+			},
+			expected: "0",
+		},
+		{
+			name: "incrby",
+			requestStr: "incrby visits 10",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockCounterStorage.EXPECT().IncrBy("visits", int64(10)).Return(int64(10), nil),
+					mockWAL.EXPECT().Append("incrby", []string{"visits", "10"}).Return(nil),
+				)
+			},
+			expected: "10",
+		},
+		{
+			name: "decrby",
+			requestStr: "decrby visits -5",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockCounterStorage.EXPECT().IncrBy("visits", int64(5)).Return(int64(15), nil),
+					mockWAL.EXPECT().Append("decrby", []string{"visits", "-5"}).Return(nil),
+				)
+			},
+			expected: "15",
+		},
+		{
+			name: "incrbyfloat",
+			requestStr: "incrbyfloat price 0.25",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockCounterStorage.EXPECT().IncrByFloat("price", 0.25).Return(10.75, nil),
+					mockWAL.EXPECT().Append("incrbyfloat", []string{"price", "0.25"}).Return(nil),
+				)
+			},
+			expected: "10.75",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+func TestComputeHandlerCounterErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := counterStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockCounterStorage: mock_compute.NewMockCounterStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mock_compute.NewMockWAL(ctrl))
+
+	mockStorage.MockCounterStorage.EXPECT().IncrBy("name", int64(1)).Return(int64(0), storage.ErrNotInteger)
+	if _, err := handler.Handle(nil, "incr name"); !errors.Is(err, storage.ErrNotInteger) {
+		t.Errorf("expected not integer error, got %v", err)
+	}
+
+	if _, err := handler.Handle(nil, "decrby visits -9223372036854775808"); !errors.Is(err, storage.ErrOverflow) {
+		t.Errorf("expected overflow error, got %v", err)
+	}
+}
+
+func TestComputeHandlerTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_compute.NewMockStorage(ctrl)
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+	session := network.NewSession("client")
+
+	var testCases = []computeTestCase{
+		{
+			name: "multi",
+			requestStr: "multi",
+			exec: func() {},
+			expected: "OK",
+		},
+		{
+			name: "set is queued",
+			requestStr: "set key value",
+			exec: func() {},
+			expected: "QUEUED",
+		},
+		{
+			name: "delete is queued",
+			requestStr: "delete other",
+			exec: func() {},
+			expected: "QUEUED",
+		},
+		{
+			name: "get is queued",
+			requestStr: "get other",
+			exec: func() {},
+			expected: "QUEUED",
+		},
+		{
+			name: "exec",
+			requestStr: "exec",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.EXPECT().Set("key", "value"),
+					mockWAL.EXPECT().Append("set", []string{"key", "value"}).Return(nil),
+					mockStorage.EXPECT().Delete("other"),
+					mockWAL.EXPECT().Append("delete", []string{"other"}).Return(nil),
+					mockStorage.EXPECT().Get("other").Return("", false),
+				)
+			},
+			expected: "1) saved\n2) deleted\n3) (error) Value by key other not found",
+		},
+		{
+			name: "multi after exec",
+			requestStr: "multi",
+			exec: func() {},
+			expected: "OK",
+		},
+		{
+			name: "set is queued before discard",
+			requestStr: "set key other",
+			exec: func() {},
+			expected: "QUEUED",
+		},
+		{
+			name: "discard",
+			requestStr: "discard",
+			exec: func() {},
+			expected: "OK",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(session, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+func TestComputeHandlerTransactionErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := compute.NewComputeHandler(
+		mock_compute.NewMockStorage(ctrl),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	session := network.NewSession("client")
+
+	if _, err := handler.Handle(session, "exec"); err == nil || err.Error() != "EXEC without MULTI" {
+		t.Errorf("exec: expected exec without multi error, got: %v", err)
+	}
+	if _, err := handler.Handle(session, "discard"); err == nil || err.Error() != "DISCARD without MULTI" {
+		t.Errorf("discard: expected discard without multi error, got: %v", err)
+	}
+	if _, err := handler.Handle(nil, "multi"); err == nil {
+		t.Errorf("multi: expected error without session")
+	}
+
+	handler.Handle(session, "multi")
+	if _, err := handler.Handle(session, "multi"); err == nil || err.Error() != "MULTI calls can not be nested" {
+		t.Errorf("multi: expected nested multi error, got: %v", err)
+	}
+	handler.Handle(session, "set key value")
+	if _, err := handler.Handle(session, "set key"); err == nil {
+		t.Errorf("set: expected validation error")
+	}
+	if _, err := handler.Handle(session, "exec"); err == nil || !strings.HasPrefix(err.Error(), "EXECABORT") {
+		t.Errorf("exec: expected aborted transaction error, got: %v", err)
+	}
+	if _, err := handler.Handle(session, "exec"); err == nil || err.Error() != "EXEC without MULTI" {
+		t.Errorf("exec: expected transaction to be closed, got: %v", err)
+	}
+}
+
+func TestComputeHandlerTransactionIsolation(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	handler.Handle(nil, "set a 0")
+	handler.Handle(nil, "set b 0")
+
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		session := network.NewSession("writer")
+		for i := 0; i < iterations; i++ {
+			handler.Handle(session, "multi")
+			handler.Handle(session, "incr a")
+			handler.Handle(session, "incr b")
+			if _, err := handler.Handle(session, "exec"); err != nil {
+				t.Errorf("writer exec: unexpected error: %v", err)
+
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+
+		session := network.NewSession("reader")
+		for i := 0; i < iterations; i++ {
+			handler.Handle(session, "multi")
+			handler.Handle(session, "get a")
+			handler.Handle(session, "get b")
+			res, err := handler.Handle(session, "exec")
+			if err != nil {
+				t.Errorf("reader exec: unexpected error: %v", err)
+
+				return
+			}
+
+			lines := strings.Split(res, "\n")
+			if len(lines) != 2 || strings.TrimPrefix(lines[0], "1) ") != strings.TrimPrefix(lines[1], "2) ") {
+				t.Errorf("reader exec: expected equal counters, got: %v", res)
+
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestComputeHandlerWatch(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	client := network.NewSession("client")
+	other := network.NewSession("other")
+
+	handler.Handle(client, "set balance 10")
+	if res, err := handler.Handle(client, "watch balance"); err != nil || res != "OK" {
+		t.Fatalf("watch: unexpected result %v (err: %v)", res, err)
+	}
+	handler.Handle(other, "incrby balance 5")
+
+	handler.Handle(client, "multi")
+	handler.Handle(client, "set balance 20")
+	if res, err := handler.Handle(client, "exec"); err != nil || res != "(nil)" {
+		t.Errorf("exec: expected aborted transaction, got %v (err: %v)", res, err)
+	}
+	if res, _ := handler.Handle(client, "get balance"); res != "15" {
+		t.Errorf("get: expected value of the other client, got %v", res)
+	}
+
+	handler.Handle(client, "watch balance")
+	handler.Handle(client, "multi")
+	if _, err := handler.Handle(client, "watch balance"); err == nil || err.Error() != "WATCH inside MULTI is not allowed" {
+		t.Errorf("watch: expected watch inside multi error, got %v", err)
+	}
+	handler.Handle(client, "set balance 20")
+	if res, err := handler.Handle(client, "exec"); err != nil || res != "1) saved" {
+		t.Errorf("exec: expected executed transaction, got %v (err: %v)", res, err)
+	}
+
+	handler.Handle(client, "watch balance")
+	handler.Handle(client, "unwatch")
+	handler.Handle(other, "delete balance")
+	handler.Handle(client, "multi")
+	handler.Handle(client, "set balance 30")
+	if res, err := handler.Handle(client, "exec"); err != nil || res != "1) saved" {
+		t.Errorf("exec: expected unwatched transaction to be executed, got %v (err: %v)", res, err)
+	}
+}
+
+type versionedStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockVersionedStorage
+}
+
+func TestComputeHandlerCompareAndSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := versionedStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockVersionedStorage: mock_compute.NewMockVersionedStorage(ctrl),
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	var testCases = []computeTestCase{
+		{
+			name: "cas swapped",
+			requestStr: "cas key old new",
+			exec: func() {
+				gomock.InOrder(
+					mockStorage.MockVersionedStorage.EXPECT().CompareAndSet("key", "old", "new").Return(true, nil),
+					mockWAL.EXPECT().Append("cas", []string{"key", "old", "new"}).Return(nil),
+				)
+			},
+			expected: "1",
+		},
+		{
+			name: "cas not swapped is not journaled",
+			requestStr: "cas key old new",
+			exec: func() {
+				mockStorage.MockVersionedStorage.EXPECT().CompareAndSet("key", "old", "new").Return(false, nil)
+			},
+			expected: "0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+type scanningStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockScanningStorage
+}
+
+func TestComputeHandlerScan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := scanningStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockScanningStorage: mock_compute.NewMockScanningStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	var testCases = []computeTestCase{
+		{
+			name: "scan",
+			requestStr: "scan 0",
+			exec: func() {
+				mockStorage.MockScanningStorage.EXPECT().Scan(uint64(0), "", 10).Return([]string{"a", "b"}, uint64(42))
+			},
+			expected: "1) 42\n2) 1) a\n   2) b",
+		},
+		{
+			name: "scan with options",
+			requestStr: "scan 42 count 100 match user:*",
+			exec: func() {
+				mockStorage.MockScanningStorage.EXPECT().Scan(uint64(42), "user:*", 100).Return(nil, uint64(0))
+			},
+			expected: "1) 0\n2) (empty array)",
+		},
+		{
+			name: "keys",
+			requestStr: "keys user:*",
+			exec: func() {
+				mockStorage.MockScanningStorage.EXPECT().Keys("user:*").Return([]string{"user:1"})
+			},
+			expected: "1) user:1",
+		},
+		{
+			name: "dbsize",
+			requestStr: "dbsize",
+			exec: func() {
+				mockStorage.MockScanningStorage.EXPECT().DBSize().Return(3)
+			},
+			expected: "3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+type orderedStorage struct {
+	*mock_compute.MockStorage
+	*mock_compute.MockOrderedStorage
+}
+
+func TestComputeHandlerRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := orderedStorage{
+		MockStorage: mock_compute.NewMockStorage(ctrl),
+		MockOrderedStorage: mock_compute.NewMockOrderedStorage(ctrl),
+	}
+	handler := compute.NewComputeHandler(
+		mockStorage,
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	var testCases = []computeTestCase{
+		{
+			name: "range",
+			requestStr: "range 2024-01 2024-02",
+			exec: func() {
+				mockStorage.MockOrderedStorage.EXPECT().Range("2024-01", "2024-02", 0, false).Return([]storage.KeyValue{
+					{Key: "2024-01", Type: storage.StringType, Value: "10"},
+					{Key: "2024-02", Type: storage.ListType},
+				})
+			},
+			expected: "1) 2024-01\n2) 10\n3) 2024-02\n4) (nil)",
+		},
+		{
+			name: "revrange unbounded with limit",
+			requestStr: "revrange - + LIMIT 1",
+			exec: func() {
+				mockStorage.MockOrderedStorage.EXPECT().Range("", "", 1, true).Return([]storage.KeyValue{
+					{Key: "2024-02", Type: storage.StringType, Value: "20"},
+				})
+			},
+			expected: "1) 2024-02\n2) 20",
+		},
+		{
+			name: "prefix",
+			requestStr: "prefix user: limit 10",
+			exec: func() {
+				mockStorage.MockOrderedStorage.EXPECT().Prefix("user:", 10).Return(nil)
+			},
+			expected: "(empty array)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.exec()
+
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+func TestComputeHandlerRangeNotSupported(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+
+	for _, request := range []string{"range a z", "revrange a z", "prefix a"} {
+		if _, err := handler.Handle(nil, request); err == nil || !strings.HasPrefix(err.Error(), "Storage engine does not support range queries") {
+			t.Errorf("%s: expected range not supported error, got: %v", request, err)
+		}
+	}
+}
+
+func TestComputeHandlerPubSub(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	subscriber := network.NewSession("subscriber")
+	publisher := network.NewSession("publisher")
+
+	if res, err := handler.Handle(subscriber, "subscribe news weather"); err != nil || res != "1) subscribe\n2) news\n3) 1\n1) subscribe\n2) weather\n3) 2" {
+		t.Fatalf("subscribe: unexpected result %q (err: %v)", res, err)
+	}
+	if res, err := handler.Handle(subscriber, "psubscribe sport.*"); err != nil || res != "1) psubscribe\n2) sport.*\n3) 3" {
+		t.Fatalf("psubscribe: unexpected result %q (err: %v)", res, err)
+	}
+	if _, err := handler.Handle(subscriber, "get key"); err == nil || !strings.HasPrefix(err.Error(), "Only (P)SUBSCRIBE") {
+		t.Errorf("get: expected subscribe mode error, got: %v", err)
+	}
+
+	if res, _ := handler.Handle(publisher, "publish news hello"); res != "1" {
+		t.Errorf("publish: expected one delivery, got %v", res)
+	}
+	if res, _ := handler.Handle(publisher, "publish sport.tennis ace"); res != "1" {
+		t.Errorf("publish: expected one delivery, got %v", res)
+	}
+	if res, _ := handler.Handle(publisher, "publish other message"); res != "0" {
+		t.Errorf("publish: expected no deliveries, got %v", res)
+	}
+	if message := <-subscriber.Pushed(); message != "1) message\n2) news\n3) hello" {
+		t.Errorf("expected channel message, got %q", message)
+	}
+	if message := <-subscriber.Pushed(); message != "1) pmessage\n2) sport.*\n3) sport.tennis\n4) ace" {
+		t.Errorf("expected pattern message, got %q", message)
+	}
+
+	if res, _ := handler.Handle(subscriber, "unsubscribe"); res != "1) unsubscribe\n2) news\n3) 2\n1) unsubscribe\n2) weather\n3) 1" {
+		t.Errorf("unsubscribe: unexpected result %q", res)
+	}
+	if res, _ := handler.Handle(subscriber, "punsubscribe"); res != "1) punsubscribe\n2) sport.*\n3) 0" {
+		t.Errorf("punsubscribe: unexpected result %q", res)
+	}
+	if res, _ := handler.Handle(subscriber, "punsubscribe"); res != "1) punsubscribe\n2) (nil)\n3) 0" {
+		t.Errorf("punsubscribe: unexpected result %q", res)
+	}
+	if _, err := handler.Handle(subscriber, "get key"); err == nil || strings.HasPrefix(err.Error(), "Only (P)SUBSCRIBE") {
+		t.Errorf("get: expected subscribe mode to be left, got: %v", err)
+	}
+
+	handler.Handle(subscriber, "subscribe news")
+	subscriber.Close()
+	if res, _ := handler.Handle(publisher, "publish news bye"); res != "0" {
+		t.Errorf("publish: expected closed session to be unsubscribed, got %v", res)
+	}
+
+	handler.Handle(publisher, "multi")
+	if _, err := handler.Handle(publisher, "subscribe news"); err == nil || err.Error() != "SUBSCRIBE inside MULTI is not allowed" {
+		t.Errorf("subscribe: expected subscribe inside multi error, got: %v", err)
+	}
+}
+
+func TestComputeHandlerKeyspaceEvents(t *testing.T) {
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	if err := handler.SetKeyspaceEvents([]string{"unknown"}); err == nil {
+		t.Errorf("expected unknown event class error")
+	}
+	if err := handler.SetKeyspaceEvents([]string{"generic", "string", "expired"}); err != nil {
+		t.Fatalf("SetKeyspaceEvents error: %v", err)
+	}
+
+	subscriber := network.NewSession("subscriber")
+	client := network.NewSession("client")
+	handler.Handle(subscriber, "psubscribe __keyspace@0__:user:* __keyevent@0__:del")
+
+	handler.Handle(client, "set user:1 alice")
+	handler.Handle(client, "incr counter")
+	handler.Handle(client, "lpush user:list a")
+	handler.Handle(client, "set user:2 bob PX 10")
+	handler.Handle(client, "delete counter")
+	handler.Handle(client, "mset user:3 carol other value")
+	handler.Handle(client, "mdel user:3 missing")
+	time.Sleep(20 * time.Millisecond)
+	handler.Handle(client, "get user:2")
+
+	expected := []string{
+		"1) pmessage\n2) __keyspace@0__:user:*\n3) __keyspace@0__:user:1\n4) set",
+		"1) pmessage\n2) __keyspace@0__:user:*\n3) __keyspace@0__:user:2\n4) set",
+		"1) pmessage\n2) __keyevent@0__:del\n3) __keyevent@0__:del\n4) counter",
+		"1) pmessage\n2) __keyspace@0__:user:*\n3) __keyspace@0__:user:3\n4) set",
+		"1) pmessage\n2) __keyspace@0__:user:*\n3) __keyspace@0__:user:3\n4) del",
+		"1) pmessage\n2) __keyevent@0__:del\n3) __keyevent@0__:del\n4) user:3",
+		"1) pmessage\n2) __keyspace@0__:user:*\n3) __keyspace@0__:user:2\n4) expired",
+	}
+	for _, message := range expected {
+		select {
+		case pushed := <-subscriber.Pushed():
+			if pushed != message {
+				t.Errorf("expected event %q, got %q", message, pushed)
+			}
+		default:
+			t.Errorf("expected event %q, got none", message)
+		}
+	}
+	select {
+	case pushed := <-subscriber.Pushed():
+		t.Errorf("unexpected event %q", pushed)
+	default:
+	}
+}
+
+func TestComputeHandlerDatabases(t *testing.T) {
+	databases, err := storage.NewDatabases(internal.Config{
+		Engine: internal.EngineConfig{EngineType: storage.ShardedEngine, Databases: 3},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	handler := compute.NewComputeHandler(
+		databases.DB(0),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetDatabases(databases)
+	session := network.NewSession("client")
+	other := network.NewSession("other")
+
+	var testCases = []struct {
+		name string
+		session *network.Session
+		requestStr string
+		expected string
+		expectedErr string
+	}{
+		{"set in the first database", session, "set key first", "saved", ""},
+		{"select", session, "select 1", "OK", ""},
+		{"key of another database", session, "get key", "value not found", "Value by key key not found"},
+		{"set in the second database", session, "set key second", "saved", ""},
+		{"set moved key", session, "set moved value", "saved", ""},
+		{"dbsize", session, "dbsize", "2", ""},
+		{"other session keeps the first database", other, "get key", "first", ""},
+		{"move", session, "move moved 2", "1", ""},
+		{"move to the same database", session, "move key 1", "", "source and destination objects are the same"},
+		{"move key existing in the target", session, "move key 0", "0", ""},
+		{"select out of range", session, "select 3", "", "DB index is out of range"},
+		{"swapdb", other, "swapdb 0 1", "OK", ""},
+		{"swapped database", other, "get key", "second", ""},
+		{"selected database is swapped too", session, "get key", "first", ""},
+		{"flushdb", session, "flushdb", "OK", ""},
+		{"flushed database", session, "dbsize", "0", ""},
+		{"other databases are not flushed", other, "dbsize", "1", ""},
+		{"select moved key database", other, "select 2", "OK", ""},
+		{"moved key", other, "get moved", "value", ""},
+		{"flushall", other, "flushall", "OK", ""},
+		{"flushed databases", other, "dbsize", "0", ""},
+	}
+
+	for _, testCase := range testCases {
+		res, err := handler.Handle(testCase.session, testCase.requestStr)
+		if testCase.expectedErr == "" && err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if testCase.expectedErr != "" && (err == nil || err.Error() != testCase.expectedErr) {
+			t.Errorf("case %v: expected error %q, got: %v", testCase.name, testCase.expectedErr, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %v \nactual: %v", testCase.name, testCase.expected, res)
+		}
+	}
+
+	handler.Handle(session, "multi")
+	if _, err := handler.Handle(session, "select 0"); err == nil || err.Error() != "SELECT inside MULTI is not allowed" {
+		t.Errorf("select: expected select inside multi error, got: %v", err)
+	}
+	handler.Handle(session, "discard")
+
+	handler.Handle(session, "watch key")
+	handler.Handle(other, "swapdb 0 1")
+	handler.Handle(session, "multi")
+	handler.Handle(session, "set key value")
+	if res, err := handler.Handle(session, "exec"); err != nil || res != "(nil)" {
+		t.Errorf("exec: expected transaction watching a swapped database to be aborted, got %v (err: %v)", res, err)
+	}
+}
+
+func TestComputeHandlerDatabasesJournal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	databases, err := storage.NewDatabases(internal.Config{
+		Engine: internal.EngineConfig{EngineType: storage.InMemoryEngine, Databases: 2},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		databases.DB(0),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetDatabases(databases)
+	handler.SetWAL(mockWAL)
+	session := network.NewSession("client")
+
+	gomock.InOrder(
+		mockWAL.EXPECT().Append("set", []string{"key", "first"}).Return(nil),
+		mockWAL.EXPECT().Append("select", []string{"1", "set", "key", "second"}).Return(nil),
+		mockWAL.EXPECT().Append("select", []string{"1", "flushdb"}).Return(nil),
+		mockWAL.EXPECT().Append("select", []string{"1", "set", "replayed", "value"}).Return(nil),
+	)
+	handler.Handle(session, "set key first")
+	handler.Handle(session, "select 1")
+	handler.Handle(session, "set key second")
+	handler.Handle(session, "flushdb")
+
+	if err := handler.Apply("select", []string{"1", "set", "replayed", "value"}); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if value, found := databases.DB(1).Get("replayed"); !found || value != "value" {
+		t.Errorf("expected command to be applied to the second database, got %v (found: %v)", value, found)
+	}
+	if err := handler.Apply("select", []string{"2", "set", "key", "value"}); err == nil {
+		t.Errorf("expected out of range database error")
+	}
+}
+
+func TestComputeHandlerMultiKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWAL := mock_compute.NewMockWAL(ctrl)
+	handler := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetWAL(mockWAL)
+
+	gomock.InOrder(
+		mockWAL.EXPECT().Append("set", []string{"tags", "x"}).Return(nil),
+		mockWAL.EXPECT().Append("mset", []string{"a", "1", "b", "2"}).Return(nil),
+		mockWAL.EXPECT().Append("mset", []string{"c", "3", "d", "4"}).Return(nil),
+		mockWAL.EXPECT().Append("mdel", []string{"a", "c"}).Return(nil),
+	)
+
+	var testCases = []struct {
+		name string
+		requestStr string
+		expected string
+		expectedErr string
+	}{
+		{"set", "set tags x", "saved", ""},
+		{"mset", "mset a 1 b 2", "OK", ""},
+		{"mset without value", "mset a 1 b", "", "Arguments parse error: expected key value pairs, got 3 arguments"},
+		{"mget with explicit nils", "mget a missing b", "1) 1\n2) (nil)\n3) 2", ""},
+		{"msetnx with existing key", "msetnx c 3 a 5", "0", ""},
+		{"msetnx sets nothing", "mget c a", "1) (nil)\n2) 1", ""},
+		{"msetnx", "msetnx c 3 d 4", "1", ""},
+		{"mdel", "mdel a missing c a", "1) 1\n2) 0\n3) 1\n4) 0", ""},
+		{"mdel of missing keys is not journaled", "mdel a c", "1) 0\n2) 0", ""},
+		{"mget after mdel", "mget a b c d", "1) (nil)\n2) 2\n3) (nil)\n4) 4", ""},
+	}
+
+	for _, testCase := range testCases {
+		res, err := handler.Handle(nil, testCase.requestStr)
+		if testCase.expectedErr == "" && err != nil {
+			t.Errorf("case %v: unexpected error: %v", testCase.name, err)
+		}
+		if testCase.expectedErr != "" && (err == nil || err.Error() != testCase.expectedErr) {
+			t.Errorf("case %v: expected error %q, got: %v", testCase.name, testCase.expectedErr, err)
+		}
+		if res != testCase.expected {
+			t.Errorf("case %v: \nexpected: %q \nactual: %q", testCase.name, testCase.expected, res)
+		}
+	}
+}
+
+func TestComputeHandlerCompressionInfo(t *testing.T) {
+	databases, err := storage.NewDatabases(internal.Config{
+		Engine: internal.EngineConfig{
+			EngineType: storage.ShardedEngine,
+			Databases: 2,
+			Compression: internal.CompressionConfig{Threshold: 64, Codec: storage.GzipCodec},
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	handler := compute.NewComputeHandler(
+		databases.DB(0),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	handler.SetDatabases(databases)
+	session := network.NewSession("client")
+
+	value := strings.Repeat("abcd", 256)
+	handler.Handle(session, "set key "+value)
+	handler.Handle(session, "select 1")
+	handler.Handle(session, "set key "+value)
+	handler.Handle(session, "set small value")
+
+	if res, err := handler.Handle(session, "get key"); err != nil || res != value {
+		t.Errorf("get: expected the decompressed value, got %q (err: %v)", res, err)
+	}
+
+	res, err := handler.Handle(session, "info compression")
+	if err != nil {
+		t.Fatalf("info: unexpected error: %v", err)
+	}
+	stats := databases.DB(0).(*storage.InMemoryStorage).CompressionStats()
+	expected := fmt.Sprintf(
+		"# compression\ncompression_threshold:64\ncompression_codec:gzip\ncompressed_values:2\ncompressed_raw_bytes:2048\ncompressed_bytes:%d\ncompression_ratio:%.2f",
+		stats.CompressedBytes,
+		2048/float64(stats.CompressedBytes),
+	)
+	if res != expected {
+		t.Errorf("expected: %v \nactual: %v", expected, res)
+	}
+}
+
+func TestComputeHandlerHistory(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{
+			ShardsCount: 4,
+			History: internal.HistoryConfig{Versions: 5},
+		},
+	})
+	handler := compute.NewComputeHandler(s, compute.NewRequestParser(), zap.NewNop())
+	session := network.NewSession("client")
+
+	handler.Handle(session, "set config v1")
+	handler.Handle(session, "set config v2")
+	handler.Handle(session, "delete config")
+
+	versions, err := s.History("config")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d (err: %v)", len(versions), err)
+	}
+	expected := ""
+	for i, value := range []string{"v1", "v2", "(nil)"} {
+		if i > 0 {
+			expected += "\n"
+		}
+		expected += fmt.Sprintf(
+			"%d) 1) %d\n   2) %s\n   3) %s",
+			i+1, i+1, versions[i].Time.Format(time.RFC3339Nano), value,
+		)
+	}
+	if res, err := handler.Handle(session, "history config"); err != nil || res != expected {
+		t.Errorf("history: expected %q, got %q (err: %v)", expected, res, err)
+	}
+
+	cases := []struct {
+		request  string
+		expected string
+	}{
+		{"getat config 1", "v1"},
+		{"getat config 3", "(nil)"},
+		{"getat config 9", "(nil)"},
+		{"getat config " + versions[1].Time.Format(time.RFC3339Nano), "v2"},
+		{"getat config 2000-01-01T00:00:00Z", "(nil)"},
+		{"revert config 2", "OK"},
+		{"get config", "v2"},
+	}
+	for _, tc := range cases {
+		if res, err := handler.Handle(session, tc.request); err != nil || res != tc.expected {
+			t.Errorf("%s: expected %q, got %q (err: %v)", tc.request, tc.expected, res, err)
+		}
+	}
+
+	if _, err := handler.Handle(session, "revert config 9"); !errors.Is(err, storage.ErrVersionNotFound) {
+		t.Errorf("revert: expected version not found error, got %v", err)
+	}
+
+	disabled := compute.NewComputeHandler(
+		storage.NewInMemoryStorage(internal.Config{}),
+		compute.NewRequestParser(),
+		zap.NewNop(),
+	)
+	if _, err := disabled.Handle(session, "history config"); !errors.Is(err, storage.ErrHistoryDisabled) {
+		t.Errorf("history: expected history disabled error, got %v", err)
+	}
+}
+
+func TestComputeHandlerIndex(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{Engine: internal.EngineConfig{ShardsCount: 4}})
+	handler := compute.NewComputeHandler(s, compute.NewRequestParser(), zap.NewNop())
+	session := network.NewSession("client")
+
+	cases := []struct {
+		request  string
+		expected string
+	}{
+		{`set user:1 {"email":"alice@example.com","age":30}`, "saved"},
+		{`set user:2 {"email":"bob@example.com","age":25}`, "saved"},
+		{"index create byemail on user: field email", "OK"},
+		{"index create byage ON user: FIELD age", "OK"},
+		{`set user:3 {"email":"carol@example.com","age":35}`, "saved"},
+		{"find byemail bob@example.com", "1) user:2"},
+		{"find byemail nobody@example.com", "(empty array)"},
+		{"findrange byage 26 +", "1) user:1\n2) user:3"},
+		{"findrange byage - +", "1) user:2\n2) user:1\n3) user:3"},
+		{"delete user:1", "deleted"},
+		{"findrange byage - 30", "1) user:2"},
+		{"index drop byage", "OK"},
+	}
+	for _, tc := range cases {
+		if res, err := handler.Handle(session, tc.request); err != nil || res != tc.expected {
+			t.Errorf("%s: expected %q, got %q (err: %v)", tc.request, tc.expected, res, err)
+		}
+	}
+
+	if _, err := handler.Handle(session, "find byage 25"); !errors.Is(err, storage.ErrIndexNotFound) {
+		t.Errorf("find: expected index not found error, got %v", err)
+	}
+	if _, err := handler.Handle(session, "index create byemail ON user: FIELD email"); !errors.Is(err, storage.ErrIndexExists) {
+		t.Errorf("index: expected index exists error, got %v", err)
+	}
+}
+
+func TestComputeHandlerDiskEngine(t *testing.T) {
+	databases, err := storage.NewDatabases(internal.Config{
+		Engine: internal.EngineConfig{
+			EngineType: storage.DiskEngine,
+			Databases: 2,
+			Disk: internal.DiskConfig{Directory: t.TempDir()},
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	defer databases.Close()
+
+	handler := compute.NewComputeHandler(databases.DB(0), compute.NewRequestParser(), zap.NewNop())
+	handler.SetDatabases(databases)
+	session := network.NewSession("client")
+
+	cases := []struct {
+		request  string
+		expected string
+	}{
+		{"set key value", "saved"},
+		{"get key", "value"},
+		{"info engine", "# engine\npersistent:true\nordered:false\nttl:false"},
+		{"flushdb", "OK"},
+		{"set key other", "saved"},
+		{"get key", "other"},
+	}
+	for _, tc := range cases {
+		if res, err := handler.Handle(session, tc.request); err != nil || res != tc.expected {
+			t.Errorf("%s: expected %q, got %q (err: %v)", tc.request, tc.expected, res, err)
+		}
+	}
+
+	if _, err := handler.Handle(session, "swapdb 0 1"); err == nil || err.Error() != "Storage engine does not support swapdb" {
+		t.Errorf("swapdb: expected swap not supported error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package compute
 import (
 	"testing"
 	"umemory/internal/compute"
+	"umemory/internal/network"
 )
 
 type parserTestCase struct {
@@ -29,7 +30,63 @@ func TestRequestParser(t *testing.T) {
 			arg: "set asd",
 			expectedCommand: "",
 			expectedArgs: nil,
-			expectedErrText: "expected 2 arguments, got 1",
+			expectedErrText: "expected 2 or 4 arguments, got 1",
+		},
+		{
+			name: "set unknown option error",
+			arg: "set k v XX 10",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "unknown set option XX",
+		},
+		{
+			name: "set invalid expire time error",
+			arg: "set k v EX -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid expire time in set",
+		},
+		{
+			name: "set expire seconds overflow error",
+			arg: "set k v EX 10000000000",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid expire time in set",
+		},
+		{
+			name: "set expire milliseconds overflow error",
+			arg: "set k v PX 9223372036854776",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid expire time in set",
+		},
+		{
+			name: "expire overflow error",
+			arg: "expire k 10000000000",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid expire time in expire",
+		},
+		{
+			name: "expire negative overflow error",
+			arg: "expire k -10000000000",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid expire time in expire",
+		},
+		{
+			name: "expire not integer error",
+			arg: "expire k ten",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not an integer or out of range",
+		},
+		{
+			name: "ttl validate error",
+			arg: "ttl",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 1 argument, got 0",
 		},
 		{
 			name: "delete validate error",
@@ -38,6 +95,237 @@ func TestRequestParser(t *testing.T) {
 			expectedArgs: nil,
 			expectedErrText: "expected 1 argument, got 2",
 		},
+		{
+			name: "lpush validate error",
+			arg: "lpush queue",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected at least 2 arguments, got 1",
+		},
+		{
+			name: "lrange not integer error",
+			arg: "lrange queue first -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not an integer or out of range",
+		},
+		{
+			name: "lrem validate error",
+			arg: "lrem queue 1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 3 arguments, got 2",
+		},
+		{
+			name: "hset validate error",
+			arg: "hset user:1 name Ann email",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected key and field value pairs, got 4 arguments",
+		},
+		{
+			name: "hincrby not integer error",
+			arg: "hincrby user:1 visits many",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not an integer or out of range",
+		},
+		{
+			name: "spop negative count error",
+			arg: "spop tags -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is out of range, must be positive",
+		},
+		{
+			name: "sinterstore validate error",
+			arg: "sinterstore dest",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected at least 2 arguments, got 1",
+		},
+		{
+			name: "zadd missing member error",
+			arg: "zadd board 1 alice 2",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected key and score member pairs, got 4 arguments",
+		},
+		{
+			name: "zadd score not float error",
+			arg: "zadd board high alice",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not a valid float",
+		},
+		{
+			name: "zrange unknown option error",
+			arg: "zrange board 0 -1 WITHVALUES",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "unknown zrange option WITHVALUES",
+		},
+		{
+			name: "zrangebyscore bound not float error",
+			arg: "zrangebyscore board (one +inf",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "min or max is not a float",
+		},
+		{
+			name: "incrby not integer error",
+			arg: "incrby visits 1.5",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not an integer or out of range",
+		},
+		{
+			name: "incrbyfloat not float error",
+			arg: "incrbyfloat price cheap",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not a valid float",
+		},
+		{
+			name: "cas validate error",
+			arg: "cas key old",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 3 arguments, got 2",
+		},
+		{
+			name: "watch validate error",
+			arg: "watch",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected at least 1 argument, got 0",
+		},
+		{
+			name: "scan unknown option error",
+			arg: "scan 0 type string",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "unknown scan option type",
+		},
+		{
+			name: "scan invalid cursor error",
+			arg: "scan -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid cursor",
+		},
+		{
+			name: "range unknown option error",
+			arg: "range a z COUNT 10",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "unknown range option COUNT",
+		},
+		{
+			name: "prefix limit error",
+			arg: "prefix user: LIMIT 0",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "value is not an integer or out of range",
+		},
+		{
+			name: "subscribe without channels error",
+			arg: "subscribe",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected at least 1 argument, got 0",
+		},
+		{
+			name: "publish without message error",
+			arg: "publish news",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 2 arguments, got 1",
+		},
+		{
+			name: "select invalid index error",
+			arg: "select -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid DB index",
+		},
+		{
+			name: "select journaled command error",
+			arg: "select 1 set key",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 2 or 4 arguments, got 1",
+		},
+		{
+			name: "swapdb invalid index error",
+			arg: "swapdb 0 first",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "invalid DB index",
+		},
+		{
+			name: "flushdb arguments error",
+			arg: "flushdb now",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 0 arguments, got 1",
+		},
+		{
+			name: "mset key without value error",
+			arg: "mset k1 v1 k2",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected key value pairs, got 3 arguments",
+		},
+		{
+			name: "mget without keys error",
+			arg: "mget",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected at least 1 argument, got 0",
+		},
+		{
+			name: "getat invalid version error",
+			arg: "getat key yesterday",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected a version or an RFC 3339 timestamp",
+		},
+		{
+			name: "revert negative version error",
+			arg: "revert key -1",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "version is not a positive integer",
+		},
+		{
+			name: "index create without field error",
+			arg: "index create byname ON user: PATH name",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected index create name ON prefix FIELD path",
+		},
+		{
+			name: "unknown index subcommand error",
+			arg: "index rebuild byname",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "unknown index subcommand rebuild",
+		},
+		{
+			name: "malformed binary request error",
+			arg: "*1\r\n$3\r\ngetx\r\n",
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "Malformed binary request",
+		},
+		{
+			name: "binary request validate error",
+			arg: string(network.EncodeRequest("get")),
+			expectedCommand: "",
+			expectedArgs: nil,
+			expectedErrText: "expected 1 argument, got 0",
+		},
 		{
 			name: "unexpected command error",
 			arg: "asdasd qwe",
@@ -90,3 +378,16 @@ func TestRequestParser(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestParserBinary(t *testing.T) {
+	parser := compute.NewRequestParser()
+
+	value := "{\"name\": \"a b\",\n \"blob\": \"\x00\xff\"}"
+	command, args, err := parser.ParseArgs(string(network.EncodeRequest("set", "key with spaces", value)))
+	if err != nil {
+		t.Fatalf("ParseArgs unexpected error: %v", err)
+	}
+	if command != "set" || len(args) != 2 || args[0] != "key with spaces" || args[1] != value {
+		t.Errorf("ParseArgs: unexpected command %q and args %q", command, args)
+	}
+}
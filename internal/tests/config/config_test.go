@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+	"umemory/internal"
+)
+
+func TestParseSize(t *testing.T) {
+	for input, expected := range map[string]internal.Size{
+		"512":  512,
+		"4KB":  4 << 10,
+		"10MB": 10 << 20,
+		"1gb":  1 << 30,
+	} {
+		size, err := internal.ParseSize(input)
+		if err != nil || size != expected {
+			t.Errorf("ParseSize(%q): expected %d, got %d (error: %v)", input, expected, size, err)
+		}
+	}
+
+	for _, input := range []string{"-1KB", "ten", "9999999999GB", "9223372036854775807KB"} {
+		if _, err := internal.ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q): expected invalid size error", input)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package network
+
+import (
+	"testing"
+	"umemory/internal/network"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryRequest(t *testing.T) {
+	args := []string{"set", "key with spaces", "{\"a\": 1,\n \"b\": \"\x00\x01\"}", ""}
+	request := network.EncodeRequest(args...)
+	assert.True(t, network.IsBinaryRequest(request))
+	assert.False(t, network.IsBinaryRequest([]byte("set key value")))
+
+	decoded, err := network.DecodeRequest(request)
+	assert.NoError(t, err)
+	assert.Equal(t, args, decoded)
+
+	assert.Equal(t, "*2\r\n$3\r\nget\r\n$1\r\nk\r\n", string(network.EncodeRequest("get", "k")))
+
+	malformed := []string{
+		"*2\r\n$3\r\nget\r\n",            // incomplete
+		"*1\r\n$3\r\ngetx\r\n",           // wrong length
+		"*1\r\n#3\r\nget\r\n",            // wrong argument prefix
+		"*x\r\n",                         // wrong count
+		"*1\r\n$3\r\nget\r\n$1\r\nk\r\n", // trailing bytes
+		"*1\r\n$9223372036854775807\r\nabc\r\n", // length overflowing the position
+		"*1\r\n$100\r\nabc\r\n",                 // length beyond the request
+		"*1\r\n$-3\r\nabc\r\n",                  // negative length
+	}
+	for _, request := range malformed {
+		_, err := network.DecodeRequest([]byte(request))
+		assert.Error(t, err, request)
+	}
+}
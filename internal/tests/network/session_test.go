@@ -0,0 +1,59 @@
+package network
+
+import (
+	"testing"
+	"umemory/internal/network"
+)
+
+func TestSessionValues(t *testing.T) {
+	session := network.NewSession("client")
+	if other := network.NewSession("other"); other.ID() == session.ID() {
+		t.Errorf("expected unique session ids")
+	}
+
+	session.SetValue("key", 1)
+	if value, _ := session.Value("key").(int); value != 1 {
+		t.Errorf("expected stored value, got %v", session.Value("key"))
+	}
+	session.SetValue("key", nil)
+	if value := session.Value("key"); value != nil {
+		t.Errorf("expected removed value, got %v", value)
+	}
+}
+
+func TestSessionPush(t *testing.T) {
+	session := network.NewSession("client")
+
+	if !session.Push("hello") {
+		t.Fatalf("expected message to be pushed")
+	}
+	if message := <-session.Pushed(); message != "hello" {
+		t.Errorf("expected pushed message, got %q", message)
+	}
+
+	closed := 0
+	session.OnClose(func() {
+		closed++
+	})
+
+	// a client not reading pushed messages is dropped
+	for session.Push("message") {
+	}
+	select {
+	case <-session.Done():
+	default:
+		t.Errorf("expected slow session to be dropped")
+	}
+	if closed != 0 {
+		t.Errorf("expected close functions to be called only on close")
+	}
+
+	session.Close()
+	session.Close()
+	if closed != 1 {
+		t.Errorf("expected close functions to be called once, got %d", closed)
+	}
+	if session.Push("late") {
+		t.Errorf("expected push to a closed session to fail")
+	}
+}
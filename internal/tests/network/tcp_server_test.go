@@ -2,8 +2,10 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
 	"time"
 	"umemory/internal"
@@ -15,10 +17,45 @@ import (
 )
 
 type TestHandler struct {}
-func (h TestHandler) Handle(requestStr string) (string, error) {
+func (h TestHandler) Handle(_ *network.Session, requestStr string) (string, error) {
 	return "Response for " + requestStr, nil
 }
 
+// SessionHandler counts the requests of every connection in its session.
+type SessionHandler struct {}
+func (h SessionHandler) Handle(session *network.Session, requestStr string) (string, error) {
+	count, _ := session.Value("count").(int)
+	session.SetValue("count", count+1)
+
+	return fmt.Sprintf("%s %d", requestStr, count+1), nil
+}
+
+// PushHandler pushes the request back to the client before responding.
+type PushHandler struct {}
+func (h PushHandler) Handle(session *network.Session, requestStr string) (string, error) {
+	session.SetPushMode(true)
+	session.Push("pushed " + requestStr)
+
+	return "", nil
+}
+
+// ArgsHandler responds with the arguments of a binary request joined by |.
+type ArgsHandler struct {}
+func (h ArgsHandler) Handle(session *network.Session, requestStr string) (string, error) {
+	args, err := network.DecodeRequest([]byte(requestStr))
+	if err != nil {
+		return "", err
+	}
+	if args[0] == "fail" {
+		return "", errors.New("failed")
+	}
+	if args[0] == "push" {
+		session.Push(args[1])
+	}
+
+	return strings.Join(args, "|"), nil
+}
+
 func TestTCPServer(t *testing.T) {
 	t.Parallel()
 
@@ -26,17 +63,10 @@ func TestTCPServer(t *testing.T) {
 	defer cancel()
 
 	cfg := internal.Config{
-		Engine: struct{
-			EngineType string `yaml:"engine_type"`
-		}{
+		Engine: internal.EngineConfig{
 			EngineType: "in memory",
 		},
-		Network: struct{
-			Address string `yaml:"address"`
-			MaxConnections int `yaml:"max_connections",omitempty`
-			MaxMessageSize int `yaml:"max_message_size",omitempty`
-			IdleTimeout time.Duration `yaml:"idle_timeout",omitempty`
-		}{
+		Network: internal.NetworkConfig{
 			Address: "localhost:22222",
 			MaxConnections: 2,
 			MaxMessageSize: 1024,
@@ -44,9 +74,7 @@ func TestTCPServer(t *testing.T) {
 	}
 	server, err := network.NewTCPServer(cfg, zap.NewNop())
 	if err != nil {
-		fmt.Errorf("network.NewTCPServer error: %w", err)
-
-		t.Fail()
+		t.Fatalf("network.NewTCPServer error: %s", err.Error())
 	}
 
 	go func() {
@@ -117,3 +145,249 @@ func TestTCPServer(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestTCPServerSession(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := internal.Config{
+		Network: internal.NetworkConfig{
+			Address: "localhost:22223",
+			MaxConnections: 2,
+			MaxMessageSize: 1024,
+		},
+	}
+	server, err := network.NewTCPServer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("network.NewTCPServer error: %s", err.Error())
+	}
+
+	go func() {
+		server.Handle(ctx, SessionHandler{})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	send := func(connection net.Conn, request string) string {
+		if _, err := connection.Write([]byte(request)); err != nil {
+			t.Fatalf("connection.Write error: %s", err.Error())
+		}
+
+		buffer := make([]byte, 1024)
+		size, err := connection.Read(buffer)
+		if err != nil {
+			t.Fatalf("connection.Read error: %s", err.Error())
+		}
+
+		return string(buffer[:size])
+	}
+
+	first, err := net.Dial("tcp", cfg.Network.Address)
+	if err != nil {
+		t.Fatalf("net.Dial error: %s", err.Error())
+	}
+	defer first.Close()
+
+	second, err := net.Dial("tcp", cfg.Network.Address)
+	if err != nil {
+		t.Fatalf("net.Dial error: %s", err.Error())
+	}
+	defer second.Close()
+
+	assert.Equal(t, "first 1", send(first, "first"))
+	assert.Equal(t, "first 2", send(first, "first"))
+	assert.Equal(t, "second 1", send(second, "second"))
+}
+
+func TestTCPServerPush(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := internal.Config{
+		Network: internal.NetworkConfig{
+			Address: "localhost:22224",
+			MaxConnections: 1,
+			MaxMessageSize: 1024,
+			IdleTimeout: 50 * time.Millisecond,
+		},
+	}
+	server, err := network.NewTCPServer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("network.NewTCPServer error: %s", err.Error())
+	}
+
+	go func() {
+		server.Handle(ctx, PushHandler{})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	connection, err := net.Dial("tcp", cfg.Network.Address)
+	if err != nil {
+		t.Fatalf("net.Dial error: %s", err.Error())
+	}
+	defer connection.Close()
+
+	if _, err := connection.Write([]byte("hello")); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+
+	// the connection in push mode outlives the idle timeout
+	time.Sleep(150 * time.Millisecond)
+
+	buffer := make([]byte, 1024)
+	size, err := connection.Read(buffer)
+	if err != nil {
+		t.Fatalf("connection.Read error: %s", err.Error())
+	}
+	assert.Equal(t, "pushed hello\n", string(buffer[:size]))
+
+	if _, err := connection.Write([]byte("again")); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+	size, err = connection.Read(buffer)
+	if err != nil {
+		t.Fatalf("connection.Read error: %s", err.Error())
+	}
+	assert.Equal(t, "pushed again\n", string(buffer[:size]))
+}
+
+func TestTCPServerBinary(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := internal.Config{
+		Network: internal.NetworkConfig{
+			Address: "localhost:22225",
+			MaxConnections: 1,
+			MaxMessageSize: 64,
+		},
+	}
+	server, err := network.NewTCPServer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("network.NewTCPServer error: %s", err.Error())
+	}
+
+	go func() {
+		server.Handle(ctx, ArgsHandler{})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	connection, err := net.Dial("tcp", cfg.Network.Address)
+	if err != nil {
+		t.Fatalf("net.Dial error: %s", err.Error())
+	}
+	defer connection.Close()
+
+	// a request split across writes is read whole, the request following
+	// it in the same write is kept
+	request := network.EncodeRequest("set", "key", "a value\nwith spaces")
+	requests := append(request[7:], network.EncodeRequest("get", "key")...)
+	if _, err := connection.Write(request[:7]); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := connection.Write(requests); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+
+	expected := "$27\r\nset|key|a value\nwith spaces\r\n$7\r\nget|key\r\n"
+	buffer := make([]byte, 1024)
+	received := ""
+	for len(received) < len(expected) {
+		size, err := connection.Read(buffer)
+		if err != nil {
+			t.Fatalf("connection.Read error: %s", err.Error())
+		}
+		received += string(buffer[:size])
+	}
+	assert.Equal(t, expected, received)
+
+	if _, err := connection.Write(network.EncodeRequest("fail")); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+	size, err := connection.Read(buffer)
+	if err != nil {
+		t.Fatalf("connection.Read error: %s", err.Error())
+	}
+	assert.Equal(t, "-6\r\nfailed\r\n", string(buffer[:size]))
+
+	// a request larger than the buffer is rejected
+	if _, err := connection.Write(network.EncodeRequest("set", "key", strings.Repeat("v", 64))); err != nil {
+		t.Fatalf("connection.Write error: %s", err.Error())
+	}
+	size, err = connection.Read(buffer)
+	if err != nil {
+		t.Fatalf("connection.Read error: %s", err.Error())
+	}
+	assert.Equal(t, "-34\r\nRead data error: small buffer size\r\n", string(buffer[:size]))
+
+	// the rest of the request cannot be read as a request, the connection
+	// is closed
+	_ = connection.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = connection.Read(buffer)
+	assert.Error(t, err)
+}
+
+func TestTCPClientSendArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := internal.Config{
+		Network: internal.NetworkConfig{
+			Address: "localhost:22226",
+			MaxConnections: 1,
+			MaxMessageSize: 1024,
+		},
+	}
+	server, err := network.NewTCPServer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("network.NewTCPServer error: %s", err.Error())
+	}
+
+	go func() {
+		server.Handle(ctx, ArgsHandler{})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	connection, err := net.Dial("tcp", cfg.Network.Address)
+	if err != nil {
+		t.Fatalf("net.Dial error: %s", err.Error())
+	}
+
+	maxMessageSize := 1024
+	idleTimeout := time.Second
+	client, err := network.NewTCPClient(network.TCPClientConfig{MaxMessageSize: &maxMessageSize, IdleTimeout: &idleTimeout}, connection, zap.NewNop())
+	if err != nil {
+		t.Fatalf("network.NewTCPClient error: %s", err.Error())
+	}
+	defer client.Close()
+
+	res, err := client.SendArgs("set", "key", "line 1\r\nline 2\x00")
+	assert.NoError(t, err)
+	assert.Equal(t, "set|key|line 1\r\nline 2\x00", res)
+
+	_, err = client.SendArgs("fail")
+	var responseErr *network.ResponseError
+	assert.ErrorAs(t, err, &responseErr)
+	assert.Equal(t, "failed", err.Error())
+
+	// the message pushed before the response is kept for ReceiveMessage
+	res, err = client.SendArgs("push", "a message")
+	assert.NoError(t, err)
+	assert.Equal(t, "push|a message", res)
+
+	message, err := client.ReceiveMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "a message", message)
+}
@@ -0,0 +1,71 @@
+package pubsub
+
+import (
+	"testing"
+	"umemory/internal/pubsub"
+)
+
+type testSubscriber struct {
+	id       uint64
+	messages []pubsub.Message
+	closed   bool
+}
+
+func (s *testSubscriber) ID() uint64 {
+	return s.id
+}
+
+func (s *testSubscriber) Deliver(message pubsub.Message) bool {
+	if s.closed {
+		return false
+	}
+	s.messages = append(s.messages, message)
+
+	return true
+}
+
+func TestBroker(t *testing.T) {
+	broker := pubsub.NewBroker()
+	first := &testSubscriber{id: 1}
+	second := &testSubscriber{id: 2}
+
+	broker.Subscribe(first, "news")
+	broker.Subscribe(first, "news")
+	broker.Subscribe(second, "news")
+	broker.PSubscribe(second, "n*")
+	broker.PSubscribe(second, "sport.*")
+
+	if delivered := broker.Publish("news", "hello"); delivered != 3 {
+		t.Errorf("expected 3 deliveries, got %d", delivered)
+	}
+	if delivered := broker.Publish("sport.football", "goal"); delivered != 1 {
+		t.Errorf("expected 1 delivery, got %d", delivered)
+	}
+	if delivered := broker.Publish("weather", "rain"); delivered != 0 {
+		t.Errorf("expected no deliveries, got %d", delivered)
+	}
+
+	if len(first.messages) != 1 || first.messages[0] != (pubsub.Message{Channel: "news", Payload: "hello"}) {
+		t.Errorf("unexpected messages of the first subscriber: %v", first.messages)
+	}
+	if len(second.messages) != 3 || second.messages[2] != (pubsub.Message{Pattern: "sport.*", Channel: "sport.football", Payload: "goal"}) {
+		t.Errorf("unexpected messages of the second subscriber: %v", second.messages)
+	}
+
+	if channels, patterns := broker.Stats(); channels != 1 || patterns != 2 {
+		t.Errorf("expected 1 channel and 2 patterns, got %d and %d", channels, patterns)
+	}
+
+	broker.Unsubscribe(first, "news")
+	broker.PUnsubscribe(second, "n*")
+	second.closed = true
+	if delivered := broker.Publish("news", "bye"); delivered != 0 {
+		t.Errorf("expected no deliveries to a closed subscriber, got %d", delivered)
+	}
+
+	broker.Unsubscribe(second, "news")
+	broker.PUnsubscribe(second, "sport.*")
+	if channels, patterns := broker.Stats(); channels != 0 || patterns != 0 {
+		t.Errorf("expected no subscriptions, got %d channels and %d patterns", channels, patterns)
+	}
+}
@@ -0,0 +1,234 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/compute"
+	"umemory/internal/replication"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+type node struct {
+	storage *storage.InMemoryStorage
+	handler *compute.ComputeHandler
+}
+
+func newNode() node {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	return node{
+		storage: s,
+		handler: compute.NewComputeHandler(s, compute.NewRequestParser(), zap.NewNop()),
+	}
+}
+
+func replicationConfig(address string) internal.Config {
+	return internal.Config{
+		Replication: internal.ReplicationConfig{
+			Address:           address,
+			LeaderAddress:     address,
+			BacklogSize:       100,
+			ReconnectInterval: 10 * time.Millisecond,
+		},
+	}
+}
+
+func startLeader(t *testing.T, ctx context.Context, n node, cfg internal.Config) *replication.Leader {
+	t.Helper()
+
+	leader, err := replication.NewLeader(cfg, n.storage, n.handler, zap.NewNop())
+	if err != nil {
+		t.Fatalf("replication.NewLeader error: %s", err.Error())
+	}
+	n.handler.SetReplication(leader)
+	go leader.Run(ctx)
+
+	return leader
+}
+
+func startFollower(t *testing.T, ctx context.Context, n node, cfg internal.Config) *replication.Follower {
+	t.Helper()
+
+	follower, err := replication.NewFollower(cfg, n.storage, n.handler, zap.NewNop())
+	if err != nil {
+		t.Fatalf("replication.NewFollower error: %s", err.Error())
+	}
+	n.handler.SetReplication(follower)
+	go follower.Run(ctx)
+
+	return follower
+}
+
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", description)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func hasValue(n node, key, expected string) func() bool {
+	return func() bool {
+		value, found := n.storage.Get(key)
+
+		return found && value == expected
+	}
+}
+
+func infoValue(fields []compute.InfoField, name string) string {
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+
+	return ""
+}
+
+func TestReplicationFullSyncAndStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := replicationConfig("localhost:22301")
+	leaderNode := newNode()
+	followerNode := newNode()
+
+	leaderNode.handler.Handle(nil, "set existing value")
+	leader := startLeader(t, ctx, leaderNode, cfg)
+	follower := startFollower(t, ctx, followerNode, cfg)
+
+	waitFor(t, "full sync", hasValue(followerNode, "existing", "value"))
+
+	leaderNode.handler.Handle(nil, "set key1 value1")
+	leaderNode.handler.Handle(nil, "set volatile value EX 100")
+	leaderNode.handler.Handle(nil, "delete existing")
+
+	waitFor(t, "streamed writes", hasValue(followerNode, "key1", "value1"))
+	waitFor(t, "streamed delete", func() bool {
+		_, found := followerNode.storage.Get("existing")
+
+		return !found
+	})
+	if ttl, found := followerNode.storage.TTL("volatile"); !found || ttl <= 0 {
+		t.Errorf("expected replicated key with ttl, got %v (found: %v)", ttl, found)
+	}
+
+	waitFor(t, "follower offset", func() bool {
+		return infoValue(follower.Info(), "offset") == infoValue(leader.Info(), "offset")
+	})
+	if infoValue(follower.Info(), "link_status") != "up" {
+		t.Errorf("expected follower link to be up")
+	}
+	if infoValue(leader.Info(), "connected_followers") != "1" {
+		t.Errorf("expected 1 connected follower, got %v", leader.Info())
+	}
+}
+
+func TestReplicationFollowerIsReadOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := replicationConfig("localhost:22302")
+	followerNode := newNode()
+	startFollower(t, ctx, followerNode, cfg)
+
+	_, err := followerNode.handler.Handle(nil, "set key value")
+	if err == nil || !strings.Contains(err.Error(), "read-only replica") {
+		t.Errorf("expected read-only replica error, got: %v", err)
+	}
+
+	res, _ := followerNode.handler.Handle(nil, "info replication")
+	if !strings.Contains(res, "role:follower") || !strings.Contains(res, "leader_address:localhost:22302") {
+		t.Errorf("unexpected replication info: %v", res)
+	}
+}
+
+func TestReplicationPartialResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := replicationConfig("localhost:22303")
+	leaderNode := newNode()
+	startLeader(t, ctx, leaderNode, cfg)
+
+	followerNode := newNode()
+	followerCtx, stopFollower := context.WithCancel(ctx)
+	follower := startFollower(t, followerCtx, followerNode, cfg)
+
+	leaderNode.handler.Handle(nil, "set key1 value1")
+	waitFor(t, "first write", hasValue(followerNode, "key1", "value1"))
+
+	stopFollower()
+	waitFor(t, "follower disconnect", func() bool {
+		return infoValue(follower.Info(), "link_status") == "down"
+	})
+
+	leaderNode.handler.Handle(nil, "set key2 value2")
+	// a key only the follower has survives a partial resync, but not a full one
+	followerNode.storage.Set("local", "value")
+
+	go follower.Run(ctx)
+	waitFor(t, "missed write", hasValue(followerNode, "key2", "value2"))
+
+	if _, found := followerNode.storage.Get("local"); !found {
+		t.Errorf("expected partial resync without reloading the keyspace")
+	}
+}
+
+// failingApplier fails to apply the first write of the key.
+type failingApplier struct {
+	applier replication.Applier
+	key     string
+	failed  atomic.Bool
+}
+
+func (a *failingApplier) Apply(command string, args []string) error {
+	if len(args) > 0 && args[0] == a.key && a.failed.CompareAndSwap(false, true) {
+		return errors.New("apply failed")
+	}
+
+	return a.applier.Apply(command, args)
+}
+
+func TestReplicationApplyErrorForcesFullResync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := replicationConfig("localhost:22304")
+	leaderNode := newNode()
+	startLeader(t, ctx, leaderNode, cfg)
+
+	followerNode := newNode()
+	applier := &failingApplier{applier: followerNode.handler, key: "broken"}
+	follower, err := replication.NewFollower(cfg, followerNode.storage, applier, zap.NewNop())
+	if err != nil {
+		t.Fatalf("replication.NewFollower error: %s", err.Error())
+	}
+	followerNode.handler.SetReplication(follower)
+	go follower.Run(ctx)
+
+	leaderNode.handler.Handle(nil, "set key1 value1")
+	waitFor(t, "first write", hasValue(followerNode, "key1", "value1"))
+
+	// a key only the follower has is dropped by a full resync
+	followerNode.storage.Set("local", "value")
+	leaderNode.handler.Handle(nil, "set broken value")
+	leaderNode.handler.Handle(nil, "set key2 value2")
+
+	waitFor(t, "full resync", hasValue(followerNode, "broken", "value"))
+	waitFor(t, "write after resync", hasValue(followerNode, "key2", "value2"))
+	if _, found := followerNode.storage.Get("local"); found {
+		t.Errorf("expected the keyspace to be reloaded after the apply error")
+	}
+}
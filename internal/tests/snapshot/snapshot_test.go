@@ -0,0 +1,331 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/snapshot"
+	"umemory/internal/storage"
+	"umemory/internal/wal"
+
+	"go.uber.org/zap"
+)
+
+type writeBlocker struct {
+	calls int
+}
+
+func (b *writeBlocker) BlockWrites(fn func() error) error {
+	b.calls++
+
+	return fn()
+}
+
+func newStorage() *storage.InMemoryStorage {
+	return storage.NewInMemoryStorage(internal.Config{})
+}
+
+func newManager(t *testing.T, directory string, s snapshot.Storage) *snapshot.Manager {
+	t.Helper()
+
+	cfg := internal.Config{
+		Snapshot: internal.SnapshotConfig{Directory: directory, Retain: 2},
+	}
+	manager, err := snapshot.NewManager(cfg, s, zap.NewNop())
+	if err != nil {
+		t.Fatalf("snapshot.NewManager error: %s", err.Error())
+	}
+
+	return manager
+}
+
+func snapshotFiles(t *testing.T, directory string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(directory, "*.snap"))
+	if err != nil {
+		t.Fatalf("Glob error: %s", err.Error())
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+func TestSnapshotSaveAndLoad(t *testing.T) {
+	directory := t.TempDir()
+
+	source := newStorage()
+	source.Set("key1", "value1")
+	source.Set("key with spaces", "value\nwith newline")
+	source.SetWithTTL("volatile", "value", time.Hour)
+	source.SetWithTTL("expired", "value", time.Millisecond)
+	source.RPush("queue", []string{"job1", "job2", "job3"})
+	time.Sleep(5 * time.Millisecond)
+
+	if err := newManager(t, directory, source).Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+
+	target := newStorage()
+	target.Set("stale", "value")
+	if _, err := newManager(t, directory, target).Load(); err != nil {
+		t.Fatalf("Load error: %s", err.Error())
+	}
+
+	for key, expected := range map[string]string{
+		"key1":            "value1",
+		"key with spaces": "value\nwith newline",
+		"volatile":        "value",
+	} {
+		if value, found := target.Get(key); !found || value != expected {
+			t.Errorf("key %q: expected %q, got %q (found: %v)", key, expected, value, found)
+		}
+	}
+	if ttl, found := target.TTL("volatile"); !found || ttl <= 0 {
+		t.Errorf("volatile key expected to keep its ttl, got %v", ttl)
+	}
+	if values, _ := target.LRange("queue", 0, -1); strings.Join(values, ",") != "job1,job2,job3" {
+		t.Errorf("list expected to keep its elements, got %v", values)
+	}
+	for _, key := range []string{"expired", "stale"} {
+		if _, found := target.Get(key); found {
+			t.Errorf("key %q expected to be missing after load", key)
+		}
+	}
+}
+
+func TestSnapshotRefusesCorrupted(t *testing.T) {
+	directory := t.TempDir()
+
+	source := newStorage()
+	manager := newManager(t, directory, source)
+
+	source.Set("key", "old")
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+	source.Set("key", "new")
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+
+	paths := snapshotFiles(t, directory)
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(paths))
+	}
+	data, _ := os.ReadFile(paths[1])
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(paths[1], data, 0o644)
+
+	target := newStorage()
+	if _, err := newManager(t, directory, target).Load(); err != nil {
+		t.Fatalf("Load error: %s", err.Error())
+	}
+	if value, _ := target.Get("key"); value != "old" {
+		t.Errorf("expected fallback to the previous snapshot, got value %q", value)
+	}
+}
+
+func TestSnapshotRetain(t *testing.T) {
+	directory := t.TempDir()
+
+	manager := newManager(t, directory, newStorage())
+	for i := 0; i < 4; i++ {
+		if err := manager.Save(); err != nil {
+			t.Fatalf("Save error: %s", err.Error())
+		}
+	}
+
+	if paths := snapshotFiles(t, directory); len(paths) != 2 {
+		t.Errorf("expected 2 retained snapshots, got %d", len(paths))
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(directory, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("expected no temporary files, got %v", tmp)
+	}
+}
+
+func TestSnapshotBlocksWritesWithoutWAL(t *testing.T) {
+	blocker := &writeBlocker{}
+	manager := newManager(t, t.TempDir(), newStorage())
+	manager.SetBlocker(blocker)
+
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+	if blocker.calls != 1 {
+		t.Errorf("expected writes to be blocked once, got %d", blocker.calls)
+	}
+}
+
+func TestSnapshotCutsWAL(t *testing.T) {
+	snapshotDirectory := t.TempDir()
+	walDirectory := t.TempDir()
+
+	cfg := internal.Config{WAL: internal.WALConfig{Directory: walDirectory}}
+	writeAheadLog, err := wal.NewWAL(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("wal.NewWAL error: %s", err.Error())
+	}
+
+	source := newStorage()
+	blocker := &writeBlocker{}
+	manager := newManager(t, snapshotDirectory, source)
+	manager.SetWAL(writeAheadLog)
+	manager.SetBlocker(blocker)
+
+	source.Set("key1", "value1")
+	writeAheadLog.Append("set", []string{"key1", "value1"})
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+	source.Set("key2", "value2")
+	writeAheadLog.Append("set", []string{"key2", "value2"})
+	writeAheadLog.Close()
+
+	if blocker.calls != 1 {
+		t.Errorf("expected writes to be blocked once, got %d", blocker.calls)
+	}
+
+	target := newStorage()
+	walSegment, err := newManager(t, snapshotDirectory, target).Load()
+	if err != nil {
+		t.Fatalf("Load error: %s", err.Error())
+	}
+
+	var replayed []string
+	writeAheadLog, _ = wal.NewWAL(cfg, zap.NewNop())
+	err = writeAheadLog.Replay(walSegment, func(command string, args []string) error {
+		replayed = append(replayed, args[0])
+		target.Set(args[0], args[1])
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay error: %s", err.Error())
+	}
+
+	if len(replayed) != 1 || replayed[0] != "key2" {
+		t.Errorf("expected only key2 to be replayed, got %v", replayed)
+	}
+	for _, key := range []string{"key1", "key2"} {
+		if _, found := target.Get(key); !found {
+			t.Errorf("key %s expected to be restored", key)
+		}
+	}
+}
+
+func TestSnapshotFallbackReplaysWAL(t *testing.T) {
+	snapshotDirectory := t.TempDir()
+	walDirectory := t.TempDir()
+
+	cfg := internal.Config{WAL: internal.WALConfig{Directory: walDirectory}}
+	writeAheadLog, err := wal.NewWAL(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("wal.NewWAL error: %s", err.Error())
+	}
+
+	source := newStorage()
+	manager := newManager(t, snapshotDirectory, source)
+	manager.SetWAL(writeAheadLog)
+	manager.SetBlocker(&writeBlocker{})
+
+	write := func(key string) {
+		source.Set(key, "value")
+		writeAheadLog.Append("set", []string{key, "value"})
+	}
+	write("key1")
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+	write("key2")
+	if err := manager.Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+	write("key3")
+	writeAheadLog.Close()
+
+	paths := snapshotFiles(t, snapshotDirectory)
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(paths))
+	}
+	data, _ := os.ReadFile(paths[1])
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(paths[1], data, 0o644)
+
+	target := newStorage()
+	walSegment, err := newManager(t, snapshotDirectory, target).Load()
+	if err != nil {
+		t.Fatalf("Load error: %s", err.Error())
+	}
+
+	var replayed []string
+	writeAheadLog, _ = wal.NewWAL(cfg, zap.NewNop())
+	err = writeAheadLog.Replay(walSegment, func(command string, args []string) error {
+		replayed = append(replayed, args[0])
+		target.Set(args[0], args[1])
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay error: %s", err.Error())
+	}
+
+	if strings.Join(replayed, ",") != "key2,key3" {
+		t.Errorf("expected writes after the older snapshot to be replayed, got %v", replayed)
+	}
+	for _, key := range []string{"key1", "key2", "key3"} {
+		if _, found := target.Get(key); !found {
+			t.Errorf("key %s expected to be restored", key)
+		}
+	}
+}
+
+func TestSnapshotBackgroundSave(t *testing.T) {
+	directory := t.TempDir()
+
+	source := newStorage()
+	source.Set("key", "value")
+	manager := newManager(t, directory, source)
+
+	if err := manager.BackgroundSave(); err != nil {
+		t.Fatalf("BackgroundSave error: %s", err.Error())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(snapshotFiles(t, directory)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(snapshotFiles(t, directory)) != 1 {
+		t.Errorf("expected background save to write a snapshot")
+	}
+}
+
+func TestSnapshotKeepsIndexes(t *testing.T) {
+	directory := t.TempDir()
+
+	source := newStorage()
+	source.Set("user:1", `{"email":"alice@example.com"}`)
+	definition := storage.IndexDefinition{Name: "byemail", Prefix: "user:", Path: "email"}
+	if err := source.CreateIndex(definition); err != nil {
+		t.Fatalf("CreateIndex error: %s", err.Error())
+	}
+	if err := newManager(t, directory, source).Save(); err != nil {
+		t.Fatalf("Save error: %s", err.Error())
+	}
+
+	target := newStorage()
+	if _, err := newManager(t, directory, target).Load(); err != nil {
+		t.Fatalf("Load error: %s", err.Error())
+	}
+
+	if definitions := target.Indexes(); len(definitions) != 1 || definitions[0] != definition {
+		t.Fatalf("expected the index to be restored, got %+v", definitions)
+	}
+	if keys, err := target.Find("byemail", "alice@example.com"); err != nil || len(keys) != 1 || keys[0] != "user:1" {
+		t.Errorf("expected the restored key to be found, got %v (err: %v)", keys, err)
+	}
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func newCompressingStorage(codec string) *storage.InMemoryStorage {
+	return storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{
+			ShardsCount: 4,
+			Compression: internal.CompressionConfig{Threshold: 1024, Codec: codec},
+		},
+	})
+}
+
+func jsonDocument(size int) string {
+	var builder strings.Builder
+	builder.WriteString("[")
+	for i := 0; builder.Len() < size; i++ {
+		fmt.Fprintf(&builder, "{\"id\": %d, \"name\": \"user %d\", \"active\": true},\n", i, i)
+	}
+	builder.WriteString("]")
+
+	return builder.String()
+}
+
+func TestInMemoryStorageCompression(t *testing.T) {
+	for _, codec := range []string{storage.FlateCodec, storage.GzipCodec} {
+		s := newCompressingStorage(codec)
+		document := jsonDocument(8192)
+
+		s.Set("small", "value")
+		s.Set("document", document)
+		if value, found := s.Get("document"); !found || value != document {
+			t.Fatalf("%s: expected the document back, found: %v", codec, found)
+		}
+
+		stats := s.CompressionStats()
+		if stats.Codec != codec || stats.Threshold != 1024 || stats.Values != 1 || stats.RawBytes != int64(len(document)) {
+			t.Errorf("%s: unexpected stats %+v", codec, stats)
+		}
+		if stats.CompressedBytes == 0 || stats.CompressedBytes*4 > stats.RawBytes {
+			t.Errorf("%s: expected the document to be compressed, got %+v", codec, stats)
+		}
+		if used := s.MemoryStats().Used; used >= int64(len(document)) {
+			t.Errorf("%s: expected used memory to count the compressed size, got %d", codec, used)
+		}
+
+		// snapshots hold the original values
+		records := s.Dump()
+		s.Load(records)
+		if value, _ := s.Get("document"); value != document {
+			t.Errorf("%s: expected the document to survive dump and load", codec)
+		}
+		if restored := s.CompressionStats(); restored != stats {
+			t.Errorf("%s: expected the same stats after load, got %+v", codec, restored)
+		}
+		for _, record := range records {
+			if record.Key == "document" && record.Value != document {
+				t.Errorf("%s: expected the dumped document to be decompressed", codec)
+			}
+		}
+
+		s.Set("document", "small again")
+		if stats := s.CompressionStats(); stats.Values != 0 || stats.RawBytes != 0 || stats.CompressedBytes != 0 {
+			t.Errorf("%s: expected no compressed values after overwrite, got %+v", codec, stats)
+		}
+	}
+}
+
+func TestInMemoryStorageCompressionOperations(t *testing.T) {
+	s := newCompressingStorage("")
+	document := jsonDocument(4096)
+
+	if ok, err := s.CompareAndSet("missing", "", document); ok || err != nil {
+		t.Errorf("expected cas of a missing key to fail, got %v (err: %v)", ok, err)
+	}
+	s.MSet([]string{"a", document, "b", document + "b"})
+	values, found := s.MGet([]string{"a", "b"})
+	if !found[0] || !found[1] || values[0] != document || values[1] != document+"b" {
+		t.Errorf("expected mget to decompress the values")
+	}
+	if ok, err := s.CompareAndSet("a", document, "replaced"); !ok || err != nil {
+		t.Errorf("expected cas to compare with the decompressed value, got %v (err: %v)", ok, err)
+	}
+	if stats := s.CompressionStats(); stats.Codec != storage.FlateCodec || stats.Values != 1 {
+		t.Errorf("expected a single compressed value with the default codec, got %+v", stats)
+	}
+
+	s.Delete("b")
+	if stats := s.CompressionStats(); stats.Values != 0 || stats.CompressedBytes != 0 {
+		t.Errorf("expected no compressed values after delete, got %+v", stats)
+	}
+
+	// values which do not get smaller are kept as they are
+	random := make([]byte, 4096)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("rand.Read error: %v", err)
+	}
+	s.Set("random", string(random))
+	if value, _ := s.Get("random"); !bytes.Equal([]byte(value), random) {
+		t.Errorf("expected the random value back")
+	}
+	if stats := s.CompressionStats(); stats.Values != 0 {
+		t.Errorf("expected incompressible value to be kept raw, got %+v", stats)
+	}
+}
+
+// trimCodec drops trailing spaces, it restores exactly 1024 of them.
+type trimCodec struct{}
+
+func (trimCodec) Compress(data []byte) ([]byte, error) {
+	return bytes.TrimRight(data, " "), nil
+}
+
+func (trimCodec) Decompress(data []byte) ([]byte, error) {
+	return append(data, bytes.Repeat([]byte(" "), 1024)...), nil
+}
+
+func TestEngineRegistryCompressionCodec(t *testing.T) {
+	cfg := internal.Config{Engine: internal.EngineConfig{
+		EngineType:  storage.ShardedEngine,
+		Compression: internal.CompressionConfig{Threshold: 1024, Codec: "zstd"},
+	}}
+	if _, err := storage.NewEngine(cfg, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "zstd") {
+		t.Errorf("expected unknown compression codec error, got: %v", err)
+	}
+
+	storage.RegisterCodec("trim", trimCodec{})
+	cfg.Engine.Compression.Codec = "trim"
+	engine, err := storage.NewEngine(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewEngine error: %v", err)
+	}
+
+	value := "value" + strings.Repeat(" ", 1024)
+	engine.Set("key", value)
+	if found, _ := engine.Get("key"); found != value {
+		t.Errorf("expected the value decompressed by the registered codec")
+	}
+	if stats := engine.(*storage.InMemoryStorage).CompressionStats(); stats.Codec != "trim" || stats.CompressedBytes != 5 {
+		t.Errorf("expected the value compressed by the registered codec, got %+v", stats)
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func TestInMemoryStorageIncrBy(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	if value, err := s.IncrBy("visits", 5); err != nil || value != 5 {
+		t.Fatalf("expected missing key to be set to 5, got %d (err: %v)", value, err)
+	}
+	if value, _ := s.IncrBy("visits", -7); value != -2 {
+		t.Errorf("expected -2, got %d", value)
+	}
+	if value, _ := s.Get("visits"); value != "-2" {
+		t.Errorf("expected stored -2, got %q", value)
+	}
+
+	s.Set("name", "Ann")
+	if _, err := s.IncrBy("name", 1); !errors.Is(err, storage.ErrNotInteger) {
+		t.Errorf("expected not integer error, got %v", err)
+	}
+
+	s.Set("max", strconv.FormatInt(math.MaxInt64, 10))
+	if _, err := s.IncrBy("max", 1); !errors.Is(err, storage.ErrOverflow) {
+		t.Errorf("expected overflow error, got %v", err)
+	}
+	if value, _ := s.Get("max"); value != strconv.FormatInt(math.MaxInt64, 10) {
+		t.Errorf("expected value to be kept on overflow, got %q", value)
+	}
+
+	s.RPush("queue", []string{"a"})
+	if _, err := s.IncrBy("queue", 1); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got %v", err)
+	}
+}
+
+func TestInMemoryStorageIncrByFloat(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	s.Set("price", "10.5")
+	if value, err := s.IncrByFloat("price", 0.1); err != nil || value != 10.6 {
+		t.Fatalf("expected 10.6, got %v (err: %v)", value, err)
+	}
+	if value, _ := s.Get("price"); value != "10.6" {
+		t.Errorf("expected stored 10.6, got %q", value)
+	}
+	if value, _ := s.IncrByFloat("price", -10.6); value != 0 {
+		t.Errorf("expected 0, got %v", value)
+	}
+
+	s.Set("name", "Ann")
+	if _, err := s.IncrByFloat("name", 1); !errors.Is(err, storage.ErrNotFloat) {
+		t.Errorf("expected not float error, got %v", err)
+	}
+	if _, err := s.IncrByFloat("price", math.Inf(1)); !errors.Is(err, storage.ErrNaNOrInf) {
+		t.Errorf("expected infinity error, got %v", err)
+	}
+}
+
+func TestInMemoryStorageIncrByKeepsTTL(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	s.SetWithTTL("counter", "1", time.Hour)
+	s.IncrBy("counter", 1)
+
+	if ttl, found := s.TTL("counter"); !found || ttl <= 0 {
+		t.Errorf("expected ttl to be kept, got %v", ttl)
+	}
+
+	s.SetWithTTL("expired", "10", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if value, _ := s.IncrBy("expired", 1); value != 1 {
+		t.Errorf("expected expired key to be treated as missing, got %d", value)
+	}
+	if ttl, _ := s.TTL("expired"); ttl != -1 {
+		t.Errorf("expected no ttl, got %v", ttl)
+	}
+}
+
+func TestInMemoryStorageConcurrentIncrBy(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	const (
+		workers    = 8
+		iterations = 500
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				if _, err := s.IncrBy("counter", 1); err != nil {
+					t.Errorf("unexpected error: %v", err)
+
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := s.Get("counter"); value != strconv.Itoa(workers*iterations) {
+		t.Errorf("expected %d, got %s", workers*iterations, value)
+	}
+}
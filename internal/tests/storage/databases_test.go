@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func newDatabases(t *testing.T, engine internal.EngineConfig) *storage.Databases {
+	t.Helper()
+
+	databases, err := storage.NewDatabases(internal.Config{Engine: engine}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+
+	return databases
+}
+
+func TestDatabases(t *testing.T) {
+	databases := newDatabases(t, internal.EngineConfig{EngineType: storage.ShardedEngine, Databases: 4})
+	if databases.Count() != 4 {
+		t.Fatalf("expected 4 databases, got %d", databases.Count())
+	}
+	if defaults := newDatabases(t, internal.EngineConfig{EngineType: storage.InMemoryEngine}); defaults.Count() != 16 {
+		t.Errorf("expected 16 databases by default, got %d", defaults.Count())
+	}
+
+	databases.DB(0).Set("key", "first")
+	databases.DB(1).Set("key", "second")
+	if value, _ := databases.DB(0).Get("key"); value != "first" {
+		t.Errorf("expected databases to be separate, got %v", value)
+	}
+
+	databases.Swap(0, 1)
+	if value, _ := databases.DB(0).Get("key"); value != "second" {
+		t.Errorf("expected swapped database, got %v", value)
+	}
+
+	first := databases.DB(0).(*storage.InMemoryStorage)
+	first.SetWithTTL("volatile", "value", time.Minute)
+	if moved, err := databases.Move("volatile", 0, 2); err != nil || !moved {
+		t.Fatalf("expected key to be moved, got %v (err: %v)", moved, err)
+	}
+	if _, found := first.Get("volatile"); found {
+		t.Errorf("expected moved key to be removed from the source database")
+	}
+	if ttl, found := databases.DB(2).(*storage.InMemoryStorage).TTL("volatile"); !found || ttl <= 0 {
+		t.Errorf("expected moved key to keep its expiration, got %v (found: %v)", ttl, found)
+	}
+	if moved, _ := databases.Move("key", 0, 1); moved {
+		t.Errorf("expected key existing in the target database not to be moved")
+	}
+	if moved, _ := databases.Move("missing", 0, 1); moved {
+		t.Errorf("expected missing key not to be moved")
+	}
+
+	first.Flush()
+	if _, found := first.Get("key"); found {
+		t.Errorf("expected flushed database to be empty")
+	}
+}
+
+func TestDatabasesDumpAndLoad(t *testing.T) {
+	databases := newDatabases(t, internal.EngineConfig{EngineType: storage.OrderedEngine, Databases: 3})
+	databases.DB(0).Set("a", "1")
+	databases.DB(2).Set("b", "2")
+
+	var buf bytes.Buffer
+	for _, record := range databases.Dump() {
+		if err := storage.WriteRecord(&buf, record); err != nil {
+			t.Fatalf("WriteRecord error: %v", err)
+		}
+	}
+
+	var records []storage.Record
+	reader := bufio.NewReader(&buf)
+	for reader.Buffered() > 0 || buf.Len() > 0 {
+		record, err := storage.ReadRecord(reader)
+		if err != nil {
+			t.Fatalf("ReadRecord error: %v", err)
+		}
+		records = append(records, record)
+	}
+	records = append(records, storage.Record{DB: 5, Key: "lost", Type: storage.StringType, Value: "3"})
+
+	restored := newDatabases(t, internal.EngineConfig{EngineType: storage.OrderedEngine, Databases: 3})
+	restored.DB(1).Set("stale", "value")
+	restored.Load(records)
+
+	if value, found := restored.DB(0).Get("a"); !found || value != "1" {
+		t.Errorf("expected key of the first database, got %v (found: %v)", value, found)
+	}
+	if value, found := restored.DB(2).Get("b"); !found || value != "2" {
+		t.Errorf("expected key of the third database, got %v (found: %v)", value, found)
+	}
+	if _, found := restored.DB(1).Get("stale"); found {
+		t.Errorf("expected load to replace the databases")
+	}
+}
+
+func TestDatabasesSharedMemoryLimit(t *testing.T) {
+	databases := newDatabases(t, internal.EngineConfig{
+		EngineType:     storage.InMemoryEngine,
+		Databases:      2,
+		MaxMemory:      1024,
+		EvictionPolicy: storage.AllKeysLRU,
+	})
+	first := databases.DB(0).(*storage.InMemoryStorage)
+	second := databases.DB(1).(*storage.InMemoryStorage)
+
+	first.Set("big", string(make([]byte, 700)))
+	second.Set("other", string(make([]byte, 700)))
+	if used := first.MemoryStats().Used; used != second.MemoryStats().Used || used <= 1024 {
+		t.Errorf("expected memory used by both databases, got %d", used)
+	}
+
+	evicted, ok := second.FreeMemory()
+	if !ok || len(evicted) != 1 || evicted[0] != "other" {
+		t.Errorf("expected key of the second database to be evicted, got %v (ok: %v)", evicted, ok)
+	}
+	if stats := first.MemoryStats(); stats.EvictedKeys != 1 || stats.Used > 1024 {
+		t.Errorf("expected shared memory stats, got %+v", stats)
+	}
+}
+
+func TestDatabasesExpirationHook(t *testing.T) {
+	databases := newDatabases(t, internal.EngineConfig{EngineType: storage.InMemoryEngine, Databases: 2})
+
+	var expired []int
+	databases.OnExpired(func(db int, key string) {
+		expired = append(expired, db)
+	})
+
+	databases.DB(0).(*storage.InMemoryStorage).SetWithTTL("key", "value", time.Millisecond)
+	databases.Swap(0, 1)
+	time.Sleep(5 * time.Millisecond)
+	databases.DB(1).Get("key")
+
+	if len(expired) != 1 || expired[0] != 1 {
+		t.Errorf("expected expiration in the swapped database, got %v", expired)
+	}
+}
+
+func TestDatabasesExpirationDuringSwapAndDump(t *testing.T) {
+	databases := newDatabases(t, internal.EngineConfig{EngineType: storage.InMemoryEngine, Databases: 2})
+	databases.OnExpired(func(db int, key string) {})
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn()
+				}
+			}
+		}()
+	}
+	run(func() {
+		db := databases.DB(0).(*storage.InMemoryStorage)
+		db.SetWithTTL("key", "value", time.Nanosecond)
+		db.Get("key")
+	})
+	run(func() { databases.Swap(0, 1) })
+	run(func() { databases.Dump() })
+
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expiration, swap and dump deadlocked")
+	}
+}
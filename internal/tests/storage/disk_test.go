@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func diskConfig(directory string) internal.Config {
+	return internal.Config{
+		Engine: internal.EngineConfig{
+			EngineType: storage.DiskEngine,
+			Disk: internal.DiskConfig{
+				Directory:           directory,
+				MemtableSize:        4 << 10,
+				BlockSize:           256,
+				CompactionThreshold: 2,
+			},
+		},
+	}
+}
+
+func openDiskStorage(t *testing.T, directory string) *storage.DiskStorage {
+	t.Helper()
+
+	s, err := storage.NewDiskStorage(diskConfig(directory), zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDiskStorage error: %v", err)
+	}
+
+	return s
+}
+
+func tableFiles(t *testing.T, directory string) int {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(directory, "*.sst"))
+	if err != nil {
+		t.Fatalf("Glob error: %v", err)
+	}
+
+	return len(paths)
+}
+
+func checkDiskValues(t *testing.T, s *storage.DiskStorage, expected map[string]string) {
+	t.Helper()
+
+	for key, value := range expected {
+		actual, found := s.Get(key)
+		if value == "" && found {
+			t.Errorf("key %s: expected to be missing, got %q", key, actual)
+		}
+		if value != "" && (!found || actual != value) {
+			t.Errorf("key %s: expected %q, got %q (found: %v)", key, value, actual, found)
+		}
+	}
+}
+
+func TestDiskStorageReplaysLog(t *testing.T) {
+	directory := t.TempDir()
+
+	s := openDiskStorage(t, directory)
+	s.Set("key1", "value1")
+	s.Set("key2", "value2")
+	s.Delete("key2")
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	s = openDiskStorage(t, directory)
+	defer s.Close()
+	checkDiskValues(t, s, map[string]string{"key1": "value1", "key2": ""})
+	if files := tableFiles(t, directory); files != 0 {
+		t.Errorf("expected no tables before the memtable is full, got %d", files)
+	}
+}
+
+func TestDiskStorageFlushAndCompact(t *testing.T) {
+	directory := t.TempDir()
+	s := openDiskStorage(t, directory)
+
+	expected := make(map[string]string)
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key%03d", i)
+			switch {
+			case i%10 == round:
+				s.Delete(key)
+				expected[key] = ""
+			default:
+				value := fmt.Sprintf("value%d-%d", i, round)
+				s.Set(key, value)
+				expected[key] = value
+			}
+		}
+		if err := s.FlushMemtable(); err != nil {
+			t.Fatalf("FlushMemtable error: %v", err)
+		}
+	}
+	if files := tableFiles(t, directory); files < 3 {
+		t.Errorf("expected a table for every flushed memtable, got %d", files)
+	}
+	checkDiskValues(t, s, expected)
+
+	before := tableFiles(t, directory)
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Compact error: %v", err)
+	}
+	if after := tableFiles(t, directory); after >= before {
+		t.Errorf("expected compaction to merge %d tables, got %d", before, after)
+	}
+	checkDiskValues(t, s, expected)
+
+	// overwritten values of the compacted tables are dropped
+	s.Set("key005", "after compaction")
+	expected["key005"] = "after compaction"
+	if err := s.FlushMemtable(); err != nil {
+		t.Fatalf("FlushMemtable error: %v", err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Compact error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	s = openDiskStorage(t, directory)
+	defer s.Close()
+	checkDiskValues(t, s, expected)
+	if _, found := s.Get("missing"); found {
+		t.Errorf("expected missing key not to be found")
+	}
+}
+
+func TestDiskStorageFreezesFullMemtable(t *testing.T) {
+	directory := t.TempDir()
+	s := openDiskStorage(t, directory)
+	defer s.Close()
+
+	expected := make(map[string]string)
+	for i := 0; i < 500; i++ {
+		key, value := fmt.Sprintf("key%03d", i), fmt.Sprintf("value%d", i)
+		s.Set(key, value)
+		expected[key] = value
+	}
+
+	// a writer writes the frozen memtable itself when the background work
+	// does not run
+	if files := tableFiles(t, directory); files == 0 {
+		t.Errorf("expected full memtables to be written to tables")
+	}
+	checkDiskValues(t, s, expected)
+}
+
+func TestDiskStorageRemovesLeftovers(t *testing.T) {
+	directory := t.TempDir()
+	s := openDiskStorage(t, directory)
+	s.Set("key", "value")
+	if err := s.FlushMemtable(); err != nil {
+		t.Fatalf("FlushMemtable error: %v", err)
+	}
+	s.Close()
+
+	leftover := filepath.Join(directory, "999999.sst")
+	if err := os.WriteFile(leftover, []byte("partial table"), 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	s = openDiskStorage(t, directory)
+	defer s.Close()
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("expected the table missing from the manifest to be removed")
+	}
+	checkDiskValues(t, s, map[string]string{"key": "value"})
+}
+
+func TestDiskStorageFlush(t *testing.T) {
+	directory := t.TempDir()
+	s := openDiskStorage(t, directory)
+	s.Set("flushed", "value")
+	if err := s.FlushMemtable(); err != nil {
+		t.Fatalf("FlushMemtable error: %v", err)
+	}
+	s.Set("memtable", "value")
+
+	s.Flush()
+	s.Set("after", "value")
+	s.Close()
+
+	s = openDiskStorage(t, directory)
+	defer s.Close()
+	checkDiskValues(t, s, map[string]string{"flushed": "", "memtable": "", "after": "value"})
+	if files := tableFiles(t, directory); files != 0 {
+		t.Errorf("expected flush to remove the tables, got %d", files)
+	}
+}
+
+func TestDiskDatabases(t *testing.T) {
+	directory := t.TempDir()
+	cfg := diskConfig(directory)
+	cfg.Engine.Databases = 2
+
+	databases, err := storage.NewDatabases(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	databases.DB(0).Set("key", "db0")
+	databases.DB(1).Set("key", "db1")
+	if !databases.DB(0).Capabilities().Persistent {
+		t.Errorf("expected disk engine to be persistent")
+	}
+	if err := databases.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	databases, err = storage.NewDatabases(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewDatabases error: %v", err)
+	}
+	defer databases.Close()
+	for i, expected := range []string{"db0", "db1"} {
+		if value, _ := databases.DB(i).Get("key"); value != expected {
+			t.Errorf("database %d: expected %q, got %q", i, expected, value)
+		}
+	}
+}
+
+func TestDiskStorageConcurrentWithBackgroundWork(t *testing.T) {
+	directory := t.TempDir()
+	s := openDiskStorage(t, directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 300; i++ {
+				key := fmt.Sprintf("w%d:key%03d", w, i)
+				s.Set(key, fmt.Sprintf("value%d", i))
+				if value, found := s.Get(key); !found || value != fmt.Sprintf("value%d", i) {
+					t.Errorf("key %s: expected to read own write, got %q", key, value)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := s.Compact(); err != nil {
+		t.Errorf("Compact error: %v", err)
+	}
+	cancel()
+	<-done
+	s.Close()
+
+	s = openDiskStorage(t, directory)
+	defer s.Close()
+	for w := 0; w < 4; w++ {
+		if value, _ := s.Get(fmt.Sprintf("w%d:key299", w)); value != "value299" {
+			t.Errorf("writer %d: expected the last value after reopen, got %q", w, value)
+		}
+	}
+}
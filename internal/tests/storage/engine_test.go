@@ -1,7 +1,12 @@
 package storage
 
 import (
+	"fmt"
+	"sync"
+	"context"
 	"testing"
+	"time"
+	"umemory/internal"
 	"umemory/internal/storage"
 )
 
@@ -14,7 +19,7 @@ type storageTestCase struct {
 }
 
 func TestInMemoryStorage(t *testing.T) {
-	storage := storage.NewInMemoryStorage()
+	storage := storage.NewInMemoryStorage(internal.Config{})
 
 	var testCases = []storageTestCase{
 		{
@@ -68,3 +73,199 @@ func TestInMemoryStorage(t *testing.T) {
 		}
 	}
 }
+
+func TestInMemoryStorageConcurrentAccess(t *testing.T) {
+	storage := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ShardsCount: 4},
+	})
+
+	const (
+		writers = 8
+		keysPerWriter = 200
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < keysPerWriter; i++ {
+				key := fmt.Sprintf("key-%d-%d", w, i)
+				storage.Set(key, key)
+				storage.Get(fmt.Sprintf("key-%d-%d", (w+1)%writers, i))
+				storage.Set("shared", key)
+				storage.Get("shared")
+				if i%2 == 0 {
+					storage.Delete(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < writers; w++ {
+		for i := 0; i < keysPerWriter; i++ {
+			key := fmt.Sprintf("key-%d-%d", w, i)
+			value, found := storage.Get(key)
+			if i%2 == 0 && found {
+				t.Errorf("key %v expected to be deleted, got value %v", key, value)
+			}
+			if i%2 != 0 && (!found || value != key) {
+				t.Errorf("key %v: expected value %v, got %v (found: %v)", key, key, value, found)
+			}
+		}
+	}
+
+	if _, found := storage.Get("shared"); !found {
+		t.Errorf("shared key expected to be found")
+	}
+}
+
+func TestInMemoryStorageSingleShard(t *testing.T) {
+	storage := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ShardsCount: 1},
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				storage.Set(fmt.Sprintf("k%d", i), fmt.Sprintf("v%d", w))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := 0; i < 100; i++ {
+		if _, found := storage.Get(fmt.Sprintf("k%d", i)); !found {
+			t.Errorf("key k%d expected to be found", i)
+		}
+	}
+}
+
+func TestInMemoryStorageExpiration(t *testing.T) {
+	storage := storage.NewInMemoryStorage(internal.Config{})
+
+	storage.SetWithTTL("volatile", "value", 50*time.Millisecond)
+	storage.Set("persistent", "value")
+
+	if value, found := storage.Get("volatile"); !found || value != "value" {
+		t.Errorf("volatile key expected to be found before expiration")
+	}
+	if ttl, found := storage.TTL("volatile"); !found || ttl <= 0 || ttl > 50*time.Millisecond {
+		t.Errorf("unexpected ttl of volatile key: %v (found: %v)", ttl, found)
+	}
+	if ttl, found := storage.TTL("persistent"); !found || ttl != -1 {
+		t.Errorf("persistent key expected to have ttl -1, got %v (found: %v)", ttl, found)
+	}
+	if _, found := storage.TTL("missing"); found {
+		t.Errorf("missing key expected not to have ttl")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, found := storage.Get("volatile"); found {
+		t.Errorf("volatile key expected to expire")
+	}
+	if storage.Expire("volatile", time.Second) {
+		t.Errorf("expire of expired key expected to fail")
+	}
+
+	if !storage.Expire("persistent", time.Minute) {
+		t.Errorf("expire of existing key expected to succeed")
+	}
+	if !storage.Persist("persistent") {
+		t.Errorf("persist of volatile key expected to succeed")
+	}
+	if storage.Persist("persistent") {
+		t.Errorf("persist of persistent key expected to fail")
+	}
+
+	storage.SetWithTTL("overwritten", "value", 10*time.Millisecond)
+	storage.Set("overwritten", "new value")
+	time.Sleep(20 * time.Millisecond)
+	if value, found := storage.Get("overwritten"); !found || value != "new value" {
+		t.Errorf("set expected to clear expiration")
+	}
+
+	if !storage.Expire("overwritten", 0) {
+		t.Errorf("expire with non-positive ttl expected to succeed")
+	}
+	if _, found := storage.Get("overwritten"); found {
+		t.Errorf("expire with non-positive ttl expected to delete key")
+	}
+}
+
+func TestInMemoryStorageActiveExpiration(t *testing.T) {
+	storage := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ExpirationInterval: 10 * time.Millisecond},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		storage.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 100; i++ {
+		storage.SetWithTTL(fmt.Sprintf("key%d", i), "value", 5*time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	for i := 0; i < 100; i++ {
+		if _, found := storage.TTL(fmt.Sprintf("key%d", i)); found {
+			t.Errorf("key%d expected to be swept", i)
+		}
+	}
+}
+
+func TestInMemoryStorageExpirationHook(t *testing.T) {
+	storage := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ExpirationInterval: 10 * time.Millisecond},
+	})
+
+	var (
+		mu      sync.Mutex
+		expired []string
+	)
+	storage.OnExpired(func(key string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		expired = append(expired, key)
+	})
+
+	storage.SetWithTTL("lazy", "value", 5*time.Millisecond)
+	storage.SetWithTTL("deleted", "value", 5*time.Millisecond)
+	storage.Delete("deleted")
+	time.Sleep(10 * time.Millisecond)
+	if _, found := storage.Get("lazy"); found {
+		t.Errorf("lazy key expected to expire")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		storage.Run(ctx)
+		close(done)
+	}()
+
+	storage.SetWithTTL("swept", "value", 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(expired) != 2 || expired[0] != "lazy" || expired[1] != "swept" {
+		t.Errorf("expected lazy and swept keys to be reported, got %v", expired)
+	}
+}
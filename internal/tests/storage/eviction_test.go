@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func newLimitedStorage(policy string, maxMemory internal.Size) *storage.InMemoryStorage {
+	return storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ShardsCount: 4, MaxMemory: maxMemory, EvictionPolicy: policy},
+	})
+}
+
+func fill(s *storage.InMemoryStorage, count int) {
+	value := strings.Repeat("v", 100)
+	for i := 0; i < count; i++ {
+		s.Set(fmt.Sprintf("key%d", i), value)
+	}
+}
+
+func TestInMemoryStorageMemoryUsage(t *testing.T) {
+	s := newLimitedStorage(storage.NoEviction, 0)
+
+	s.Set("key", "value")
+	used := s.MemoryStats().Used
+	if used <= int64(len("key")+len("value")) {
+		t.Errorf("expected used memory to include the key and the value, got %d", used)
+	}
+
+	s.Set("key", "longer value")
+	if s.MemoryStats().Used != used+int64(len("longer value")-len("value")) {
+		t.Errorf("expected used memory to follow overwrites, got %d", s.MemoryStats().Used)
+	}
+
+	s.Delete("key")
+	if s.MemoryStats().Used != 0 {
+		t.Errorf("expected no used memory after delete, got %d", s.MemoryStats().Used)
+	}
+
+	if evicted, ok := s.FreeMemory(); !ok || len(evicted) != 0 {
+		t.Errorf("expected no eviction without memory limit, got %v", evicted)
+	}
+}
+
+func TestInMemoryStorageNoEviction(t *testing.T) {
+	s := newLimitedStorage(storage.NoEviction, 1024)
+	fill(s, 20)
+
+	evicted, ok := s.FreeMemory()
+	if ok || len(evicted) != 0 {
+		t.Errorf("expected out of memory without eviction, got %v (ok: %v)", evicted, ok)
+	}
+	if _, found := s.Get("key0"); !found {
+		t.Errorf("expected keys to be kept")
+	}
+}
+
+func TestInMemoryStorageEviction(t *testing.T) {
+	for _, policy := range []string{storage.AllKeysLRU, storage.AllKeysLFU, storage.AllKeysRandom} {
+		s := newLimitedStorage(policy, 2048)
+		fill(s, 50)
+		for i := 0; i < 10; i++ {
+			s.Get("key0")
+		}
+
+		evicted, ok := s.FreeMemory()
+		if !ok || len(evicted) == 0 {
+			t.Fatalf("%s: expected eviction, got %v (ok: %v)", policy, evicted, ok)
+		}
+
+		stats := s.MemoryStats()
+		if stats.Used > stats.Max {
+			t.Errorf("%s: expected used memory %d within limit %d", policy, stats.Used, stats.Max)
+		}
+		if stats.EvictedKeys != uint64(len(evicted)) {
+			t.Errorf("%s: expected %d evicted keys, got %d", policy, len(evicted), stats.EvictedKeys)
+		}
+		for _, key := range evicted {
+			if _, found := s.Get(key); found {
+				t.Errorf("%s: expected key %s to be evicted", policy, key)
+			}
+		}
+		if _, found := s.Get("key0"); !found && policy != storage.AllKeysRandom {
+			t.Errorf("%s: expected recently used key to be kept", policy)
+		}
+	}
+}
+
+func TestInMemoryStorageVolatileEviction(t *testing.T) {
+	s := newLimitedStorage(storage.VolatileLRU, 2048)
+	fill(s, 20)
+	if evicted, ok := s.FreeMemory(); ok || len(evicted) != 0 {
+		t.Errorf("expected out of memory without volatile keys, got %v (ok: %v)", evicted, ok)
+	}
+
+	s = newLimitedStorage(storage.VolatileLRU, 2048)
+	fill(s, 10)
+	for i := 0; i < 20; i++ {
+		s.SetWithTTL(fmt.Sprintf("volatile%d", i), "value", time.Minute)
+	}
+	evicted, ok := s.FreeMemory()
+	if !ok {
+		t.Fatalf("expected volatile keys to be evicted")
+	}
+	for _, key := range evicted {
+		if !strings.HasPrefix(key, "volatile") {
+			t.Errorf("expected only volatile keys to be evicted, got %s", key)
+		}
+	}
+}
+
+func TestInMemoryStorageConcurrentEviction(t *testing.T) {
+	s := newLimitedStorage(storage.AllKeysLRU, 4096)
+
+	done := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		go func(w int) {
+			defer func() { done <- struct{}{} }()
+
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("key%d-%d", w, i)
+				s.FreeMemory()
+				s.Set(key, strings.Repeat("v", 50))
+				s.Get(key)
+			}
+		}(w)
+	}
+	for w := 0; w < 4; w++ {
+		<-done
+	}
+
+	if _, ok := s.FreeMemory(); !ok {
+		t.Errorf("expected memory to be freed")
+	}
+	if stats := s.MemoryStats(); stats.Used > stats.Max {
+		t.Errorf("expected used memory %d within limit %d", stats.Used, stats.Max)
+	}
+}
+
+func TestEngineRegistryUnknownEvictionPolicy(t *testing.T) {
+	cfg := internal.Config{Engine: internal.EngineConfig{EngineType: storage.ShardedEngine, EvictionPolicy: "fifo"}}
+	if _, err := storage.NewEngine(cfg, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "fifo") {
+		t.Errorf("expected unknown eviction policy error, got: %v", err)
+	}
+}
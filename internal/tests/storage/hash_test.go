@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func TestInMemoryStorageHash(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	added, err := s.HSet("user:1", map[string]string{"name": "Ann", "email": "ann@example.com"})
+	if err != nil || added != 2 {
+		t.Fatalf("expected 2 added fields, got %d (err: %v)", added, err)
+	}
+	if added, _ := s.HSet("user:1", map[string]string{"name": "Anna", "age": "30"}); added != 1 {
+		t.Errorf("expected 1 added field, got %d", added)
+	}
+
+	if value, found, _ := s.HGet("user:1", "name"); !found || value != "Anna" {
+		t.Errorf("expected overwritten name, got %q", value)
+	}
+	if _, found, _ := s.HGet("user:1", "phone"); found {
+		t.Errorf("expected missing field")
+	}
+	if found, _ := s.HExists("user:1", "email"); !found {
+		t.Errorf("expected email field to exist")
+	}
+	if length, _ := s.HLen("user:1"); length != 3 {
+		t.Errorf("expected 3 fields, got %d", length)
+	}
+
+	all, _ := s.HGetAll("user:1")
+	if strings.Join(all, ",") != "age,30,email,ann@example.com,name,Anna" {
+		t.Errorf("unexpected hgetall: %v", all)
+	}
+	keys, _ := s.HKeys("user:1")
+	if strings.Join(keys, ",") != "age,email,name" {
+		t.Errorf("unexpected hkeys: %v", keys)
+	}
+	values, _ := s.HVals("user:1")
+	if strings.Join(values, ",") != "30,ann@example.com,Anna" {
+		t.Errorf("unexpected hvals: %v", values)
+	}
+
+	if removed, _ := s.HDel("user:1", []string{"age", "phone"}); removed != 1 {
+		t.Errorf("expected 1 removed field, got %d", removed)
+	}
+	s.HDel("user:1", []string{"name", "email"})
+	if _, found := s.Type("user:1"); found {
+		t.Errorf("expected empty hash to be removed")
+	}
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory, got %d", used)
+	}
+}
+
+func TestInMemoryStorageHashIncrBy(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	if value, err := s.HIncrBy("counters", "visits", 5); err != nil || value != 5 {
+		t.Errorf("expected 5, got %d (err: %v)", value, err)
+	}
+	if value, _ := s.HIncrBy("counters", "visits", -7); value != -2 {
+		t.Errorf("expected -2, got %d", value)
+	}
+
+	s.HSet("counters", map[string]string{"name": "visits", "max": "9223372036854775807"})
+	if _, err := s.HIncrBy("counters", "name", 1); !errors.Is(err, storage.ErrHashValueNotInteger) {
+		t.Errorf("expected not an integer error, got: %v", err)
+	}
+	if _, err := s.HIncrBy("counters", "max", 1); !errors.Is(err, storage.ErrOverflow) {
+		t.Errorf("expected overflow error, got: %v", err)
+	}
+	if value, _, _ := s.HGet("counters", "max"); value != "9223372036854775807" {
+		t.Errorf("expected value to be kept on overflow, got %s", value)
+	}
+	if _, err := s.HIncrBy("counters", "min", math.MinInt64); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// a failed increment does not leave an empty hash behind
+	s.Set("string", "value")
+	if _, err := s.HIncrBy("string", "field", 1); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got: %v", err)
+	}
+}
+
+func TestInMemoryStorageHashDump(t *testing.T) {
+	source := storage.NewInMemoryStorage(internal.Config{})
+	source.HSet("user:1", map[string]string{"name": "Ann", "email": "ann@example.com"})
+	source.RPush("queue", []string{"a", "b"})
+
+	target := storage.NewInMemoryStorage(internal.Config{})
+	target.Load(source.Dump())
+
+	all, _ := target.HGetAll("user:1")
+	if strings.Join(all, ",") != "email,ann@example.com,name,Ann" {
+		t.Errorf("unexpected restored hash: %v", all)
+	}
+	if target.MemoryStats().Used != source.MemoryStats().Used {
+		t.Errorf("expected the same used memory, got %d and %d", target.MemoryStats().Used, source.MemoryStats().Used)
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func newHistoryStorage(versions int, retention time.Duration) *storage.InMemoryStorage {
+	return storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{
+			ShardsCount: 4,
+			History:     internal.HistoryConfig{Versions: versions, Retention: retention},
+		},
+	})
+}
+
+func historyOf(t *testing.T, s *storage.InMemoryStorage, key string) string {
+	t.Helper()
+
+	versions, err := s.History(key)
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+
+	result := ""
+	for _, version := range versions {
+		if version.Deleted {
+			result += fmt.Sprintf("%d:deleted ", version.Version)
+		} else {
+			result += fmt.Sprintf("%d:%s ", version.Version, version.Value)
+		}
+	}
+
+	return result
+}
+
+func TestInMemoryStorageHistory(t *testing.T) {
+	s := newHistoryStorage(3, 0)
+
+	s.Set("config", "v1")
+	s.Set("config", "v2")
+	s.Expire("config", time.Minute)
+	if history := historyOf(t, s, "config"); history != "1:v1 2:v2 " {
+		t.Errorf("expected two versions not counting expire, got %q", history)
+	}
+
+	s.Delete("config")
+	s.Set("config", "v3")
+	s.Set("config", "v4")
+	if history := historyOf(t, s, "config"); history != "3:deleted 4:v3 5:v4 " {
+		t.Errorf("expected last three versions, got %q", history)
+	}
+
+	if version, found, _ := s.VersionAt("config", 4); !found || version.Value != "v3" {
+		t.Errorf("expected version 4 to hold v3, got %+v (found: %v)", version, found)
+	}
+	if _, found, _ := s.VersionAt("config", 1); found {
+		t.Errorf("expected version 1 to be dropped")
+	}
+
+	value, exists, err := s.Revert("config", 4)
+	if err != nil || !exists || value != "v3" {
+		t.Fatalf("expected revert to v3, got %q %v (err: %v)", value, exists, err)
+	}
+	if value, _ := s.Get("config"); value != "v3" {
+		t.Errorf("expected reverted value v3, got %q", value)
+	}
+	if history := historyOf(t, s, "config"); history != "4:v3 5:v4 6:v3 " {
+		t.Errorf("expected revert to add a version, got %q", history)
+	}
+	if _, _, err := s.Revert("config", 1); !errors.Is(err, storage.ErrVersionNotFound) {
+		t.Errorf("expected version not found error, got %v", err)
+	}
+
+	// collections are not versioned, replacing a string records a deletion
+	s.SAdd("tags", []string{"go"})
+	s.Set("dest", "value")
+	s.SUnionStore("dest", []string{"tags"})
+	if history := historyOf(t, s, "dest"); history != "1:value 2:deleted " {
+		t.Errorf("expected replaced string to be recorded as deleted, got %q", history)
+	}
+	if history := historyOf(t, s, "tags"); history != "" {
+		t.Errorf("expected no history of a set, got %q", history)
+	}
+}
+
+func TestInMemoryStorageHistoryAtTime(t *testing.T) {
+	s := newHistoryStorage(10, 0)
+
+	before := time.Now()
+	s.Set("key", "v1")
+	time.Sleep(5 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	s.Set("key", "v2")
+
+	if _, found, _ := s.VersionAtTime("key", before); found {
+		t.Errorf("expected no version before the first one")
+	}
+	if version, found, _ := s.VersionAtTime("key", between); !found || version.Value != "v1" {
+		t.Errorf("expected v1 between the writes, got %+v", version)
+	}
+	if version, found, _ := s.VersionAtTime("key", time.Now()); !found || version.Value != "v2" {
+		t.Errorf("expected v2 now, got %+v", version)
+	}
+}
+
+func TestInMemoryStorageHistoryRetention(t *testing.T) {
+	s := newHistoryStorage(0, 20*time.Millisecond)
+
+	s.Set("key", "v1")
+	s.Set("key", "v2")
+	s.Set("deleted", "value")
+	s.Delete("deleted")
+	time.Sleep(30 * time.Millisecond)
+
+	// the current version is kept, replaced ones leave the window
+	if history := historyOf(t, s, "key"); history != "2:v2 " {
+		t.Errorf("expected the current version only, got %q", history)
+	}
+	if history := historyOf(t, s, "deleted"); history != "" {
+		t.Errorf("expected the history of a deleted key to be dropped, got %q", history)
+	}
+	s.Delete("key")
+	if used := s.MemoryStats().Used; used == 0 {
+		t.Errorf("expected the history to use memory")
+	}
+	time.Sleep(30 * time.Millisecond)
+	historyOf(t, s, "key")
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory after the history is dropped, got %d", used)
+	}
+}
+
+func TestInMemoryStorageHistoryDisabled(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	s.Set("key", "value")
+	if _, err := s.History("key"); !errors.Is(err, storage.ErrHistoryDisabled) {
+		t.Errorf("expected history disabled error, got %v", err)
+	}
+}
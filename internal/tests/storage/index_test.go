@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func newIndexedStorage(t *testing.T) *storage.InMemoryStorage {
+	t.Helper()
+
+	s := storage.NewInMemoryStorage(internal.Config{Engine: internal.EngineConfig{ShardsCount: 4}})
+	s.Set("user:1", `{"name":"alice","age":30,"address":{"city":"Berlin"}}`)
+	s.Set("user:2", `{"name":"bob","age":25,"address":{"city":"Paris"}}`)
+	s.Set("order:1", `{"name":"alice","age":1}`)
+
+	for _, definition := range []storage.IndexDefinition{
+		{Name: "byname", Prefix: "user:", Path: "name"},
+		{Name: "byage", Prefix: "user:", Path: "$.age"},
+		{Name: "bycity", Prefix: "user:", Path: "address.city"},
+	} {
+		if err := s.CreateIndex(definition); err != nil {
+			t.Fatalf("CreateIndex %s error: %v", definition.Name, err)
+		}
+	}
+
+	return s
+}
+
+func find(t *testing.T, s *storage.InMemoryStorage, name, value string) string {
+	t.Helper()
+
+	keys, err := s.Find(name, value)
+	if err != nil {
+		t.Fatalf("Find error: %v", err)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func findRange(t *testing.T, s *storage.InMemoryStorage, name string, min, max storage.IndexRange) string {
+	t.Helper()
+
+	keys, err := s.FindRange(name, min, max)
+	if err != nil {
+		t.Fatalf("FindRange error: %v", err)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func TestInMemoryStorageIndex(t *testing.T) {
+	s := newIndexedStorage(t)
+
+	if keys := find(t, s, "byname", "alice"); keys != "user:1" {
+		t.Errorf("expected existing keys to be indexed by prefix, got %q", keys)
+	}
+	if keys := find(t, s, "byage", "25.0"); keys != "user:2" {
+		t.Errorf("expected numbers to be matched by value, got %q", keys)
+	}
+
+	s.Set("user:3", `{"name":"carol","age":30,"address":{"city":"Berlin"}}`)
+	s.Set("user:1", `{"name":"alice","age":31}`)
+	if keys := find(t, s, "bycity", "Berlin"); keys != "user:3" {
+		t.Errorf("expected the overwritten document to be reindexed, got %q", keys)
+	}
+
+	s.Delete("user:3")
+	s.RPush("user:4", []string{"not", "a", "document"})
+	s.Set("user:5", "not json")
+	if keys := find(t, s, "bycity", "Berlin"); keys != "" {
+		t.Errorf("expected the deleted key to be dropped, got %q", keys)
+	}
+
+	s.SetWithTTL("user:6", `{"name":"dave","age":40}`, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if keys := find(t, s, "byname", "dave"); keys != "" {
+		t.Errorf("expected the expired key not to be found, got %q", keys)
+	}
+
+	if err := s.CreateIndex(storage.IndexDefinition{Name: "byname", Prefix: "user:", Path: "name"}); !errors.Is(err, storage.ErrIndexExists) {
+		t.Errorf("expected index exists error, got %v", err)
+	}
+	if err := s.CreateIndex(storage.IndexDefinition{Name: "bad", Prefix: "user:", Path: "a..b"}); !errors.Is(err, storage.ErrInvalidPath) {
+		t.Errorf("expected invalid path error, got %v", err)
+	}
+	if _, err := s.Find("missing", "value"); !errors.Is(err, storage.ErrIndexNotFound) {
+		t.Errorf("expected index not found error, got %v", err)
+	}
+}
+
+func TestInMemoryStorageIndexRange(t *testing.T) {
+	s := newIndexedStorage(t)
+	s.Set("user:3", `{"name":"carol","age":"unknown"}`)
+	s.Set("user:4", `{"name":"dave","age":25}`)
+
+	tests := []struct {
+		name     string
+		min, max storage.IndexRange
+		expected string
+	}{
+		{"numbers", storage.IndexRange{Value: "20"}, storage.IndexRange{Value: "30"}, "user:2,user:4,user:1"},
+		{"from number", storage.IndexRange{Value: "26"}, storage.IndexRange{Unbounded: true}, "user:1"},
+		{"strings", storage.IndexRange{Value: "a"}, storage.IndexRange{Value: "z"}, "user:3"},
+		{"unbounded", storage.IndexRange{Unbounded: true}, storage.IndexRange{Unbounded: true}, "user:2,user:4,user:1,user:3"},
+	}
+	for _, tt := range tests {
+		if keys := findRange(t, s, "byage", tt.min, tt.max); keys != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, keys)
+		}
+	}
+}
+
+func TestInMemoryStorageIndexDumpAndFlush(t *testing.T) {
+	s := newIndexedStorage(t)
+	if err := s.DropIndex("bycity"); err != nil {
+		t.Fatalf("DropIndex error: %v", err)
+	}
+
+	target := storage.NewInMemoryStorage(internal.Config{})
+	target.Load(s.Dump())
+
+	definitions := target.Indexes()
+	if len(definitions) != 2 || definitions[0].Name != "byage" || definitions[1].Name != "byname" {
+		t.Fatalf("expected the dumped indexes, got %+v", definitions)
+	}
+	if keys := find(t, target, "byname", "bob"); keys != "user:2" {
+		t.Errorf("expected loaded keys to be indexed, got %q", keys)
+	}
+
+	target.Flush()
+	if keys := find(t, target, "byname", "bob"); keys != "" {
+		t.Errorf("expected no keys after flush, got %q", keys)
+	}
+	target.Set("user:7", `{"name":"bob"}`)
+	if keys := find(t, target, "byname", "bob"); keys != "user:7" {
+		t.Errorf("expected indexes to be kept by flush, got %q", keys)
+	}
+
+	s.Flush()
+	for _, name := range []string{"byname", "byage"} {
+		if err := s.DropIndex(name); err != nil {
+			t.Fatalf("DropIndex error: %v", err)
+		}
+	}
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory without keys and indexes, got %d", used)
+	}
+}
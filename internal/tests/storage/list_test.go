@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func listOf(t *testing.T, s *storage.InMemoryStorage, key string) string {
+	t.Helper()
+
+	values, err := s.LRange(key, 0, -1)
+	if err != nil {
+		t.Fatalf("LRange error: %s", err.Error())
+	}
+
+	return strings.Join(values, ",")
+}
+
+func TestInMemoryStorageList(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	if length, err := s.RPush("list", []string{"b", "c"}); err != nil || length != 2 {
+		t.Fatalf("expected length 2, got %d (err: %v)", length, err)
+	}
+	if length, _ := s.LPush("list", []string{"a", "z"}); length != 4 {
+		t.Errorf("expected length 4, got %d", length)
+	}
+	if list := listOf(t, s, "list"); list != "z,a,b,c" {
+		t.Errorf("expected z,a,b,c, got %s", list)
+	}
+
+	if value, found, _ := s.LPop("list"); !found || value != "z" {
+		t.Errorf("expected z popped from head, got %q", value)
+	}
+	if value, found, _ := s.RPop("list"); !found || value != "c" {
+		t.Errorf("expected c popped from tail, got %q", value)
+	}
+	if length, _ := s.LLen("list"); length != 2 {
+		t.Errorf("expected length 2, got %d", length)
+	}
+
+	if value, found, _ := s.LIndex("list", -1); !found || value != "b" {
+		t.Errorf("expected b at index -1, got %q", value)
+	}
+	if _, found, _ := s.LIndex("list", 5); found {
+		t.Errorf("expected index 5 to be out of range")
+	}
+
+	for _, testCase := range []struct {
+		start, stop int
+		expected    string
+	}{
+		{0, 0, "a"},
+		{-2, -1, "a,b"},
+		{1, 100, "b"},
+		{-100, 100, "a,b"},
+		{2, 5, ""},
+		{1, 0, ""},
+	} {
+		values, _ := s.LRange("list", testCase.start, testCase.stop)
+		if strings.Join(values, ",") != testCase.expected {
+			t.Errorf("lrange %d %d: expected %q, got %v", testCase.start, testCase.stop, testCase.expected, values)
+		}
+	}
+
+	s.LPop("list")
+	s.LPop("list")
+	if _, found, _ := s.LPop("list"); found {
+		t.Errorf("expected empty list")
+	}
+	if _, found := s.Type("list"); found {
+		t.Errorf("expected empty list to be removed")
+	}
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory, got %d", used)
+	}
+}
+
+func TestInMemoryStorageListRemoveAndTrim(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	for _, testCase := range []struct {
+		count    int
+		removed  int
+		expected string
+	}{
+		{2, 2, "b,x,c,x"},
+		{-1, 1, "x,b,x,x,c"},
+		{0, 4, "b,c"},
+	} {
+		s.Delete("list")
+		s.RPush("list", []string{"x", "b", "x", "x", "c", "x"})
+
+		removed, err := s.LRem("list", testCase.count, "x")
+		if err != nil || removed != testCase.removed {
+			t.Errorf("lrem %d: expected %d removed, got %d (err: %v)", testCase.count, testCase.removed, removed, err)
+		}
+		if list := listOf(t, s, "list"); list != testCase.expected {
+			t.Errorf("lrem %d: expected %s, got %s", testCase.count, testCase.expected, list)
+		}
+	}
+
+	s.Delete("list")
+	s.RPush("list", []string{"a", "b", "c", "d", "e"})
+	s.LTrim("list", 1, -2)
+	if list := listOf(t, s, "list"); list != "b,c,d" {
+		t.Errorf("expected b,c,d after trim, got %s", list)
+	}
+
+	s.LTrim("list", 5, 10)
+	if _, found := s.Type("list"); found {
+		t.Errorf("expected list trimmed to nothing to be removed")
+	}
+}
+
+func TestInMemoryStorageListWrongType(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	s.Set("string", "value")
+	s.RPush("list", []string{"value"})
+
+	if _, err := s.LPush("string", []string{"value"}); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got: %v", err)
+	}
+	if _, err := s.LRange("string", 0, -1); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got: %v", err)
+	}
+	if _, found := s.Get("list"); found {
+		t.Errorf("expected list to be missing for Get")
+	}
+	if valueType, _ := s.Type("list"); valueType != storage.ListType {
+		t.Errorf("expected list type, got %s", valueType)
+	}
+
+	// set replaces a value of any type
+	s.Set("list", "value")
+	if valueType, _ := s.Type("list"); valueType != storage.StringType {
+		t.Errorf("expected string type, got %s", valueType)
+	}
+}
+
+func TestInMemoryStorageListExpiration(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	s.RPush("list", []string{"value"})
+	s.Expire("list", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if length, _ := s.LLen("list"); length != 0 {
+		t.Errorf("expected expired list to be empty, got %d", length)
+	}
+	if length, _ := s.RPush("list", []string{"value"}); length != 1 {
+		t.Errorf("expected expired list to be replaced, got %d", length)
+	}
+	if ttl, _ := s.TTL("list"); ttl != -1 {
+		t.Errorf("expected new list without expiration, got %v", ttl)
+	}
+}
+
+func TestInMemoryStorageListDeque(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	var expected []string
+	for i := 0; i < 100; i++ {
+		s.LPush("list", []string{"l"})
+		s.RPush("list", []string{"r"})
+		expected = append(append([]string{"l"}, expected...), "r")
+	}
+	for i := 0; i < 80; i++ {
+		s.LPop("list")
+		expected = expected[1:]
+	}
+	if list := listOf(t, s, "list"); list != strings.Join(expected, ",") {
+		t.Errorf("unexpected list after pushes and pops: %s", list)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func TestInMemoryStorageMultiKey(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	s.SetWithTTL("a", "old", time.Minute)
+	s.SAdd("tags", []string{"go"})
+	s.MSet([]string{"a", "1", "b", "2", "b", "3"})
+
+	values, found := s.MGet([]string{"a", "missing", "b", "tags"})
+	if fmt.Sprint(values, found) != "[1  3 ] [true false true false]" {
+		t.Errorf("unexpected values %q found %v", values, found)
+	}
+	if ttl, _ := s.TTL("a"); ttl != -1 {
+		t.Errorf("expected mset to remove the time to live")
+	}
+
+	if s.MSetNX([]string{"c", "1", "a", "2"}) {
+		t.Errorf("expected msetnx to fail when a key exists")
+	}
+	if _, found := s.Get("c"); found {
+		t.Errorf("expected no key set by failed msetnx")
+	}
+	if !s.MSetNX([]string{"c", "1", "d", "2"}) {
+		t.Errorf("expected msetnx to set missing keys")
+	}
+
+	deleted := s.MDelete([]string{"a", "missing", "c", "a", "tags"})
+	if fmt.Sprint(deleted) != "[true false true false true]" {
+		t.Errorf("unexpected deleted %v", deleted)
+	}
+	if _, found := s.Get("a"); found {
+		t.Errorf("expected a to be deleted")
+	}
+}
+
+func TestInMemoryStorageConcurrentMultiKey(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ShardsCount: 4},
+	})
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 50; i++ {
+				value := fmt.Sprintf("%d-%d", w, i)
+				pairs := make([]string, 0, 2*len(keys))
+				for _, key := range keys {
+					pairs = append(pairs, key, value)
+				}
+				s.MSet(pairs)
+
+				// every key holds the value of the same mset
+				values, _ := s.MGet(keys)
+				for _, v := range values[1:] {
+					if v != values[0] {
+						t.Errorf("expected values of a single mset, got %v", values)
+
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
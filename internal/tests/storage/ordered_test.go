@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func keysOf(keyValues []storage.KeyValue) string {
+	keys := make([]string, 0, len(keyValues))
+	for _, keyValue := range keyValues {
+		keys = append(keys, keyValue.Key)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func TestOrderedStorageRange(t *testing.T) {
+	s := storage.NewOrderedStorage(internal.Config{})
+	for _, key := range []string{"2024-03", "2024-01", "2023-12", "2024-02", "2025-01"} {
+		s.Set(key, "value:"+key)
+	}
+
+	var testCases = []struct {
+		name     string
+		start    string
+		end      string
+		limit    int
+		reverse  bool
+		expected string
+	}{
+		{"inclusive bounds", "2024-01", "2024-03", 0, false, "2024-01,2024-02,2024-03"},
+		{"bounds between keys", "2024", "2024-02a", 0, false, "2024-01,2024-02"},
+		{"no upper bound", "2024-02", "", 0, false, "2024-02,2024-03,2025-01"},
+		{"all keys", "", "", 0, false, "2023-12,2024-01,2024-02,2024-03,2025-01"},
+		{"limit", "", "", 2, false, "2023-12,2024-01"},
+		{"reverse", "2024-01", "2024-03", 0, true, "2024-03,2024-02,2024-01"},
+		{"reverse without upper bound", "2024-02", "", 2, true, "2025-01,2024-03"},
+		{"empty range", "2024-03", "2024-01", 0, false, ""},
+	}
+
+	for _, testCase := range testCases {
+		result := s.Range(testCase.start, testCase.end, testCase.limit, testCase.reverse)
+		if keys := keysOf(result); keys != testCase.expected {
+			t.Errorf("case %s: expected %q, got %q", testCase.name, testCase.expected, keys)
+		}
+	}
+
+	result := s.Range("2024-01", "2024-01", 0, false)
+	if len(result) != 1 || result[0].Value != "value:2024-01" || result[0].Type != storage.StringType {
+		t.Errorf("expected key with value, got %v", result)
+	}
+}
+
+func TestOrderedStoragePrefix(t *testing.T) {
+	s := storage.NewOrderedStorage(internal.Config{})
+	s.Set("user:2", "b")
+	s.Set("user:1", "a")
+	s.Set("users", "all")
+	s.Set("order:1", "o")
+	s.RPush("user:queue", []string{"x"})
+
+	if keys := keysOf(s.Prefix("user:", 0)); keys != "user:1,user:2,user:queue" {
+		t.Errorf("unexpected prefix keys: %s", keys)
+	}
+	if keys := keysOf(s.Prefix("user", 2)); keys != "user:1,user:2" {
+		t.Errorf("unexpected limited prefix keys: %s", keys)
+	}
+	if result := s.Prefix("user:q", 0); len(result) != 1 || result[0].Type != storage.ListType {
+		t.Errorf("expected list key, got %v", result)
+	}
+	if result := s.Prefix("missing", 0); len(result) != 0 {
+		t.Errorf("expected no keys, got %v", result)
+	}
+}
+
+func TestOrderedStorageKeepsIndex(t *testing.T) {
+	s := storage.NewOrderedStorage(internal.Config{})
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("key:%03d", i), "value")
+	}
+	for i := 0; i < 100; i += 2 {
+		s.Delete(fmt.Sprintf("key:%03d", i))
+	}
+	s.SetWithTTL("key:001", "value", time.Millisecond)
+	s.RPush("key:003", []string{"a"})
+	s.RPush("key:100", []string{"a"})
+	s.LPop("key:100")
+	time.Sleep(5 * time.Millisecond)
+
+	if keys := keysOf(s.Range("key:000", "key:010", 0, false)); keys != "key:003,key:005,key:007,key:009" {
+		t.Errorf("unexpected keys after modifications: %s", keys)
+	}
+	if keys := keysOf(s.Range("key:095", "", 0, false)); keys != "key:095,key:097,key:099" {
+		t.Errorf("expected emptied list to be removed from the index, got %s", keys)
+	}
+
+	s.Load([]storage.Record{{Key: "restored", Value: "value"}})
+	if keys := keysOf(s.Range("", "", 0, false)); keys != "restored" {
+		t.Errorf("expected index to be replaced by load, got %s", keys)
+	}
+	if !s.Capabilities().Ordered {
+		t.Errorf("expected ordered capability")
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+func TestEngineRegistry(t *testing.T) {
+	for _, name := range []string{storage.InMemoryEngine, storage.ShardedEngine, storage.OrderedEngine} {
+		cfg := internal.Config{Engine: internal.EngineConfig{EngineType: name}}
+		engine, err := storage.NewEngine(cfg, zap.NewNop())
+		if err != nil {
+			t.Fatalf("engine %s: NewEngine error: %s", name, err.Error())
+		}
+
+		engine.Set("key", "value")
+		if value, found := engine.Get("key"); !found || value != "value" {
+			t.Errorf("engine %s: expected stored value, got %v (found: %v)", name, value, found)
+		}
+		if !engine.Capabilities().TTL {
+			t.Errorf("engine %s: expected ttl support", name)
+		}
+		if _, ok := engine.(storage.Runner); !ok {
+			t.Errorf("engine %s: expected background runner", name)
+		}
+	}
+}
+
+func TestEngineRegistryUnknownEngine(t *testing.T) {
+	cfg := internal.Config{Engine: internal.EngineConfig{EngineType: "magnetic_tape"}}
+	_, err := storage.NewEngine(cfg, zap.NewNop())
+	if err == nil {
+		t.Fatalf("expected unknown engine error")
+	}
+
+	for _, name := range storage.Engines() {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error to list engine %s, got: %s", name, err.Error())
+		}
+	}
+}
+
+func TestEngineRegistryDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on duplicate registration")
+		}
+	}()
+
+	storage.Register(storage.InMemoryEngine, nil)
+}
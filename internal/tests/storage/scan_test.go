@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func TestInMemoryStorageKeys(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	for _, key := range []string{"user:1", "user:2", "user:10", "order:1", "hello", "hallo", "hxllo", "h*llo"} {
+		s.Set(key, "value")
+	}
+	s.RPush("user:queue", []string{"a"})
+	s.SetWithTTL("user:expired", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var testCases = []struct {
+		pattern  string
+		expected string
+	}{
+		{"*", "h*llo,hallo,hello,hxllo,order:1,user:1,user:10,user:2,user:queue"},
+		{"user:*", "user:1,user:10,user:2,user:queue"},
+		{"user:?", "user:1,user:2"},
+		{"h[ae]llo", "hallo,hello"},
+		{"h[^e]llo", "h*llo,hallo,hxllo"},
+		{"h[a-f]llo", "hallo,hello"},
+		{"h\\*llo", "h*llo"},
+		{"*:1*", "order:1,user:1,user:10"},
+		{"missing", ""},
+	}
+
+	for _, testCase := range testCases {
+		if keys := strings.Join(s.Keys(testCase.pattern), ","); keys != testCase.expected {
+			t.Errorf("pattern %q: expected %q, got %q", testCase.pattern, testCase.expected, keys)
+		}
+	}
+
+	if size := s.DBSize(); size != 10 {
+		t.Errorf("expected 10 keys, got %d", size)
+	}
+}
+
+func TestInMemoryStorageScan(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("key:%d", i), "value")
+	}
+	s.Set("other", "value")
+
+	seen := make(map[string]int)
+	var cursor uint64
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("scan did not complete")
+		}
+
+		var keys []string
+		keys, cursor = s.Scan(cursor, "key:*", 7)
+		for _, key := range keys {
+			seen[key]++
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if len(seen) != 100 {
+		t.Errorf("expected 100 keys, got %d", len(seen))
+	}
+	for key, count := range seen {
+		if count != 1 {
+			t.Errorf("expected key %s to be returned once, got %d", key, count)
+		}
+	}
+}
+
+func TestInMemoryStorageScanConcurrentModification(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	for i := 0; i < 200; i++ {
+		s.Set(fmt.Sprintf("stable:%d", i), "value")
+	}
+
+	seen := make(map[string]bool)
+	var (
+		cursor uint64
+		page   int
+	)
+	for {
+		var keys []string
+		keys, cursor = s.Scan(cursor, "", 10)
+		for _, key := range keys {
+			seen[key] = true
+		}
+		if cursor == 0 {
+			break
+		}
+
+		// keys added and removed between pages must not hide stable keys
+		for i := 0; i < 20; i++ {
+			s.Set(fmt.Sprintf("added:%d:%d", page, i), "value")
+			s.Delete(fmt.Sprintf("added:%d:%d", page-1, i))
+		}
+		page++
+	}
+
+	for i := 0; i < 200; i++ {
+		if key := fmt.Sprintf("stable:%d", i); !seen[key] {
+			t.Errorf("expected key %s to be returned", key)
+		}
+	}
+}
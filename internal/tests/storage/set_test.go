@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func membersOf(t *testing.T, s *storage.InMemoryStorage, key string) string {
+	t.Helper()
+
+	members, err := s.SMembers(key)
+	if err != nil {
+		t.Fatalf("SMembers error: %s", err.Error())
+	}
+
+	return strings.Join(members, ",")
+}
+
+func TestInMemoryStorageSet(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	if added, err := s.SAdd("tags", []string{"go", "db", "go"}); err != nil || added != 2 {
+		t.Fatalf("expected 2 added members, got %d (err: %v)", added, err)
+	}
+	if added, _ := s.SAdd("tags", []string{"db", "cache"}); added != 1 {
+		t.Errorf("expected 1 added member, got %d", added)
+	}
+	if members := membersOf(t, s, "tags"); members != "cache,db,go" {
+		t.Errorf("expected cache,db,go, got %s", members)
+	}
+	if found, _ := s.SIsMember("tags", "go"); !found {
+		t.Errorf("expected go to be a member")
+	}
+	if found, _ := s.SIsMember("tags", "rust"); found {
+		t.Errorf("expected rust not to be a member")
+	}
+	if length, _ := s.SCard("tags"); length != 3 {
+		t.Errorf("expected 3 members, got %d", length)
+	}
+	if removed, _ := s.SRem("tags", []string{"cache", "rust"}); removed != 1 {
+		t.Errorf("expected 1 removed member, got %d", removed)
+	}
+
+	members, _ := s.SRandMember("tags", 5)
+	if len(members) != 2 {
+		t.Errorf("expected 2 distinct random members, got %v", members)
+	}
+	members, _ = s.SRandMember("tags", -5)
+	if len(members) != 5 {
+		t.Errorf("expected 5 random members with repeats, got %v", members)
+	}
+
+	popped, _ := s.SPop("tags", 1)
+	if len(popped) != 1 || (popped[0] != "go" && popped[0] != "db") {
+		t.Errorf("expected one popped member, got %v", popped)
+	}
+	s.SPop("tags", 10)
+	if _, found := s.Type("tags"); found {
+		t.Errorf("expected empty set to be removed")
+	}
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory, got %d", used)
+	}
+}
+
+func TestInMemoryStorageSetAlgebra(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	s.SAdd("a", []string{"1", "2", "3", "4"})
+	s.SAdd("b", []string{"3", "4", "5"})
+	s.SAdd("c", []string{"4", "6"})
+
+	for _, testCase := range []struct {
+		name     string
+		combine  func(keys []string) ([]string, error)
+		keys     []string
+		expected string
+	}{
+		{"sinter", s.SInter, []string{"a", "b", "c"}, "4"},
+		{"sinter missing key", s.SInter, []string{"a", "missing"}, ""},
+		{"sunion", s.SUnion, []string{"a", "b", "missing", "c"}, "1,2,3,4,5,6"},
+		{"sdiff", s.SDiff, []string{"a", "b", "c"}, "1,2"},
+		{"sdiff missing first key", s.SDiff, []string{"missing", "a"}, ""},
+	} {
+		members, err := testCase.combine(testCase.keys)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", testCase.name, err)
+		}
+		if strings.Join(members, ",") != testCase.expected {
+			t.Errorf("%s: expected %q, got %v", testCase.name, testCase.expected, members)
+		}
+	}
+
+	s.Set("dest", "value")
+	if length, _ := s.SUnionStore("dest", []string{"b", "c"}); length != 4 {
+		t.Errorf("expected 4 stored members, got %d", length)
+	}
+	if members := membersOf(t, s, "dest"); members != "3,4,5,6" {
+		t.Errorf("expected stored union, got %s", members)
+	}
+
+	// the destination may be one of the sources
+	if length, _ := s.SDiffStore("dest", []string{"dest", "a"}); length != 2 {
+		t.Errorf("expected 2 stored members, got %d", length)
+	}
+	if length, _ := s.SInterStore("dest", []string{"dest", "a"}); length != 0 {
+		t.Errorf("expected empty intersection, got %d", length)
+	}
+	if _, found := s.Type("dest"); found {
+		t.Errorf("expected empty result to remove the destination")
+	}
+
+	s.Set("string", "value")
+	if _, err := s.SUnion([]string{"a", "string"}); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got: %v", err)
+	}
+	if _, err := s.SInterStore("a", []string{"string"}); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got: %v", err)
+	}
+	if members := membersOf(t, s, "a"); members != "1,2,3,4" {
+		t.Errorf("expected destination to be kept on error, got %s", members)
+	}
+}
+
+func TestInMemoryStorageSetConcurrentAlgebra(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{
+		Engine: internal.EngineConfig{ShardsCount: 4},
+	})
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	for _, key := range keys {
+		s.SAdd(key, []string{"shared"})
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for i := 0; i < 50; i++ {
+				// keys in different order in every call
+				first := (w + i) % len(keys)
+				ordered := append(append([]string{}, keys[first:]...), keys[:first]...)
+
+				s.SAdd(ordered[0], []string{fmt.Sprintf("member%d-%d", w, i)})
+				s.SUnionStore(ordered[1], ordered[2:])
+				members, _ := s.SInter(ordered)
+				if len(members) == 0 || members[len(members)-1] != "shared" {
+					t.Errorf("expected shared member in all sets, got %v", members)
+
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
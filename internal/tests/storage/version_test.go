@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func TestInMemoryStorageVersion(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	missing := s.Version("key")
+	if s.Version("key") != missing {
+		t.Fatalf("expected version to be stable without modifications")
+	}
+
+	s.Set("key", "value")
+	created := s.Version("key")
+	if created == missing {
+		t.Errorf("expected set to change the version")
+	}
+
+	s.Get("key")
+	if s.Version("key") != created {
+		t.Errorf("expected get not to change the version")
+	}
+
+	s.Delete("key")
+	if deleted := s.Version("key"); deleted == created || deleted == missing {
+		t.Errorf("expected delete to change the version, got %d", deleted)
+	}
+
+	s.RPush("queue", []string{"a"})
+	pushed := s.Version("queue")
+	s.RPush("queue", []string{"b"})
+	if s.Version("queue") == pushed {
+		t.Errorf("expected list push to change the version")
+	}
+
+	s.SetWithTTL("volatile", "value", time.Millisecond)
+	volatile := s.Version("volatile")
+	time.Sleep(5 * time.Millisecond)
+	if s.Version("volatile") == volatile {
+		t.Errorf("expected expiration to change the version")
+	}
+}
+
+func TestInMemoryStorageCompareAndSet(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	if swapped, err := s.CompareAndSet("key", "old", "new"); err != nil || swapped {
+		t.Errorf("expected missing key not to be swapped, got %v (err: %v)", swapped, err)
+	}
+
+	s.SetWithTTL("key", "old", time.Hour)
+	if swapped, _ := s.CompareAndSet("key", "other", "new"); swapped {
+		t.Errorf("expected different value not to be swapped")
+	}
+	if swapped, _ := s.CompareAndSet("key", "old", "new"); !swapped {
+		t.Errorf("expected equal value to be swapped")
+	}
+	if value, _ := s.Get("key"); value != "new" {
+		t.Errorf("expected new value, got %q", value)
+	}
+	if ttl, _ := s.TTL("key"); ttl <= 0 {
+		t.Errorf("expected ttl to be kept, got %v", ttl)
+	}
+
+	s.SAdd("tags", []string{"go"})
+	if _, err := s.CompareAndSet("tags", "go", "db"); !errors.Is(err, storage.ErrWrongType) {
+		t.Errorf("expected wrong type error, got %v", err)
+	}
+}
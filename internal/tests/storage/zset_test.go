@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/storage"
+)
+
+func scored(members []storage.ScoredMember) string {
+	result := ""
+	for _, member := range members {
+		result += fmt.Sprintf("%s:%g ", member.Member, member.Score)
+	}
+
+	return result
+}
+
+func TestInMemoryStorageZSet(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	added, err := s.ZAdd("board", map[string]float64{"ann": 10, "bob": 20, "cid": 20, "dan": 5})
+	if err != nil || added != 4 {
+		t.Fatalf("expected 4 added members, got %d (err: %v)", added, err)
+	}
+	if added, _ := s.ZAdd("board", map[string]float64{"ann": 30, "eve": 1}); added != 1 {
+		t.Errorf("expected 1 added member, got %d", added)
+	}
+
+	if score, found, _ := s.ZScore("board", "ann"); !found || score != 30 {
+		t.Errorf("expected updated score 30, got %v", score)
+	}
+	if _, found, _ := s.ZScore("board", "zed"); found {
+		t.Errorf("expected missing member")
+	}
+
+	members, _ := s.ZRange("board", 0, -1, false)
+	if scored(members) != "eve:1 dan:5 bob:20 cid:20 ann:30 " {
+		t.Errorf("unexpected zrange: %s", scored(members))
+	}
+	members, _ = s.ZRange("board", 0, 1, true)
+	if scored(members) != "ann:30 cid:20 " {
+		t.Errorf("unexpected zrevrange: %s", scored(members))
+	}
+	members, _ = s.ZRange("board", -2, 100, false)
+	if scored(members) != "cid:20 ann:30 " {
+		t.Errorf("unexpected zrange with negative start: %s", scored(members))
+	}
+
+	if rank, found, _ := s.ZRank("board", "bob", false); !found || rank != 2 {
+		t.Errorf("expected rank 2, got %d", rank)
+	}
+	if rank, found, _ := s.ZRank("board", "bob", true); !found || rank != 2 {
+		t.Errorf("expected reverse rank 2, got %d", rank)
+	}
+	if rank, _, _ := s.ZRank("board", "eve", true); rank != 4 {
+		t.Errorf("expected reverse rank 4, got %d", rank)
+	}
+	if _, found, _ := s.ZRank("board", "zed", false); found {
+		t.Errorf("expected missing member to have no rank")
+	}
+
+	if score, _ := s.ZIncrBy("board", "eve", 100); score != 101 {
+		t.Errorf("expected incremented score 101, got %v", score)
+	}
+	if rank, _, _ := s.ZRank("board", "eve", true); rank != 0 {
+		t.Errorf("expected incremented member to move to the top, got rank %d", rank)
+	}
+	s.ZIncrBy("board", "inf", math.Inf(-1))
+	if _, err := s.ZIncrBy("board", "inf", math.Inf(1)); !errors.Is(err, storage.ErrScoreNaN) {
+		t.Errorf("expected NaN score error, got: %v", err)
+	}
+
+	if removed, _ := s.ZRem("board", []string{"inf", "eve", "zed"}); removed != 2 {
+		t.Errorf("expected 2 removed members, got %d", removed)
+	}
+	s.ZRem("board", []string{"ann", "bob", "cid", "dan"})
+	if _, found := s.Type("board"); found {
+		t.Errorf("expected empty sorted set to be removed")
+	}
+	if used := s.MemoryStats().Used; used != 0 {
+		t.Errorf("expected no used memory, got %d", used)
+	}
+}
+
+func TestInMemoryStorageZSetScoreRange(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+	s.ZAdd("board", map[string]float64{"a": 1, "b": 2, "c": 2, "d": 3, "e": 4})
+
+	for _, testCase := range []struct {
+		min, max storage.ScoreBound
+		expected string
+	}{
+		{storage.ScoreBound{Value: 2}, storage.ScoreBound{Value: 3}, "b:2 c:2 d:3 "},
+		{storage.ScoreBound{Value: 2, Exclusive: true}, storage.ScoreBound{Value: 4}, "d:3 e:4 "},
+		{storage.ScoreBound{Value: 1}, storage.ScoreBound{Value: 3, Exclusive: true}, "a:1 b:2 c:2 "},
+		{storage.ScoreBound{Value: math.Inf(-1)}, storage.ScoreBound{Value: math.Inf(1)}, "a:1 b:2 c:2 d:3 e:4 "},
+		{storage.ScoreBound{Value: 5}, storage.ScoreBound{Value: 10}, ""},
+		{storage.ScoreBound{Value: 3}, storage.ScoreBound{Value: 2}, ""},
+		{storage.ScoreBound{Value: 2, Exclusive: true}, storage.ScoreBound{Value: 3, Exclusive: true}, ""},
+	} {
+		members, _ := s.ZRangeByScore("board", testCase.min, testCase.max)
+		if scored(members) != testCase.expected {
+			t.Errorf("range %v %v: expected %q, got %q", testCase.min, testCase.max, testCase.expected, scored(members))
+		}
+
+		count, _ := s.ZCount("board", testCase.min, testCase.max)
+		if count != len(members) {
+			t.Errorf("range %v %v: expected count %d, got %d", testCase.min, testCase.max, len(members), count)
+		}
+	}
+}
+
+func TestInMemoryStorageZSetOrder(t *testing.T) {
+	s := storage.NewInMemoryStorage(internal.Config{})
+
+	scores := make(map[string]float64)
+	for i := 0; i < 2000; i++ {
+		member := fmt.Sprintf("member%d", rand.Intn(500))
+		if rand.Intn(4) == 0 {
+			s.ZRem("board", []string{member})
+			delete(scores, member)
+
+			continue
+		}
+
+		score := float64(rand.Intn(100))
+		s.ZAdd("board", map[string]float64{member: score})
+		scores[member] = score
+	}
+
+	expected := make([]storage.ScoredMember, 0, len(scores))
+	for member, score := range scores {
+		expected = append(expected, storage.ScoredMember{Member: member, Score: score})
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].Score != expected[j].Score {
+			return expected[i].Score < expected[j].Score
+		}
+
+		return expected[i].Member < expected[j].Member
+	})
+
+	members, _ := s.ZRange("board", 0, -1, false)
+	if scored(members) != scored(expected) {
+		t.Fatalf("sorted set order differs from the expected one")
+	}
+	for i, member := range expected {
+		if rank, _, _ := s.ZRank("board", member.Member, false); rank != i {
+			t.Fatalf("member %s: expected rank %d, got %d", member.Member, i, rank)
+		}
+	}
+	for _, index := range []int{0, len(expected) / 2, len(expected) - 1} {
+		members, _ := s.ZRange("board", index, index, true)
+		if len(members) != 1 || members[0] != expected[len(expected)-1-index] {
+			t.Errorf("reverse index %d: unexpected member %v", index, members)
+		}
+	}
+}
+
+func TestInMemoryStorageZSetDump(t *testing.T) {
+	source := storage.NewInMemoryStorage(internal.Config{})
+	source.ZAdd("board", map[string]float64{"ann": 0.1, "bob": -2.5e10, "inf": math.Inf(1)})
+
+	target := storage.NewInMemoryStorage(internal.Config{})
+	target.Load(source.Dump())
+
+	members, _ := target.ZRange("board", 0, -1, false)
+	if scored(members) != "bob:-2.5e+10 ann:0.1 inf:+Inf " {
+		t.Errorf("unexpected restored sorted set: %s", scored(members))
+	}
+}
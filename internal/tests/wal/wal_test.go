@@ -0,0 +1,241 @@
+package wal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"umemory/internal"
+	"umemory/internal/wal"
+
+	"go.uber.org/zap"
+)
+
+type walRecord struct {
+	command string
+	args    []string
+}
+
+func newWAL(t *testing.T, directory string, maxSegmentSize internal.Size) *wal.WAL {
+	t.Helper()
+
+	cfg := internal.Config{
+		WAL: internal.WALConfig{
+			Directory:      directory,
+			FsyncPolicy:    wal.FsyncAlways,
+			MaxSegmentSize: maxSegmentSize,
+		},
+	}
+	w, err := wal.NewWAL(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("wal.NewWAL error: %s", err.Error())
+	}
+
+	return w
+}
+
+func replay(t *testing.T, w *wal.WAL) []walRecord {
+	t.Helper()
+
+	var records []walRecord
+	err := w.Replay(0, func(command string, args []string) error {
+		records = append(records, walRecord{command: command, args: args})
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay error: %s", err.Error())
+	}
+
+	return records
+}
+
+func TestWALAppendAndReplay(t *testing.T) {
+	directory := t.TempDir()
+
+	expected := []walRecord{
+		{command: "set", args: []string{"key1", "value1"}},
+		{command: "set", args: []string{"key2", "value with spaces", "PXAT", "1700000000000"}},
+		{command: "delete", args: []string{"key1"}},
+		{command: "set", args: []string{"key3", ""}},
+	}
+
+	w := newWAL(t, directory, 0)
+	for _, record := range expected {
+		if err := w.Append(record.command, record.args); err != nil {
+			t.Fatalf("Append error: %s", err.Error())
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close error: %s", err.Error())
+	}
+
+	actual := replay(t, newWAL(t, directory, 0))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected records: %v \nactual records: %v", expected, actual)
+	}
+}
+
+func TestWALSegmentRotation(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 64)
+	for i := 0; i < 10; i++ {
+		if err := w.Append("set", []string{"key", "some long enough value"}); err != nil {
+			t.Fatalf("Append error: %s", err.Error())
+		}
+	}
+	w.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(directory, "*.wal"))
+	if len(segments) < 2 {
+		t.Errorf("expected several segments, got %d", len(segments))
+	}
+
+	// a restarted log keeps appending after the existing segments
+	w = newWAL(t, directory, 64)
+	if len(replay(t, w)) != 10 {
+		t.Errorf("expected 10 records before restart")
+	}
+	w.Append("delete", []string{"key"})
+	w.Close()
+
+	records := replay(t, newWAL(t, directory, 64))
+	if len(records) != 11 || records[10].command != "delete" {
+		t.Errorf("expected 11 records ending with delete, got %v", records)
+	}
+}
+
+func TestWALTornTail(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 0)
+	w.Append("set", []string{"key1", "value1"})
+	w.Append("set", []string{"key2", "value2"})
+	w.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(directory, "*.wal"))
+	if len(segments) != 1 {
+		t.Fatalf("expected 1 segment, got %d", len(segments))
+	}
+	info, _ := os.Stat(segments[0])
+	if err := os.Truncate(segments[0], info.Size()-3); err != nil {
+		t.Fatalf("Truncate error: %s", err.Error())
+	}
+
+	w = newWAL(t, directory, 0)
+	records := replay(t, w)
+	if len(records) != 1 || records[0].args[0] != "key1" {
+		t.Errorf("expected only the first record, got %v", records)
+	}
+	w.Append("set", []string{"key3", "value3"})
+	w.Close()
+
+	records = replay(t, newWAL(t, directory, 0))
+	if len(records) != 2 || records[1].args[0] != "key3" {
+		t.Errorf("expected records key1 and key3, got %v", records)
+	}
+}
+
+func TestWALOversizedRecordLength(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 0)
+	w.Append("set", []string{"key1", "value1"})
+	w.Close()
+
+	// a corrupted header claiming an almost 4 GiB payload
+	segments, _ := filepath.Glob(filepath.Join(directory, "*.wal"))
+	data, _ := os.ReadFile(segments[0])
+	data = binary.LittleEndian.AppendUint32(data, 0xfffffff0)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = append(data, "short"...)
+	os.WriteFile(segments[0], data, 0o644)
+
+	w = newWAL(t, directory, 0)
+	records := replay(t, w)
+	if len(records) != 1 || records[0].args[0] != "key1" {
+		t.Errorf("expected only the first record, got %v", records)
+	}
+	w.Close()
+
+	// the log stays readable after the corrupted tail is cut off
+	records = replay(t, newWAL(t, directory, 0))
+	if len(records) != 1 {
+		t.Errorf("expected 1 record after truncation, got %v", records)
+	}
+}
+
+func TestWALCorruptedRecord(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 0)
+	w.Append("set", []string{"key1", "value1"})
+	w.Append("set", []string{"key2", "value2"})
+	w.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(directory, "*.wal"))
+	data, _ := os.ReadFile(segments[0])
+	data[len(data)/4] ^= 0xff
+	os.WriteFile(segments[0], data, 0o644)
+
+	err := newWAL(t, directory, 0).Replay(0, func(string, []string) error { return nil })
+	if err == nil {
+		t.Errorf("expected replay error for corrupted segment")
+	}
+}
+
+func TestWALUnknownFsyncPolicy(t *testing.T) {
+	cfg := internal.Config{
+		WAL: internal.WALConfig{Directory: t.TempDir(), FsyncPolicy: "sometimes"},
+	}
+	if _, err := wal.NewWAL(cfg, zap.NewNop()); err == nil {
+		t.Errorf("expected unknown fsync policy error")
+	}
+}
+
+func TestWALRotateAndRemoveSegments(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 0)
+	w.Append("set", []string{"key1", "value1"})
+	cut, err := w.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate error: %s", err.Error())
+	}
+	w.Append("set", []string{"key2", "value2"})
+
+	if err := w.RemoveSegments(cut); err != nil {
+		t.Fatalf("RemoveSegments error: %s", err.Error())
+	}
+	w.Close()
+
+	records := replay(t, newWAL(t, directory, 0))
+	if len(records) != 1 || records[0].args[0] != "key2" {
+		t.Errorf("expected only the record after the cut, got %v", records)
+	}
+}
+
+func TestWALReplayAfterSegment(t *testing.T) {
+	directory := t.TempDir()
+
+	w := newWAL(t, directory, 0)
+	w.Append("set", []string{"key1", "value1"})
+	cut, _ := w.Rotate()
+	w.Append("set", []string{"key2", "value2"})
+	w.Close()
+
+	var keys []string
+	err := newWAL(t, directory, 0).Replay(cut, func(command string, args []string) error {
+		keys = append(keys, args[0])
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(keys, []string{"key2"}) {
+		t.Errorf("expected only key2 to be replayed, got %v", keys)
+	}
+}
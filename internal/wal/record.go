@@ -0,0 +1,111 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// recordHeaderSize is the size of the payload length and the payload
+// checksum written before every record.
+const recordHeaderSize = 8
+
+var (
+	errTornRecord      = errors.New("torn record")
+	errCorruptedRecord = errors.New("corrupted record")
+)
+
+// Record is a single write command stored in the log.
+type Record struct {
+	Command string
+	Args    []string
+}
+
+// encodeRecord serializes the record as
+// [payload length uint32][payload crc32 uint32][payload], where the payload
+// is a list of uvarint length prefixed strings: the command and its args.
+func encodeRecord(record Record) []byte {
+	payload := make([]byte, 0, 64)
+	payload = binary.AppendUvarint(payload, uint64(len(record.Args)+1))
+	payload = appendString(payload, record.Command)
+	for _, arg := range record.Args {
+		payload = appendString(payload, arg)
+	}
+
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+
+	return append(buf, payload...)
+}
+
+// readRecord reads the next record of at most limit bytes left in the
+// stream. It returns io.EOF at the clean end of the stream and errTornRecord
+// if the stream ends in the middle of a record, including a payload length
+// beyond the end of the stream, which is checked before allocating it.
+func readRecord(r io.Reader, limit int64) (Record, int, error) {
+	header := make([]byte, recordHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return Record{}, 0, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return Record{}, n, errTornRecord
+		}
+
+		return Record{}, n, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	checksum := binary.LittleEndian.Uint32(header[4:8])
+	if int64(size) > limit-recordHeaderSize {
+		return Record{}, recordHeaderSize, errTornRecord
+	}
+
+	payload := make([]byte, size)
+	if n, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return Record{}, recordHeaderSize + n, errTornRecord
+		}
+
+		return Record{}, recordHeaderSize + n, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return Record{}, recordHeaderSize + int(size), errCorruptedRecord
+	}
+
+	record, err := decodePayload(payload)
+	if err != nil {
+		return Record{}, recordHeaderSize + int(size), err
+	}
+
+	return record, recordHeaderSize + int(size), nil
+}
+
+func decodePayload(payload []byte) (Record, error) {
+	count, n := binary.Uvarint(payload)
+	if n <= 0 || count == 0 || count > uint64(len(payload)) {
+		return Record{}, errCorruptedRecord
+	}
+	payload = payload[n:]
+
+	values := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		length, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < length {
+			return Record{}, fmt.Errorf("%w: invalid string length", errCorruptedRecord)
+		}
+		values = append(values, string(payload[n:n+int(length)]))
+		payload = payload[n+int(length):]
+	}
+
+	return Record{Command: values[0], Args: values[1:]}, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+
+	return append(buf, s...)
+}
@@ -0,0 +1,343 @@
+package wal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"umemory/internal"
+
+	"go.uber.org/zap"
+)
+
+const (
+	FsyncAlways      = "always"
+	FsyncEverySecond = "every_second"
+	FsyncNever       = "never"
+
+	defaultMaxSegmentSize = 10 << 20
+
+	segmentPrefix = "segment_"
+	segmentSuffix = ".wal"
+)
+
+// WAL appends write commands to segment files, so that the keyspace can be
+// restored by replaying them after a restart.
+type WAL struct {
+	mu sync.Mutex
+
+	directory      string
+	fsyncPolicy    string
+	maxSegmentSize int64
+
+	segment     *os.File
+	segmentID   int
+	segmentSize int64
+	dirty       bool
+
+	logger *zap.Logger
+}
+
+func NewWAL(config internal.Config, logger *zap.Logger) (*WAL, error) {
+	if logger == nil {
+		return nil, errors.New("logger is invalid")
+	}
+	if config.WAL.Directory == "" {
+		return nil, errors.New("wal directory is not set")
+	}
+
+	wal := &WAL{
+		directory:      config.WAL.Directory,
+		fsyncPolicy:    FsyncEverySecond,
+		maxSegmentSize: defaultMaxSegmentSize,
+		logger:         logger,
+	}
+
+	switch config.WAL.FsyncPolicy {
+	case "":
+	case FsyncAlways, FsyncEverySecond, FsyncNever:
+		wal.fsyncPolicy = config.WAL.FsyncPolicy
+	default:
+		return nil, fmt.Errorf("unknown wal fsync policy %q", config.WAL.FsyncPolicy)
+	}
+	if config.WAL.MaxSegmentSize > 0 {
+		wal.maxSegmentSize = int64(config.WAL.MaxSegmentSize)
+	}
+
+	if err := os.MkdirAll(wal.directory, 0o755); err != nil {
+		return nil, fmt.Errorf("create wal directory error: %w", err)
+	}
+
+	ids, err := wal.segmentIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		wal.segmentID = ids[len(ids)-1]
+	}
+
+	return wal, nil
+}
+
+// Replay reads segments newer than afterSegment in order and passes every
+// record to apply, segments up to afterSegment are already covered by a
+// snapshot. It must be called before the first Append. A record torn by a
+// crash at the end of the last segment is cut off, any other damage is an
+// error.
+func (w *WAL) Replay(afterSegment int, apply func(command string, args []string) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids, err := w.segmentIDs()
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if id <= afterSegment {
+			continue
+		}
+
+		last := i == len(ids)-1
+		if err := w.replaySegment(id, last, apply); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *WAL) replaySegment(id int, last bool, apply func(command string, args []string) error) error {
+	path := w.segmentPath(id)
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open wal segment error: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat wal segment error: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		record, n, err := readRecord(reader, info.Size()-offset)
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, errTornRecord) && last {
+			w.logger.Warn(
+				"WAL: torn record at the end of the last segment, truncating",
+				zap.String("segment", path),
+				zap.Int64("offset", offset),
+			)
+
+			return os.Truncate(path, offset)
+		}
+		if err != nil {
+			return fmt.Errorf("read wal segment %s at offset %d error: %w", path, offset, err)
+		}
+
+		if err := apply(record.Command, record.Args); err != nil {
+			return fmt.Errorf("apply wal record from segment %s at offset %d error: %w", path, offset, err)
+		}
+		offset += int64(n)
+	}
+}
+
+// Append writes the command to the current segment, rotating it when it
+// grows above the configured size.
+func (w *WAL) Append(command string, args []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.segment == nil || w.segmentSize >= w.maxSegmentSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	data := encodeRecord(Record{Command: command, Args: args})
+	n, err := w.segment.Write(data)
+	w.segmentSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("write wal segment error: %w", err)
+	}
+
+	if w.fsyncPolicy == FsyncAlways {
+		if err := w.segment.Sync(); err != nil {
+			return fmt.Errorf("sync wal segment error: %w", err)
+		}
+	} else {
+		w.dirty = true
+	}
+
+	return nil
+}
+
+// Rotate closes the current segment, so that the following records go to
+// a new one, and returns the id of the last closed segment.
+func (w *WAL) Rotate() (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.segment == nil {
+		return w.segmentID, nil
+	}
+
+	if err := w.closeSegment(); err != nil {
+		return 0, err
+	}
+
+	return w.segmentID, nil
+}
+
+// RemoveSegments deletes segments with ids up to the given one, once they
+// are covered by a snapshot.
+func (w *WAL) RemoveSegments(upTo int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids, err := w.segmentIDs()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if id > upTo || (w.segment != nil && id == w.segmentID) {
+			continue
+		}
+		if err := os.Remove(w.segmentPath(id)); err != nil {
+			return fmt.Errorf("remove wal segment error: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Run syncs the current segment to disk every second when the
+// every_second fsync policy is used, until ctx is done.
+func (w *WAL) Run(ctx context.Context) {
+	if w.fsyncPolicy != FsyncEverySecond {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Sync(); err != nil {
+				w.logger.Error("WAL: sync error", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sync()
+}
+
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.segment == nil {
+		return nil
+	}
+
+	return w.closeSegment()
+}
+
+func (w *WAL) sync() error {
+	if w.segment == nil || !w.dirty {
+		return nil
+	}
+
+	if err := w.segment.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+
+	return nil
+}
+
+func (w *WAL) closeSegment() error {
+	if err := w.segment.Sync(); err != nil {
+		return fmt.Errorf("sync wal segment error: %w", err)
+	}
+	err := w.segment.Close()
+	w.segment = nil
+	w.dirty = false
+	if err != nil {
+		return fmt.Errorf("close wal segment error: %w", err)
+	}
+
+	return nil
+}
+
+// rotate closes the current segment and starts a new one, every process
+// start appends to a fresh segment.
+func (w *WAL) rotate() error {
+	if w.segment != nil {
+		if err := w.closeSegment(); err != nil {
+			return err
+		}
+	}
+
+	w.segmentID++
+	segment, err := os.OpenFile(w.segmentPath(w.segmentID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("create wal segment error: %w", err)
+	}
+
+	w.segment = segment
+	w.segmentSize = 0
+	w.dirty = false
+
+	return nil
+}
+
+func (w *WAL) segmentIDs() ([]int, error) {
+	entries, err := os.ReadDir(w.directory)
+	if err != nil {
+		return nil, fmt.Errorf("read wal directory error: %w", err)
+	}
+
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+func (w *WAL) segmentPath(id int) string {
+	return filepath.Join(w.directory, fmt.Sprintf("%s%06d%s", segmentPrefix, id, segmentSuffix))
+}